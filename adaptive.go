@@ -0,0 +1,55 @@
+package Flow
+
+import (
+	"time"
+)
+
+// adaptiveLimit is the effective concurrency of a parallel batch with
+// "adaptive_concurrency", tuned between 1 and "parallel_limit" by additive
+// increase and multiplicative decrease: every attempt that fails with a
+// retryable error, or takes longer than "adaptive_latency", halves the limit,
+// and a run of successes as long as the current limit raises it by one. Failures
+// of attempts that started before the last decrease don't decrease it again, so
+// one burst of errors from the calls already in flight halves it only once.
+type adaptiveLimit struct {
+	node      string
+	max       int
+	current   int
+	latency   time.Duration // slowest attempt not counted as overload, or 0
+	successes int           // since the last change
+	cut       time.Time     // time of the last decrease
+}
+
+// newAdaptiveLimit creates a limit for the named node, starting at max
+func newAdaptiveLimit(node string, max int, latency time.Duration) *adaptiveLimit {
+	a := &adaptiveLimit{node: node, max: max, current: max, latency: latency}
+	setGauge(GaugeBatchConcurrency, node, float64(a.current))
+	return a
+}
+
+// observe adjusts the limit after an attempt that started at started and took
+// elapsed, and reports whether it was raised
+func (a *adaptiveLimit) observe(started time.Time, elapsed time.Duration, err error) bool {
+	overloaded := (err != nil && IsRetryable(err)) || (a.latency > 0 && elapsed > a.latency)
+	if overloaded {
+		a.successes = 0
+		if started.Before(a.cut) || a.current == 1 {
+			return false
+		}
+		a.current = max(a.current/2, 1)
+		a.cut = time.Now()
+		setGauge(GaugeBatchConcurrency, a.node, float64(a.current))
+		return false
+	}
+	if err != nil || a.current == a.max {
+		return false
+	}
+	a.successes++
+	if a.successes < a.current {
+		return false
+	}
+	a.successes = 0
+	a.current++
+	setGauge(GaugeBatchConcurrency, a.node, float64(a.current))
+	return true
+}
@@ -0,0 +1,90 @@
+package Flow
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestAdaptiveLimit tests the increase and decrease of the adaptive limit
+func TestAdaptiveLimit(t *testing.T) {
+	a := newAdaptiveLimit("test-adaptive-limit", 8, 50*time.Millisecond)
+	overload := errors.New("overloaded")
+
+	before := time.Now()
+	a.observe(before, time.Millisecond, overload)
+	if a.current != 4 {
+		t.Fatalf("Expected a failure to halve the limit to 4, got %d", a.current)
+	}
+	a.observe(before, time.Millisecond, overload)
+	if a.current != 4 {
+		t.Errorf("Expected failures of calls started before the decrease to be ignored, got %d", a.current)
+	}
+	a.observe(time.Now(), time.Second, nil)
+	if a.current != 2 {
+		t.Errorf("Expected a slow attempt to halve the limit to 2, got %d", a.current)
+	}
+	a.observe(time.Now(), time.Millisecond, Permanent(overload))
+	if a.current != 2 {
+		t.Errorf("Expected permanent errors to leave the limit alone, got %d", a.current)
+	}
+
+	for i := 0; i < 2+3; i++ {
+		a.observe(time.Now(), time.Millisecond, nil)
+	}
+	if a.current != 4 {
+		t.Errorf("Expected runs of successes to raise the limit to 4, got %d", a.current)
+	}
+	if got := gaugeValue(GaugeBatchConcurrency, "test-adaptive-limit"); got != 4 {
+		t.Errorf("Expected the gauge to report 4, got %v", got)
+	}
+}
+
+// TestAdaptiveConcurrency tests that a parallel batch backs off a downstream
+// service that fails under load
+func TestAdaptiveConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var concurrent, overloads int
+	node := NewNode()
+	node.SetName("test-adaptive-concurrency")
+	node.SetParams(map[string]interface{}{
+		"batch":                true,
+		"parallel":             true,
+		"parallel_limit":       16,
+		"adaptive_concurrency": true,
+		"retries":              10,
+		"retry_delay":          time.Millisecond,
+		"data":                 make([]interface{}, 64),
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		mu.Lock()
+		concurrent++
+		full := concurrent > 3
+		if full {
+			overloads++
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			concurrent--
+			mu.Unlock()
+		}()
+		if full {
+			return nil, errors.New("service overloaded")
+		}
+		time.Sleep(2 * time.Millisecond)
+		return "ok", nil
+	})
+
+	state := NewSharedState()
+	if action := node.Run(state); action != BatchCompleteAction {
+		t.Fatalf("Expected %q, got %q", BatchCompleteAction, action)
+	}
+	if got := gaugeValue(GaugeBatchConcurrency, "test-adaptive-concurrency"); got >= 16 {
+		t.Errorf("Expected the concurrency to be lowered, got %v", got)
+	}
+	if overloads > 64 {
+		t.Errorf("Expected fewer overload errors than items, got %d", overloads)
+	}
+}
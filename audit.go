@@ -0,0 +1,138 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditEntry records a single branch decision made during a flow run:
+// which node ran, the action it chose, and the state keys it wrote.
+type AuditEntry struct {
+	// Sequence is the one-based position of the entry within the run
+	Sequence int `json:"sequence"`
+	// Time is when the node finished
+	Time time.Time `json:"time"`
+	// Flow is the name of the flow
+	Flow string `json:"flow,omitempty"`
+	// Node is the name of the node that made the decision
+	Node string `json:"node,omitempty"`
+	// CorrelationID is the run's correlation ID, if any
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// Action is the action the node returned
+	Action string `json:"action"`
+	// Mutations holds the new value of every state key written by the node,
+	// including the nodes it runs itself, such as those of a nested flow or the
+	// branches of a StartAll join, but not by nodes running concurrently with it
+	Mutations map[string]interface{} `json:"mutations,omitempty"`
+}
+
+// AuditSink receives audit entries as a flow runs. Sinks must treat the trail as
+// append-only. An error returned by Record fails the run, so decisions are never
+// made without being recorded.
+type AuditSink interface {
+	Record(entry AuditEntry) error
+}
+
+// SetAuditSink sets the sink that receives an AuditEntry for every node executed
+// by the flow. Passing nil disables auditing.
+//
+// Example:
+//
+//	trail := flow.NewMemoryAuditSink()
+//	pipeline.SetAuditSink(trail)
+//	pipeline.Run(state)
+//	for _, entry := range trail.Entries() {
+//		fmt.Printf("%s -> %s %v\n", entry.Node, entry.Action, entry.Mutations)
+//	}
+func (f *Flow) SetAuditSink(sink AuditSink) {
+	f.audit = sink
+}
+
+// auditSequenceKey is the context key of the auditSequence of a flow's run
+type auditSequenceKey struct{}
+
+// auditSequence numbers the audit entries of one run of a flow, across the
+// concurrent branches and source items it orchestrates
+type auditSequence struct {
+	flow *Flow
+	last atomic.Int64
+}
+
+// withAuditSequence attaches a sequence for the run of f to ctx, unless an
+// enclosing orchestration of the same run already did
+func withAuditSequence(ctx context.Context, f *Flow) context.Context {
+	if seq, ok := ctx.Value(auditSequenceKey{}).(*auditSequence); ok && seq.flow == f {
+		return ctx
+	}
+	return context.WithValue(ctx, auditSequenceKey{}, &auditSequence{flow: f})
+}
+
+// recordAudit sends the decision of node to the flow's audit sink. The node is
+// passed in rather than read from the run's scope, which concurrent branches share.
+func (f *Flow) recordAudit(ctx context.Context, node *Node, action string, mutations map[string]interface{}) {
+	var sequence int
+	if seq, ok := ctx.Value(auditSequenceKey{}).(*auditSequence); ok {
+		sequence = int(seq.last.Add(1))
+	}
+	entry := AuditEntry{
+		Sequence:      sequence,
+		Time:          time.Now(),
+		Flow:          f.name,
+		Node:          node.name,
+		CorrelationID: CorrelationIDFrom(ctx),
+		Action:        action,
+		Mutations:     mutations,
+	}
+	if err := f.audit.Record(entry); err != nil {
+		panic(fmt.Errorf("flow: audit sink failed: %w", err))
+	}
+}
+
+// MemoryAuditSink keeps audit entries in memory
+type MemoryAuditSink struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// NewMemoryAuditSink creates an empty in-memory audit sink
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{}
+}
+
+// Record appends the entry to the trail
+func (m *MemoryAuditSink) Record(entry AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// Entries returns a copy of the recorded trail in order
+func (m *MemoryAuditSink) Entries() []AuditEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]AuditEntry(nil), m.entries...)
+}
+
+// WriterAuditSink writes each audit entry as a line of JSON to an io.Writer
+type WriterAuditSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterAuditSink creates a sink writing JSON lines to w (e.g. an append-only file)
+func NewWriterAuditSink(w io.Writer) *WriterAuditSink {
+	return &WriterAuditSink{enc: json.NewEncoder(w)}
+}
+
+// Record writes the entry as one JSON line
+func (w *WriterAuditSink) Record(entry AuditEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(entry)
+}
@@ -0,0 +1,130 @@
+package Flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// buildApprovalFlow creates a two-step flow that writes state and branches
+func buildApprovalFlow() *Flow {
+	score := NewNode()
+	score.SetName("score")
+	score.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		shared.Set("risk_score", 12)
+		return "low_risk", nil
+	})
+
+	approve := NewNode()
+	approve.SetName("approve")
+	approve.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		shared.Set("decision", "approved")
+		return "approved", nil
+	})
+	score.Next(approve, "low_risk")
+
+	pipeline := NewFlow().Start(score)
+	pipeline.SetName("loan")
+	return pipeline
+}
+
+// TestAuditTrail tests that each decision and its state mutations are recorded
+func TestAuditTrail(t *testing.T) {
+	pipeline := buildApprovalFlow()
+	trail := NewMemoryAuditSink()
+	pipeline.SetAuditSink(trail)
+
+	state := NewSharedState()
+	state.Set("applicant", "alice") // Written before the run, not attributed to any node
+	pipeline.Run(state)
+
+	entries := trail.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	first, second := entries[0], entries[1]
+	if first.Sequence != 1 || first.Node != "score" || first.Action != "low_risk" || first.Flow != "loan" {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+	if len(first.Mutations) != 1 || first.Mutations["risk_score"] != 12 {
+		t.Errorf("Expected only risk_score mutation, got %v", first.Mutations)
+	}
+	if second.Sequence != 2 || second.Node != "approve" || second.Mutations["decision"] != "approved" {
+		t.Errorf("Unexpected second entry: %+v", second)
+	}
+	if _, ok := second.Mutations["risk_score"]; ok {
+		t.Error("Expected mutations to be attributed to the node that made them")
+	}
+}
+
+// TestWriterAuditSink tests JSON lines output
+func TestWriterAuditSink(t *testing.T) {
+	pipeline := buildApprovalFlow()
+	var buf bytes.Buffer
+	pipeline.SetAuditSink(NewWriterAuditSink(&buf))
+
+	pipeline.Run(NewSharedState())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %d", len(lines))
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if entry.Node != "approve" || entry.Action != "approved" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+}
+
+// TestAuditConcurrentBranches tests that entries of StartAll branches are attributed to their own nodes
+func TestAuditConcurrentBranches(t *testing.T) {
+	var running sync.WaitGroup
+	running.Add(2)
+	branch := func(name string) *Node {
+		n := NewNode()
+		n.SetName(name)
+		n.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+			// Both branches write while the other is running
+			running.Done()
+			running.Wait()
+			shared.Set(name+"_loaded", true)
+			return nil, nil
+		})
+		return n
+	}
+
+	pipeline := NewFlow()
+	pipeline.StartAll(branch("config"), branch("user"))
+	trail := NewMemoryAuditSink()
+	pipeline.SetAuditSink(trail)
+	pipeline.Run(NewSharedState())
+
+	entries := trail.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected entries for both branches and the join, got %+v", entries)
+	}
+	sequences := map[int]bool{}
+	for _, entry := range entries {
+		sequences[entry.Sequence] = true
+		switch entry.Node {
+		case "config", "user":
+			if len(entry.Mutations) != 1 || entry.Mutations[entry.Node+"_loaded"] != true {
+				t.Errorf("Expected %s to be credited with its own write only, got %v", entry.Node, entry.Mutations)
+			}
+		case "start_all":
+			if len(entry.Mutations) != 2 {
+				t.Errorf("Expected the join to include the writes of its branches, got %v", entry.Mutations)
+			}
+		default:
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+	}
+	if !sequences[1] || !sequences[2] || !sequences[3] {
+		t.Errorf("Expected distinct sequence numbers across branches, got %+v", entries)
+	}
+}
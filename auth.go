@@ -0,0 +1,108 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUnauthorized is returned when the Authorizer denies an operation
+var ErrUnauthorized = errors.New("flow: unauthorized")
+
+// Operations checked by a Runner's Authorizer
+const (
+	// AuthRun starts a registered flow by name (RunNamed, RunDocument)
+	AuthRun = "run"
+	// AuthList lists a run through the admin API
+	AuthList = "list"
+	// AuthCancel cancels a run through the admin API
+	AuthCancel = "cancel"
+	// AuthRetry retries a failed or interrupted run through the admin API
+	AuthRetry = "retry"
+	// AuthDiscard discards the checkpoint of a failed or interrupted run through the admin API
+	AuthDiscard = "discard"
+	// AuthNodeTypes lists the registered node types through the admin API
+	AuthNodeTypes = "node_types"
+)
+
+// AuthRequest describes an operation the Authorizer is asked to allow
+type AuthRequest struct {
+	// Action is the operation: AuthRun, AuthList, AuthCancel, AuthRetry, AuthDiscard or AuthNodeTypes
+	Action string
+	// Flow is the name of the flow the operation applies to ("" for AuthNodeTypes)
+	Flow string
+	// Tenant is the tenant of the run, from TenantKey in its state ("" if untagged)
+	Tenant string
+	// RunID identifies the run for admin operations
+	RunID string
+	// HTTP is the admin API request carrying the caller's credentials; nil for
+	// in-process calls
+	HTTP *http.Request
+}
+
+// Authorizer decides whether an operation on a Runner is allowed, returning nil
+// to allow it and an error to deny it. Register one with Runner.SetAuthorizer.
+//
+// Example:
+//
+//	runner.SetAuthorizer(flow.AuthorizerFunc(func(ctx context.Context, req flow.AuthRequest) error {
+//		if req.HTTP == nil {
+//			return nil // in-process callers are trusted
+//		}
+//		if tenantForToken(req.HTTP.Header.Get("Authorization")) != req.Tenant {
+//			return errors.New("token not valid for tenant")
+//		}
+//		return nil
+//	}))
+type Authorizer interface {
+	Authorize(ctx context.Context, req AuthRequest) error
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface
+type AuthorizerFunc func(ctx context.Context, req AuthRequest) error
+
+// Authorize calls fn(ctx, req)
+func (fn AuthorizerFunc) Authorize(ctx context.Context, req AuthRequest) error {
+	return fn(ctx, req)
+}
+
+// SetAuthorizer sets the Authorizer consulted by RunNamed, RunDocument and the
+// admin API. Without one, in-process calls are allowed and every admin API
+// request is denied, so runs are never remotely controllable by default.
+func (r *Runner) SetAuthorizer(a Authorizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authorizer = a
+}
+
+// authorize consults the Authorizer, returning an error wrapping ErrUnauthorized
+// if it denies req. Requests over HTTP are denied when no Authorizer is set.
+func (r *Runner) authorize(ctx context.Context, req AuthRequest) error {
+	r.mu.Lock()
+	a := r.authorizer
+	r.mu.Unlock()
+
+	if a == nil {
+		if req.HTTP != nil {
+			return fmt.Errorf("%w: %s %s: no authorizer configured", ErrUnauthorized, req.Action, req.Flow)
+		}
+		return nil
+	}
+	if err := a.Authorize(ctx, req); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return err
+		}
+		return fmt.Errorf("%w: %s %s: %w", ErrUnauthorized, req.Action, req.Flow, err)
+	}
+	return nil
+}
+
+// tenantOf returns the tenant stored under TenantKey in shared, or ""
+func tenantOf(shared *SharedState) string {
+	if shared == nil {
+		return ""
+	}
+	tenant, _ := shared.Get(TenantKey).(string)
+	return tenant
+}
@@ -0,0 +1,171 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRunNamedAuthorizer tests that named runs are checked with the run's flow and tenant
+func TestRunNamedAuthorizer(t *testing.T) {
+	runner := NewRunner()
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return "done", nil })
+	pipeline := NewFlow().Start(node)
+	pipeline.SetName("billing")
+	runner.Register(pipeline)
+
+	// In-process runs are allowed without an authorizer
+	if _, err := runner.RunNamed("billing", NewSharedState()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var seen []AuthRequest
+	runner.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, req AuthRequest) error {
+		seen = append(seen, req)
+		if req.Tenant != "acme" {
+			return errors.New("tenant not allowed")
+		}
+		return nil
+	}))
+
+	shared := NewSharedState()
+	shared.Set(TenantKey, "acme")
+	if action, err := runner.RunNamed("billing", shared); err != nil || action != "done" {
+		t.Errorf("Expected the acme run to be allowed, got %q, %v", action, err)
+	}
+	_, err := runner.RunNamed("billing", NewSharedState())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+	if len(seen) != 2 || seen[0].Action != AuthRun || seen[0].Flow != "billing" || seen[0].HTTP != nil {
+		t.Errorf("Unexpected authorization requests: %+v", seen)
+	}
+}
+
+// TestRunNamedContextAuthorizer tests that the caller's ctx reaches the Authorizer
+func TestRunNamedContextAuthorizer(t *testing.T) {
+	type callerKey struct{}
+	runner := NewRunner()
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return "done", nil })
+	pipeline := NewFlow().Start(node)
+	pipeline.SetName("billing")
+	runner.Register(pipeline)
+	runner.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, req AuthRequest) error {
+		if ctx.Value(callerKey{}) != "ops" {
+			return errors.New("caller not allowed")
+		}
+		return nil
+	}))
+
+	ctx := context.WithValue(context.Background(), callerKey{}, "ops")
+	if action, err := runner.RunNamedContext(ctx, "billing", NewSharedState()); err != nil || action != "done" {
+		t.Errorf("Expected the ops caller to be allowed, got %q, %v", action, err)
+	}
+	if _, err := runner.RunNamed("billing", NewSharedState()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized without the caller, got %v", err)
+	}
+
+	var out strings.Builder
+	if err := runner.RunDocumentContext(ctx, "billing", strings.NewReader(`{}`), &out); err != nil {
+		t.Errorf("Expected the ops caller's document run to be allowed, got %v", err)
+	}
+	if err := runner.RunDocument("billing", strings.NewReader(`{}`), &out); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized for the document run without the caller, got %v", err)
+	}
+}
+
+// TestAdminHandlerAuthorization tests default denial and per-tenant filtering of the admin API
+func TestAdminHandlerAuthorization(t *testing.T) {
+	runner := NewRunner()
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return "done", nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetName("auth-test")
+
+	done := make(chan struct{}, 2)
+	for _, tenant := range []string{"acme", "globex"} {
+		shared := NewSharedState()
+		shared.Set(TenantKey, tenant)
+		go func() {
+			_, _ = runner.Run(pipeline, shared)
+			done <- struct{}{}
+		}()
+		<-started
+	}
+	defer func() {
+		close(release)
+		<-done
+		<-done
+	}()
+
+	server := httptest.NewServer(runner.AdminHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/runs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var runs []ActiveRun
+	_ = json.NewDecoder(resp.Body).Decode(&runs)
+	resp.Body.Close()
+	if len(runs) != 0 {
+		t.Errorf("Expected no runs listed without an authorizer, got %+v", runs)
+	}
+
+	var globexID string
+	for _, info := range runner.Active() {
+		if info.Tenant == "globex" {
+			globexID = info.ID
+		}
+	}
+	resp, err = http.Post(server.URL+"/runs/"+globexID+"/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 without an authorizer, got %d", resp.StatusCode)
+	}
+
+	runner.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, req AuthRequest) error {
+		if req.HTTP.Header.Get("X-Tenant") != req.Tenant {
+			return errors.New("wrong tenant")
+		}
+		return nil
+	}))
+	get, _ := http.NewRequest(http.MethodGet, server.URL+"/runs", nil)
+	get.Header.Set("X-Tenant", "acme")
+	resp, err = http.DefaultClient.Do(get)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	runs = nil
+	_ = json.NewDecoder(resp.Body).Decode(&runs)
+	resp.Body.Close()
+	if len(runs) != 1 || runs[0].Tenant != "acme" {
+		t.Errorf("Expected only the acme run, got %+v", runs)
+	}
+
+	cancel, _ := http.NewRequest(http.MethodPost, server.URL+"/runs/"+globexID+"/cancel", nil)
+	cancel.Header.Set("X-Tenant", "acme")
+	resp, err = http.DefaultClient.Do(cancel)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 cancelling another tenant's run, got %d", resp.StatusCode)
+	}
+}
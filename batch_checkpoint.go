@@ -0,0 +1,82 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchCheckpoint is the incremental progress of a batch passed to the function
+// registered with SetBatchCheckpointFunc
+type BatchCheckpoint struct {
+	// Node is the name of the batch node
+	Node string
+	// Completed is the number of items completed so far
+	Completed int
+	// Total is the number of items in the batch
+	Total int
+	// Results holds the result of every completed item, keyed by item index
+	Results map[int]interface{}
+}
+
+// SetBatchCheckpointFunc sets a function called every "checkpoint_every" completed
+// batch items with the accumulated results, so external systems can persist the
+// incremental progress of long batch jobs. Calls are serialized, even in parallel
+// mode, and run on the goroutine that completed the item.
+//
+// Example:
+//
+//	node.SetParams(map[string]interface{}{
+//		"data":             records,
+//		"batch":            true,
+//		"checkpoint_every": 1000,
+//	})
+//	node.SetBatchCheckpointFunc(func(shared *flow.SharedState, cp flow.BatchCheckpoint) {
+//		store.SaveProgress(cp.Node, cp.Completed, cp.Results)
+//	})
+func (n *Node) SetBatchCheckpointFunc(fn func(*SharedState, BatchCheckpoint)) {
+	n.checkpointFunc = fn
+}
+
+// batchCheckpointer accumulates completed results and invokes the checkpoint func.
+// All methods are no-ops on a nil checkpointer.
+type batchCheckpointer struct {
+	mu      sync.Mutex
+	node    *Node
+	every   int
+	total   int
+	results map[int]interface{}
+}
+
+// newBatchCheckpointer returns a checkpointer for total items if the node has a
+// checkpoint func and a positive "checkpoint_every"
+func (n *Node) newBatchCheckpointer(ctx context.Context, total int) *batchCheckpointer {
+	every := n.getIntParam(ctx, "checkpoint_every")
+	if n.checkpointFunc == nil || every <= 0 {
+		return nil
+	}
+	return &batchCheckpointer{node: n, every: every, total: total, results: make(map[int]interface{})}
+}
+
+// done records a completed item, checkpointing every "checkpoint_every" items
+func (c *batchCheckpointer) done(shared *SharedState, index int, result interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[index] = result
+	if len(c.results)%c.every != 0 {
+		return
+	}
+
+	results := make(map[int]interface{}, len(c.results))
+	for i, r := range c.results {
+		results[i] = r
+	}
+	c.node.checkpointFunc(shared, BatchCheckpoint{
+		Node:      c.node.name,
+		Completed: len(results),
+		Total:     c.total,
+		Results:   results,
+	})
+}
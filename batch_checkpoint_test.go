@@ -0,0 +1,46 @@
+package Flow
+
+import "testing"
+
+// TestBatchCheckpointEvery tests that the checkpoint func sees accumulated results every N items
+func TestBatchCheckpointEvery(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		items := make([]int, 10)
+		for i := range items {
+			items[i] = i
+		}
+
+		node := NewNode()
+		node.SetName("import")
+		node.SetParams(map[string]interface{}{
+			"data":             items,
+			"batch":            true,
+			"parallel":         parallel,
+			"parallel_limit":   3,
+			"checkpoint_every": 4,
+		})
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			return item.(int) * 2, nil
+		})
+
+		var checkpoints []BatchCheckpoint
+		node.SetBatchCheckpointFunc(func(shared *SharedState, cp BatchCheckpoint) {
+			checkpoints = append(checkpoints, cp)
+		})
+		node.Run(NewSharedState())
+
+		if len(checkpoints) != 2 {
+			t.Fatalf("Expected 2 checkpoints (parallel: %v), got %d", parallel, len(checkpoints))
+		}
+		for i, cp := range checkpoints {
+			if cp.Node != "import" || cp.Total != 10 || cp.Completed != 4*(i+1) || len(cp.Results) != cp.Completed {
+				t.Errorf("Unexpected checkpoint %d (parallel: %v): %+v", i, parallel, cp)
+			}
+			for index, result := range cp.Results {
+				if result != index*2 {
+					t.Errorf("Expected result %d for item %d, got %v", index*2, index, result)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchMetadataKey is the SharedState key under which batch nodes with
+// "batch_metadata": true store a []BatchItemMetadata parallel to batch_results
+const BatchMetadataKey = "batch_metadata"
+
+// BatchItemMetadata describes how a single batch item was processed, so slow or
+// flaky items can be identified without external instrumentation.
+//
+// Example:
+//
+//	for _, item := range state.Get(flow.BatchMetadataKey).([]flow.BatchItemMetadata) {
+//		if item.Attempts > 1 || item.Duration > time.Second {
+//			log.Printf("item %d: %d attempts in %v on worker %d", item.Index, item.Attempts, item.Duration, item.Worker)
+//		}
+//	}
+type BatchItemMetadata struct {
+	// Index is the position of the item in the batch data
+	Index int
+	// Worker is the parallel worker slot that processed the item (0 for sequential
+	// batches), or -1 if the item was never started
+	Worker int
+	// Duration is the time spent on the item, including retries and backoff
+	Duration time.Duration
+	// Attempts is the number of exec calls made for the item
+	Attempts int
+	// Err is the item's final error, if it failed
+	Err error
+}
+
+// batchMetaRecorder collects per-item metadata when "batch_metadata" is enabled.
+// All methods are no-ops on a nil recorder.
+type batchMetaRecorder struct {
+	mu    sync.Mutex
+	items []BatchItemMetadata
+}
+
+// newBatchMetaRecorder returns a recorder for total items if the node asked for metadata
+func (n *Node) newBatchMetaRecorder(ctx context.Context, total int) *batchMetaRecorder {
+	if !n.getBoolParam(ctx, "batch_metadata") {
+		return nil
+	}
+	items := make([]BatchItemMetadata, total)
+	for i := range items {
+		items[i] = BatchItemMetadata{Index: i, Worker: -1}
+	}
+	return &batchMetaRecorder{items: items}
+}
+
+// itemRecorder returns the retry recorder to use for one item: a child of rec that
+// counts the item's own attempts when metadata is collected, or rec itself
+func (m *batchMetaRecorder) itemRecorder(rec *retryRecorder) *retryRecorder {
+	if m == nil {
+		return rec
+	}
+	return &retryRecorder{parent: rec}
+}
+
+// record stores the outcome of one item
+func (m *batchMetaRecorder) record(index, worker int, started time.Time, rec *retryRecorder, err error) {
+	if m == nil {
+		return
+	}
+	rec.mu.Lock()
+	attempts := rec.telemetry.Attempts
+	rec.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[index] = BatchItemMetadata{
+		Index:    index,
+		Worker:   worker,
+		Duration: time.Since(started),
+		Attempts: attempts,
+		Err:      err,
+	}
+}
+
+// store writes the collected metadata to shared state
+func (m *batchMetaRecorder) store(shared *SharedState) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	shared.Set(BatchMetadataKey, append([]BatchItemMetadata(nil), m.items...))
+}
@@ -0,0 +1,387 @@
+package Flow
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SetItemWeightFunc sets the function computing how much each item of a parallel
+// batch counts against "parallel_limit", such as its payload size in megabytes,
+// so a few huge items are not worked on together just because their count is
+// under the limit. Weights are clamped to between 1 and the limit, and items
+// still start in order: an item waits for enough weight to free up rather than
+// being overtaken, and an item at least as heavy as the limit runs alone.
+//
+// Example:
+//
+//	node.SetParams(map[string]interface{}{"batch": true, "parallel": true, "parallel_limit": 64})
+//	node.SetItemWeightFunc(func(item interface{}) int {
+//		return len(item.([]byte)) >> 20 // at most 64 MB of payloads at a time
+//	})
+func (n *Node) SetItemWeightFunc(fn func(item interface{}) int) {
+	n.weightFunc = fn
+}
+
+// batchItem is a parallel batch item that has started its attempts
+type batchItem struct {
+	index   int
+	started time.Time
+	rec     *retryRecorder
+	call    *execCall
+	resume  time.Time // when a parked item's backoff elapses
+}
+
+// batchQueue dispatches the items of a parallel batch to at most limit workers.
+// Items waiting out a retry backoff are parked in a delay queue served by a single
+// timer rather than in sleeping goroutines, and workers exit while there is
+// nothing to do, so tens of thousands of pending retries cost a heap entry each
+// instead of a goroutine stack.
+type batchQueue struct {
+	ctx   context.Context
+	limit int
+	total int
+	skip  func(index int) bool
+	work  func(q *batchQueue, worker int, item *batchItem, index int)
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup // running workers and parked items
+	next    int            // next item index not yet started
+	due     []*batchItem   // parked items whose backoff has elapsed
+	parked  parkedItems    // items waiting out their backoff, earliest first
+	timer   *time.Timer    // fires when the earliest parked item is due
+	running int
+	free    []int // worker IDs not in use
+
+	// weights are the costs of the items against limit, or nil for one each;
+	// see weighted. load is the weight of the items being worked on.
+	weights []int
+	load    int
+
+	// adaptive lowers the load allowed below limit while attempts fail; see adapt
+	adaptive *adaptiveLimit
+
+	// coordinated pauses every item while one backs off; see coordinate
+	coordinated bool
+	pausedUntil time.Time
+	pause       *time.Timer // ends the pause, holding the wait group while pending
+	probing     bool        // attempts run one at a time until one succeeds
+
+	// rng picks the next attempt of a deterministic queue; see deterministic
+	rng *rand.Rand
+}
+
+// newBatchQueue creates a queue over total items. Items for which skip returns
+// true are never started, and no further items are started once ctx is done.
+// work is called with a nil item to start the item at index, or with an item
+// whose backoff has elapsed to resume it; it may park the item again.
+func newBatchQueue(ctx context.Context, total, limit int, skip func(int) bool, work func(q *batchQueue, worker int, item *batchItem, index int)) *batchQueue {
+	q := &batchQueue{ctx: ctx, limit: limit, total: total, skip: skip, work: work}
+	for w := limit - 1; w >= 0; w-- {
+		q.free = append(q.free, w)
+	}
+	return q
+}
+
+// deterministic makes run process the items on the calling goroutine, one
+// attempt at a time, picking the next attempt among the next new item and the
+// items due a retry with a generator seeded by seed. Backoffs are not slept, so a
+// retry may come at any later pick, and item i is accounted to worker i % limit;
+// a given seed always yields the same interleaving.
+func (q *batchQueue) deterministic(seed int64) *batchQueue {
+	q.rng = rand.New(rand.NewSource(seed))
+	return q
+}
+
+// weighted makes each item count for weights[index] against limit instead of
+// one. Weights are clamped to [1, limit], and an item waits until the load of
+// the items being worked on leaves room for it rather than being overtaken.
+func (q *batchQueue) weighted(weights []int) *batchQueue {
+	for i, w := range weights {
+		weights[i] = min(max(w, 1), q.limit)
+	}
+	q.weights = weights
+	return q
+}
+
+// adapt makes the load allowed follow adaptive, which the work function feeds
+// with the outcome of each attempt through observe
+func (q *batchQueue) adapt(adaptive *adaptiveLimit) *batchQueue {
+	q.adaptive = adaptive
+	return q
+}
+
+// coordinate shares backoffs between items: an attempt that fails and backs off
+// pauses the whole batch until its backoff elapses, so items failing together
+// don't retry together. After a pause, attempts run one at a time until one
+// succeeds, so a single probe finds out whether the failures are over.
+func (q *batchQueue) coordinate() *batchQueue {
+	q.coordinated = true
+	return q
+}
+
+// observe reports the outcome of an attempt that started at started to the
+// adaptive limit and the coordinated backoff, if any. wait is how long the batch
+// should pause after a failure.
+func (q *batchQueue) observe(started time.Time, err error, wait time.Duration) {
+	if q.adaptive == nil && !q.coordinated {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.adaptive != nil {
+		q.adaptive.observe(started, time.Since(started), err)
+	}
+	if q.coordinated && q.rng == nil {
+		if err == nil {
+			q.probing = false
+		} else if wait > 0 {
+			q.pauseLocked(wait)
+		}
+	}
+	q.spawnLocked()
+}
+
+// pauseLocked stops attempts from starting until wait has elapsed, unless the
+// batch is already paused for longer
+func (q *batchQueue) pauseLocked(wait time.Duration) {
+	q.probing = true
+	until := time.Now().Add(wait)
+	if !until.After(q.pausedUntil) {
+		return
+	}
+	q.pausedUntil = until
+	if q.pause != nil && q.pause.Stop() {
+		q.pause.Reset(wait)
+		return
+	}
+	q.wg.Add(1)
+	q.pause = time.AfterFunc(wait, q.endPause)
+}
+
+// endPause starts workers once the pause has elapsed
+func (q *batchQueue) endPause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.spawnLocked()
+	q.wg.Done()
+}
+
+// run processes items until none are left to start and none are parked
+func (q *batchQueue) run() {
+	if q.rng != nil {
+		q.runDeterministic()
+		return
+	}
+	stop := context.AfterFunc(q.ctx, q.wakeAll)
+	defer stop()
+
+	q.mu.Lock()
+	q.spawnLocked()
+	q.mu.Unlock()
+	q.wg.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.timer != nil {
+		q.timer.Stop()
+	}
+}
+
+// runDeterministic implements run for a deterministic queue. Parked items don't
+// hold a worker, as with run, so new items keep starting while others back off.
+func (q *batchQueue) runDeterministic() {
+	for {
+		choices := len(q.due)
+		if q.startableLocked() {
+			choices++
+		}
+		if choices == 0 {
+			return
+		}
+		if pick := q.rng.Intn(choices); pick < len(q.due) {
+			item := q.due[pick]
+			q.due = append(q.due[:pick], q.due[pick+1:]...)
+			q.work(q, item.index%q.limit, item, item.index)
+		} else {
+			index := q.next
+			q.next++
+			q.work(q, index%q.limit, nil, index)
+		}
+	}
+}
+
+// park resumes item on a worker once wait has elapsed
+func (q *batchQueue) park(item *batchItem, wait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.rng != nil {
+		// The backoff is simulated: the item may resume at any later pick
+		q.due = append(q.due, item)
+		return
+	}
+	q.wg.Add(1)
+	item.resume = time.Now().Add(wait)
+	heap.Push(&q.parked, item)
+	if q.parked[0] == item {
+		q.scheduleLocked()
+	}
+}
+
+// scheduleLocked sets the timer for the earliest parked item
+func (q *batchQueue) scheduleLocked() {
+	wait := time.Until(q.parked[0].resume)
+	if q.timer == nil {
+		q.timer = time.AfterFunc(wait, q.wake)
+		return
+	}
+	q.timer.Reset(wait)
+}
+
+// wake moves parked items whose backoff has elapsed to the due list
+func (q *batchQueue) wake() {
+	q.wakeUntil(time.Now())
+}
+
+// wakeAll moves every parked item to the due list once ctx is done, so workers
+// abandon their backoff
+func (q *batchQueue) wakeAll() {
+	q.wakeUntil(time.Time{})
+}
+
+// wakeUntil moves parked items due by now to the due list; the zero time wakes all
+func (q *batchQueue) wakeUntil(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	woken := 0
+	if now.IsZero() {
+		q.pausedUntil = time.Time{}
+		if q.pause != nil && q.pause.Stop() {
+			woken++ // the pending pause leaves the wait group as well
+		}
+	}
+	for len(q.parked) > 0 && (now.IsZero() || !q.parked[0].resume.After(now)) {
+		q.due = append(q.due, heap.Pop(&q.parked).(*batchItem))
+		woken++
+	}
+	if len(q.parked) > 0 {
+		q.scheduleLocked()
+	}
+	// Workers for the woken items join the wait group before the items leave it
+	q.spawnLocked()
+	for ; woken > 0; woken-- {
+		q.wg.Done()
+	}
+}
+
+// spawnLocked starts workers while there is work for them and slots are free
+func (q *batchQueue) spawnLocked() {
+	for q.running < q.limit && q.fitsLocked() {
+		worker := q.free[len(q.free)-1]
+		q.free = q.free[:len(q.free)-1]
+		q.running++
+		q.wg.Add(1)
+		go q.worker(worker)
+	}
+}
+
+// hasWorkLocked reports whether an item is due or can be started, skipping past
+// items that are never started
+func (q *batchQueue) hasWorkLocked() bool {
+	return len(q.due) > 0 || q.startableLocked()
+}
+
+// fitsLocked reports whether the item a worker would take next fits within the
+// weight left under limit, or under the adaptive limit, and the batch isn't paused
+func (q *batchQueue) fitsLocked() bool {
+	if !q.hasWorkLocked() {
+		return false
+	}
+	if q.coordinated && q.ctx.Err() == nil {
+		// Attempts wait out the pause, then probe one at a time
+		if time.Now().Before(q.pausedUntil) || (q.probing && q.load > 0) {
+			return false
+		}
+	}
+	limit := q.limit
+	if q.adaptive != nil {
+		limit = q.adaptive.current
+	}
+	return q.load == 0 || q.load+q.nextWeightLocked() <= limit
+}
+
+// nextWeightLocked returns the weight of the item a worker would take next
+func (q *batchQueue) nextWeightLocked() int {
+	if q.weights == nil {
+		return 1
+	}
+	if len(q.due) > 0 {
+		return q.weights[q.due[0].index]
+	}
+	return q.weights[q.next]
+}
+
+// startableLocked reports whether an item can be started, skipping past items
+// that are never started
+func (q *batchQueue) startableLocked() bool {
+	for q.next < q.total && q.skip(q.next) {
+		q.next++
+	}
+	return q.next < q.total && q.ctx.Err() == nil
+}
+
+// worker processes items until there is nothing left to do right now, or the
+// next item is too heavy to join the ones being worked on.
+// Started items that were parked take precedence over new ones.
+func (q *batchQueue) worker(id int) {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		if !q.fitsLocked() {
+			q.running--
+			q.free = append(q.free, id)
+			q.mu.Unlock()
+			return
+		}
+		weight := q.nextWeightLocked()
+		q.load += weight
+		var item *batchItem
+		index := q.next
+		if len(q.due) > 0 {
+			item = q.due[0]
+			q.due = q.due[1:]
+		} else {
+			q.next++
+		}
+		q.mu.Unlock()
+
+		q.work(q, id, item, index)
+
+		q.mu.Lock()
+		q.load -= weight
+		// Items held back by the load may fit now
+		q.spawnLocked()
+		q.mu.Unlock()
+	}
+}
+
+// parkedItems is a heap of parked batch items, earliest resume time first
+type parkedItems []*batchItem
+
+func (p parkedItems) Len() int           { return len(p) }
+func (p parkedItems) Less(i, j int) bool { return p[i].resume.Before(p[j].resume) }
+func (p parkedItems) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (p *parkedItems) Push(x interface{}) {
+	*p = append(*p, x.(*batchItem))
+}
+
+func (p *parkedItems) Pop() interface{} {
+	old := *p
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*p = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,250 @@
+package Flow
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParallelRetriesParkWithoutGoroutines tests that items waiting out a backoff
+// don't each hold a goroutine
+func TestParallelRetriesParkWithoutGoroutines(t *testing.T) {
+	data := make([]int, 500)
+	for i := range data {
+		data[i] = i
+	}
+
+	var mu sync.Mutex
+	attempts := make(map[int]int)
+	peak := 0
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":           data,
+		"batch":          true,
+		"parallel":       true,
+		"parallel_limit": 4,
+		"retries":        2,
+		"retry_delay":    50 * time.Millisecond,
+		"batch_metadata": true,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[item.(int)]++
+		if g := runtime.NumGoroutine(); g > peak {
+			peak = g
+		}
+		if attempts[item.(int)] == 1 {
+			return nil, errors.New("transient")
+		}
+		return item.(int) * 2, nil
+	})
+
+	before := runtime.NumGoroutine()
+	state := NewSharedState()
+	node.Run(state)
+
+	if peak-before > 20 {
+		t.Errorf("Expected pending retries not to hold goroutines, peaked %d above baseline", peak-before)
+	}
+	results := state.GetSlice(BatchResultsKey)
+	for i, result := range results {
+		if result != i*2 {
+			t.Fatalf("Expected result %d for item %d, got %v", i*2, i, result)
+		}
+	}
+	for _, item := range state.Get(BatchMetadataKey).([]BatchItemMetadata) {
+		if item.Attempts != 2 || item.Worker < 0 || item.Worker >= 4 {
+			t.Fatalf("Expected 2 attempts on one of 4 workers, got %+v", item)
+		}
+	}
+}
+
+// TestDeterministicParallelBatch tests that a seed fixes the interleaving of attempts,
+// with backoffs simulated rather than slept
+func TestDeterministicParallelBatch(t *testing.T) {
+	run := func(seed int) []string {
+		var order []string
+		attempts := make(map[int]int)
+		node := NewNode()
+		node.SetParams(map[string]interface{}{
+			"data":          []int{0, 1, 2, 3, 4, 5},
+			"batch":         true,
+			"parallel":      true,
+			"deterministic": true,
+			"seed":          seed,
+			"retries":       2,
+			"retry_delay":   time.Hour,
+		})
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			i := item.(int)
+			attempts[i]++
+			order = append(order, fmt.Sprintf("%d.%d", i, attempts[i]))
+			if i%2 == 0 && attempts[i] == 1 {
+				return nil, errors.New("transient")
+			}
+			return i * 10, nil
+		})
+		state := NewSharedState()
+		node.Run(state)
+		for i, result := range state.GetSlice(BatchResultsKey) {
+			if result != i*10 {
+				t.Fatalf("Expected results in item order, got %v", state.GetSlice(BatchResultsKey))
+			}
+		}
+		return order
+	}
+
+	start := time.Now()
+	first := run(7)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the hour-long backoffs to be simulated, took %v", elapsed)
+	}
+	if len(first) != 9 {
+		t.Fatalf("Expected 9 attempts, got %v", first)
+	}
+	for i := 0; i < 5; i++ {
+		if again := run(7); !reflect.DeepEqual(again, first) {
+			t.Fatalf("Expected the same interleaving for the same seed, got %v and %v", first, again)
+		}
+	}
+	differs := false
+	for seed := 0; seed < 10 && !differs; seed++ {
+		differs = !reflect.DeepEqual(run(seed), first)
+	}
+	if !differs {
+		t.Error("Expected other seeds to interleave differently")
+	}
+}
+
+// TestWeightedParallelBatch tests that item weights count against parallel_limit
+func TestWeightedParallelBatch(t *testing.T) {
+	weights := []int{1, 1, 4, 1, 1, 2, 2, 10, 1, 1}
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":           []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		"batch":          true,
+		"parallel":       true,
+		"parallel_limit": 4,
+	})
+	node.SetItemWeightFunc(func(item interface{}) int { return weights[item.(int)] })
+
+	var mu sync.Mutex
+	var load, peak, concurrent, peakConcurrent int
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		weight := min(weights[item.(int)], 4)
+		mu.Lock()
+		load += weight
+		concurrent++
+		peak = max(peak, load)
+		peakConcurrent = max(peakConcurrent, concurrent)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		load -= weight
+		concurrent--
+		mu.Unlock()
+		return item, nil
+	})
+
+	state := NewSharedState()
+	if action := node.Run(state); action != BatchCompleteAction {
+		t.Fatalf("Expected %q, got %q", BatchCompleteAction, action)
+	}
+	if got := len(state.GetSlice(BatchResultsKey)); got != len(weights) {
+		t.Fatalf("Expected %d results, got %d", len(weights), got)
+	}
+	if peak > 4 {
+		t.Errorf("Expected the weight in flight to stay within the limit, peaked at %d", peak)
+	}
+	if peakConcurrent < 2 {
+		t.Errorf("Expected light items to run concurrently, peaked at %d", peakConcurrent)
+	}
+}
+
+// TestCoordinatedBackoff tests that items failing together don't retry together
+func TestCoordinatedBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var failures int
+	outage := time.Now().Add(50 * time.Millisecond)
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":                []int{0, 1, 2, 3, 4, 5, 6, 7},
+		"batch":               true,
+		"parallel":            true,
+		"parallel_limit":      8,
+		"coordinated_backoff": true,
+		"retries":             5,
+		"retry_delay":         30 * time.Millisecond,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		time.Sleep(5 * time.Millisecond)
+		if time.Now().Before(outage) {
+			mu.Lock()
+			failures++
+			mu.Unlock()
+			return nil, errors.New("provider outage")
+		}
+		return item, nil
+	})
+
+	state := NewSharedState()
+	if action := node.Run(state); action != BatchCompleteAction {
+		t.Fatalf("Expected %q, got %q", BatchCompleteAction, action)
+	}
+	// Independent backoffs would fail every item twice; a shared one probes
+	// with a single item while the outage lasts
+	if failures > 10 {
+		t.Errorf("Expected retries during the outage to be probes, got %d failures", failures)
+	}
+}
+
+// TestCoordinatedBackoffBreaker tests that an open breaker pauses the whole batch
+func TestCoordinatedBackoffBreaker(t *testing.T) {
+	CircuitBreaker("test-coordinated-breaker", 1, 60*time.Millisecond)
+	outage := time.Now().Add(30 * time.Millisecond)
+	node := NewNode()
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if time.Now().Before(outage) {
+			return nil, errors.New("provider outage")
+		}
+		return item, nil
+	})
+	f := NewFlow().Start(node)
+	f.SetParams(map[string]interface{}{
+		"data":                []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		"batch":               true,
+		"parallel":            true,
+		"parallel_limit":      4,
+		"coordinated_backoff": true,
+		"breaker":             "test-coordinated-breaker",
+		"retries":             10,
+		"retry_delay":         time.Millisecond,
+	})
+	var mu sync.Mutex
+	var rejected int
+	f.Subscribe(func(e Event) {
+		if e.Type == EventExecAttempt && errors.Is(e.Err, ErrCircuitOpen) {
+			mu.Lock()
+			rejected++
+			mu.Unlock()
+		}
+	})
+
+	state := NewSharedState()
+	start := time.Now()
+	f.Run(state)
+	if got := len(state.GetSlice(BatchResultsKey)); got != 12 {
+		t.Fatalf("Expected 12 results, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Expected the batch to wait for the breaker's cooldown, took %v", elapsed)
+	}
+	if rejected > 4 {
+		t.Errorf("Expected the batch to stop calling while the breaker is open, got %d rejections", rejected)
+	}
+}
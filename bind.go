@@ -0,0 +1,180 @@
+package Flow
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidParams wraps the field errors returned by BindParams and Params.Bind
+var ErrInvalidParams = errors.New("flow: invalid params")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// BindParams populates the struct dst points to from the node's parameters as
+// set; see Params.Bind, which also binds the values of ParamFuncs computed for a
+// run when called on the params of a SetExecFuncWithParams function
+func (n *Node) BindParams(dst interface{}) error {
+	return n.paramMap().Bind(dst)
+}
+
+// Bind populates the struct dst points to from the parameters, so exec
+// functions of complex nodes work with a typed, validated config instead of
+// looking up keys one by one. Each field tagged `param:"key"` is set from the
+// parameter key; fields without the tag, or tagged "-", are left alone. The
+// tag option "required" makes a missing key an error, and a `default:"..."`
+// tag, spelled as in a "${env:NAME:default}" placeholder, is used when the key
+// is missing.
+//
+// Values are converted to the field's type where nothing is lost: between
+// numeric types, from duration strings such as "30s" to time.Duration, and from
+// lists of strings to []string. Every missing or unconvertible field is
+// reported, joined in one error wrapping ErrInvalidParams.
+//
+// Example:
+//
+//	type fetchConfig struct {
+//		URL     string        `param:"url,required"`
+//		Timeout time.Duration `param:"timeout" default:"10s"`
+//		Headers []string      `param:"headers"`
+//	}
+//
+//	func fetch(params flow.Params, prep interface{}) (interface{}, error) {
+//		var cfg fetchConfig
+//		if err := params.Bind(&cfg); err != nil {
+//			return nil, flow.Permanent(err)
+//		}
+//		...
+//	}
+func (p Params) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("flow: Bind needs a non-nil pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup("param")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		key, options, _ := strings.Cut(tag, ",")
+		value, present := p[key]
+		if !present || value == nil {
+			text, hasDefault := field.Tag.Lookup("default")
+			switch {
+			case hasDefault && field.Type.Kind() == reflect.String:
+				value = text
+			case hasDefault:
+				value = parseParamValue(text)
+			case options == "required":
+				errs = append(errs, fmt.Errorf("param %q is required", key))
+				continue
+			default:
+				continue
+			}
+		}
+		converted, err := convertParam(value, field.Type)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("param %q: %w", key, err))
+			continue
+		}
+		v.Field(i).Set(converted)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidParams, errors.Join(errs...))
+	}
+	return nil
+}
+
+// convertParam converts a parameter value to typ without losing information
+func convertParam(value interface{}, typ reflect.Type) (reflect.Value, error) {
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(typ) {
+		return v, nil
+	}
+	mismatch := fmt.Errorf("cannot use %T as %s", value, typ)
+
+	if typ == durationType {
+		// Plain numbers are ambiguous as durations
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(d), nil
+	}
+
+	out := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := paramInt(v)
+		if !ok || out.OverflowInt(i) {
+			return reflect.Value{}, mismatch
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := paramInt(v)
+		if !ok || i < 0 || out.OverflowUint(uint64(i)) {
+			return reflect.Value{}, mismatch
+		}
+		out.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case v.CanInt():
+			out.SetFloat(float64(v.Int()))
+		case v.CanUint():
+			out.SetFloat(float64(v.Uint()))
+		case v.CanFloat():
+			out.SetFloat(v.Float())
+		default:
+			return reflect.Value{}, mismatch
+		}
+	case reflect.String:
+		if v.Kind() != reflect.String {
+			return reflect.Value{}, mismatch
+		}
+		out.SetString(v.String())
+	case reflect.Bool:
+		if v.Kind() != reflect.Bool {
+			return reflect.Value{}, mismatch
+		}
+		out.SetBool(v.Bool())
+	case reflect.Slice:
+		// toStrings drops non-string elements, which would lose them
+		list := toStrings(value)
+		isList := v.Kind() == reflect.Slice
+		if typ.Elem().Kind() != reflect.String || isList && len(list) != v.Len() || !isList && list == nil {
+			return reflect.Value{}, mismatch
+		}
+		out = reflect.MakeSlice(typ, len(list), len(list))
+		for i, s := range list {
+			out.Index(i).SetString(s)
+		}
+	default:
+		return reflect.Value{}, mismatch
+	}
+	return out, nil
+}
+
+// paramInt returns a numeric value as an int64 if it is a whole number in range
+func paramInt(v reflect.Value) (int64, bool) {
+	switch {
+	case v.CanInt():
+		return v.Int(), true
+	case v.CanUint():
+		return int64(v.Uint()), v.Uint() <= math.MaxInt64
+	case v.CanFloat():
+		f := v.Float()
+		return int64(f), f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package Flow
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindConfig struct {
+	URL      string        `param:"url,required"`
+	Timeout  time.Duration `param:"timeout" default:"10s"`
+	Interval time.Duration `param:"interval"`
+	Retries  int64         `param:"retries" default:"3"`
+	Ratio    float64       `param:"ratio"`
+	Verbose  bool          `param:"verbose"`
+	Headers  []string      `param:"headers"`
+	Region   string        `param:"region" default:"42"`
+	Ignored  string        `param:"-"`
+	Untagged string
+}
+
+// TestBindParams tests populating a typed config from node params
+func TestBindParams(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"url":      "https://api.example.com",
+		"interval": "250ms",
+		"ratio":    2,
+		"verbose":  true,
+		"headers":  []interface{}{"Accept", "Authorization"},
+		"-":        "unused",
+		"Untagged": "unused",
+	})
+
+	var cfg bindConfig
+	if err := node.BindParams(&cfg); err != nil {
+		t.Fatalf("Expected the params to bind, got %v", err)
+	}
+	want := bindConfig{
+		URL: "https://api.example.com", Timeout: 10 * time.Second, Interval: 250 * time.Millisecond,
+		Retries: 3, Ratio: 2, Verbose: true, Headers: []string{"Accept", "Authorization"}, Region: "42",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Expected %+v, got %+v", want, cfg)
+	}
+}
+
+// TestBindParamsErrors tests that every missing or invalid field is reported
+func TestBindParamsErrors(t *testing.T) {
+	var cfg bindConfig
+	err := Params{
+		"timeout": 30,
+		"retries": 1.5,
+		"verbose": "yes",
+		"headers": []interface{}{"Accept", 1},
+	}.Bind(&cfg)
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("Expected ErrInvalidParams, got %v", err)
+	}
+	for _, key := range []string{"url", "timeout", "retries", "verbose", "headers"} {
+		if !strings.Contains(err.Error(), `param "`+key+`"`) {
+			t.Errorf("Expected an error for %q, got %v", key, err)
+		}
+	}
+
+	if err := (Params{}).Bind(cfg); err == nil || errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected an error for a non-pointer destination, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned in place of calling exec while a node's circuit breaker is open
+var ErrCircuitOpen = errors.New("flow: circuit breaker is open")
+
+// BreakerState describes the state of a circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through while counting consecutive failures
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects every call until the cooldown elapses
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe call through to decide whether to close again
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// Breaker is a named circuit breaker shared by every node that references it.
+// A failure spike detected by one node trips the breaker for all of them, and
+// half-open probing is coordinated centrally so only one probe call is in flight
+// at a time.
+//
+// Breakers are created and registered with CircuitBreaker() and referenced from
+// nodes through the "breaker" parameter.
+//
+// Example:
+//
+//	flow.CircuitBreaker("openrouter", 5, 30*time.Second)
+//
+//	summarize.SetParams(map[string]interface{}{"breaker": "openrouter", "retries": 3})
+//	classify.SetParams(map[string]interface{}{"breaker": "openrouter"})
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+var breakerRegistry = struct {
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}{
+	breakers: make(map[string]*Breaker),
+}
+
+// CircuitBreaker registers a named circuit breaker that opens after threshold
+// consecutive failures and probes again after cooldown, and returns it. If a
+// breaker with the same name is already registered, the existing breaker is
+// returned unchanged. A threshold <= 0 is treated as 1.
+//
+// Parameters:
+//   - name: The name nodes use to reference the breaker via the "breaker" param
+//   - threshold: Consecutive failures that trip the breaker
+//   - cooldown: How long the breaker stays open before allowing a probe call
+//
+// Returns:
+//   - *Breaker: The registered breaker
+func CircuitBreaker(name string, threshold int, cooldown time.Duration) *Breaker {
+	breakerRegistry.mu.Lock()
+	defer breakerRegistry.mu.Unlock()
+
+	if existing, ok := breakerRegistry.breakers[name]; ok {
+		return existing
+	}
+
+	if threshold <= 0 {
+		threshold = 1
+	}
+	b := &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+	breakerRegistry.breakers[name] = b
+	return b
+}
+
+// lookupBreaker returns the registered breaker for name, or nil if none exists
+func lookupBreaker(name string) *Breaker {
+	breakerRegistry.mu.RLock()
+	defer breakerRegistry.mu.RUnlock()
+	return breakerRegistry.breakers[name]
+}
+
+// registeredBreakers returns every registered breaker
+func registeredBreakers() []*Breaker {
+	breakerRegistry.mu.RLock()
+	defer breakerRegistry.mu.RUnlock()
+	breakers := make([]*Breaker, 0, len(breakerRegistry.breakers))
+	for _, b := range breakerRegistry.breakers {
+		breakers = append(breakers, b)
+	}
+	return breakers
+}
+
+// Name returns the name the breaker was registered under
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Reset closes the breaker and clears its failure count
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// allow reports whether a call may proceed, returning an ErrCircuitOpen error if not
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// openFor returns how long the breaker stays open, or 0 if it isn't open
+func (b *Breaker) openFor() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerOpen {
+		return 0
+	}
+	return max(b.cooldown-time.Since(b.openedAt), 0)
+}
+
+// breakerCooldown returns how long the node's breaker stays open after the
+// failure err, or 0 if err is nil or the breaker isn't open
+func (n *Node) breakerCooldown(ctx context.Context, err error) time.Duration {
+	name := n.getStringParam(ctx, "breaker")
+	if err == nil || name == "" {
+		return 0
+	}
+	if b := lookupBreaker(name); b != nil {
+		return b.openFor()
+	}
+	return 0
+}
+
+// record updates the breaker with the outcome of an allowed call
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+}
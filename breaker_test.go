@@ -0,0 +1,118 @@
+package Flow
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestBreakerSharedAcrossNodes tests that failures in one node trip the breaker for another
+func TestBreakerSharedAcrossNodes(t *testing.T) {
+	breaker := CircuitBreaker("test-shared-api", 2, time.Hour)
+	state := NewSharedState()
+
+	failing := NewNode()
+	failing.SetParams(map[string]interface{}{
+		"breaker": "test-shared-api",
+		"retries": 2,
+	})
+	failing.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("upstream unavailable")
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+		failing.Run(state)
+	}()
+
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("Expected breaker to be open, got %s", breaker.State())
+	}
+
+	called := false
+	other := NewNode()
+	other.SetParams(map[string]interface{}{
+		"breaker": "test-shared-api",
+	})
+	other.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	})
+
+	func() {
+		defer func() {
+			r := recover()
+			err, ok := r.(error)
+			if !ok || !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("Expected ErrCircuitOpen panic, got %v", r)
+			}
+		}()
+		other.Run(state)
+	}()
+
+	if called {
+		t.Error("Expected exec not to be called while breaker is open")
+	}
+}
+
+// TestBreakerHalfOpenProbe tests recovery through a half-open probe
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	breaker := CircuitBreaker("test-half-open", 1, time.Millisecond*10)
+
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("Expected closed breaker to allow, got %v", err)
+	}
+	breaker.record(fmt.Errorf("boom"))
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected open breaker to reject, got %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 15)
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("Expected half-open after cooldown, got %s", breaker.State())
+	}
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("Expected probe to be allowed, got %v", err)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected concurrent probe to be rejected, got %v", err)
+	}
+
+	breaker.record(nil)
+	if breaker.State() != BreakerClosed {
+		t.Errorf("Expected closed after successful probe, got %s", breaker.State())
+	}
+}
+
+// TestBreakerHalfOpenProbePanics tests that a probe whose exec panics reopens the
+// breaker instead of leaving the probe outstanding
+func TestBreakerHalfOpenProbePanics(t *testing.T) {
+	breaker := CircuitBreaker("test-half-open-panic", 1, time.Millisecond*10)
+	breaker.allow()
+	breaker.record(fmt.Errorf("boom"))
+	time.Sleep(time.Millisecond * 15)
+
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		panic("probe exploded")
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"breaker": "test-half-open-panic"})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the exec panic to propagate")
+			}
+		}()
+		pipeline.Run(NewSharedState())
+	}()
+
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("Expected the panicking probe to reopen the breaker, got %s", breaker.State())
+	}
+	time.Sleep(time.Millisecond * 15)
+	if err := breaker.allow(); err != nil {
+		t.Errorf("Expected a new probe after the cooldown, got %v", err)
+	}
+}
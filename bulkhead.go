@@ -0,0 +1,121 @@
+package Flow
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBulkheadFull is returned for exec calls shed because the node's bulkhead has
+// no free slot and its queue is full. Shed calls are not retried.
+var ErrBulkheadFull = errors.New("flow: bulkhead full")
+
+// ShedAction is returned by a node with "bulkhead_shed": ShedDegrade whose exec
+// call was shed, so flows can route it to a cheaper branch with Next(cheap, ShedAction)
+const ShedAction = "shed"
+
+// Load shedding policies for the "bulkhead_shed" param, applied when a call finds
+// the bulkhead queue full
+const (
+	// ShedReject fails the new call with ErrBulkheadFull (the default)
+	ShedReject = "reject"
+	// ShedDropOldest fails the longest-waiting call with ErrBulkheadFull and queues the new one
+	ShedDropOldest = "drop_oldest"
+	// ShedDegrade fails the new call like ShedReject, and the node returns ShedAction
+	// instead of panicking (outside batch mode)
+	ShedDegrade = "degrade"
+)
+
+// bulkhead gives a node its own bounded execution slots and wait queue, so a slow
+// dependency saturating them is isolated from the capacity other nodes need.
+// It is created from the "bulkhead" and "bulkhead_queue" params on first use.
+type bulkhead struct {
+	mu      sync.Mutex
+	size    int
+	inUse   int
+	queue   int        // calls allowed to wait for a slot
+	waiters *list.List // of chan error, oldest first
+}
+
+// enter takes a bulkhead slot, waiting in the queue if there is room, and returns
+// the function releasing it. When the queue is full the shed policy decides which
+// call fails with ErrBulkheadFull. A queued call returns ctx.Err() if ctx is done
+// first.
+func (b *bulkhead) enter(ctx context.Context, shed string) (func(), error) {
+	b.mu.Lock()
+	if b.inUse < b.size {
+		b.inUse++
+		b.mu.Unlock()
+		return b.release, nil
+	}
+	if b.waiters.Len() >= b.queue {
+		if shed != ShedDropOldest || b.queue == 0 {
+			b.mu.Unlock()
+			return nil, Permanent(ErrBulkheadFull)
+		}
+		oldest := b.waiters.Remove(b.waiters.Front()).(chan error)
+		oldest <- Permanent(ErrBulkheadFull)
+	}
+	ready := make(chan error, 1)
+	waiter := b.waiters.PushBack(ready)
+	b.mu.Unlock()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			return nil, err
+		}
+		return b.release, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		select {
+		case err := <-ready:
+			if err == nil {
+				// The slot was handed over as ctx finished; hand it on
+				b.releaseLocked()
+			}
+		default:
+			b.waiters.Remove(waiter)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a slot, handing it directly to the oldest waiter if any
+func (b *bulkhead) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.releaseLocked()
+}
+
+func (b *bulkhead) releaseLocked() {
+	if front := b.waiters.Front(); front != nil {
+		b.waiters.Remove(front).(chan error) <- nil
+		return
+	}
+	b.inUse--
+}
+
+// bulkhead returns the node's bulkhead, or nil if "bulkhead" is not set. The
+// slot and queue sizes are fixed by the params in effect on first use.
+func (n *Node) bulkhead(ctx context.Context) *bulkhead {
+	size := n.getIntParam(ctx, "bulkhead")
+	if size <= 0 {
+		return nil
+	}
+	n.bulkheadOnce.Do(func() {
+		queue := n.getIntParam(ctx, "bulkhead_queue")
+		if queue < 0 {
+			queue = 0
+		}
+		n.isolation = &bulkhead{size: size, queue: queue, waiters: list.New()}
+	})
+	return n.isolation
+}
+
+// degraded reports whether a failed exec call was shed by a node that degrades to ShedAction
+func (n *Node) degraded(ctx context.Context, err error) bool {
+	return n.getStringParam(ctx, "bulkhead_shed") == ShedDegrade && errors.Is(err, ErrBulkheadFull)
+}
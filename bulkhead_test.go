@@ -0,0 +1,152 @@
+package Flow
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBulkheadRejectsWhenFull tests bounded slots, queueing, and rejection past the queue
+func TestBulkheadRejectsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":           []int{1, 2, 3, 4},
+		"batch":          true,
+		"parallel":       true,
+		"bulkhead":       1,
+		"bulkhead_queue": 1,
+	})
+	var mu sync.Mutex
+	started := 0
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		mu.Lock()
+		started++
+		mu.Unlock()
+		<-release
+		return item, nil
+	})
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { err, _ = recover().(error) }()
+		node.Run(NewSharedState())
+	}()
+
+	// One call holds the slot, one waits, and the other two are rejected
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-done
+
+	if !errors.Is(err, ErrBulkheadFull) {
+		t.Errorf("Expected a bulkhead rejection, got %v", err)
+	}
+	if started != 2 {
+		t.Errorf("Expected 2 calls to run, got %d", started)
+	}
+	if b := node.bulkhead(context.Background()); held(b) != 0 || waiting(b) != 0 {
+		t.Errorf("Expected the bulkhead to be drained, got %d held and %d waiting", held(b), waiting(b))
+	}
+}
+
+// TestBulkheadShedding tests the drop_oldest and degrade shedding policies
+func TestBulkheadShedding(t *testing.T) {
+	b := &bulkhead{size: 1, queue: 1, waiters: list.New()}
+	release, err := b.enter(context.Background(), ShedDropOldest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	oldest := make(chan error)
+	go func() {
+		_, err := b.enter(context.Background(), ShedDropOldest)
+		oldest <- err
+	}()
+	for waiting(b) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	newest := make(chan error)
+	go func() {
+		release, err := b.enter(context.Background(), ShedDropOldest)
+		if err == nil {
+			release()
+		}
+		newest <- err
+	}()
+
+	if err := <-oldest; !errors.Is(err, ErrBulkheadFull) || IsRetryable(err) {
+		t.Errorf("Expected the oldest waiter to be shed permanently, got %v", err)
+	}
+	release()
+	if err := <-newest; err != nil {
+		t.Errorf("Expected the newest call to get the slot, got %v", err)
+	}
+
+	// A degrading node routes shed calls to its ShedAction branch
+	hold := make(chan struct{})
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"bulkhead": 1, "bulkhead_shed": ShedDegrade})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		<-hold
+		return "full answer", nil
+	})
+	first := make(chan string)
+	go func() { first <- node.Run(NewSharedState()) }()
+	for node.bulkhead(context.Background()) == nil || held(node.bulkhead(context.Background())) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if action := node.Run(NewSharedState()); action != ShedAction {
+		t.Errorf("Expected %q, got %q", ShedAction, action)
+	}
+	close(hold)
+	if action := <-first; action != "full answer" {
+		t.Errorf("Expected the first call to complete, got %q", action)
+	}
+}
+
+// TestBulkheadQueueCancelled tests that a queued call leaves the queue once its
+// ctx is done, without losing the slot
+func TestBulkheadQueueCancelled(t *testing.T) {
+	b := &bulkhead{size: 1, queue: 1, waiters: list.New()}
+	release, err := b.enter(context.Background(), ShedReject)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.enter(ctx, ShedReject); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the queued call to give up, got %v", err)
+	}
+	if waiting(b) != 0 {
+		t.Errorf("Expected the cancelled call to leave the queue, %d waiting", waiting(b))
+	}
+
+	release()
+	if held(b) != 0 {
+		t.Errorf("Expected the slot to be free, %d held", held(b))
+	}
+	release, err = b.enter(context.Background(), ShedReject)
+	if err != nil {
+		t.Fatalf("Expected the slot to be available, got %v", err)
+	}
+	release()
+}
+
+// waiting returns the number of calls queued in b
+func waiting(b *bulkhead) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.waiters.Len()
+}
+
+// held returns the number of slots of b in use
+func held(b *bulkhead) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inUse
+}
@@ -0,0 +1,301 @@
+package Flow
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores values by key with an optional time to live. It backs exec
+// memoization ("cache": true), and nodes choose a registered backend through the
+// "cache_backend" param so cache behavior can match the deployment topology: the
+// default per-node LRU for a single process, or a shared Redis cache for a fleet.
+//
+// A cache is an optimization, so implementations treat backend failures as misses
+// rather than failing the node.
+type Cache interface {
+	// Get returns the value stored under key, if present and not expired
+	Get(key string) (interface{}, bool)
+	// Set stores value under key, expiring it after ttl (never if ttl <= 0)
+	Set(key string, value interface{}, ttl time.Duration)
+	// Delete removes key
+	Delete(key string)
+}
+
+var cacheRegistry = struct {
+	mu     sync.RWMutex
+	caches map[string]Cache
+}{
+	caches: make(map[string]Cache),
+}
+
+// RegisterCache registers a cache backend under name, replacing any backend
+// previously registered with that name, so nodes can reference it through the
+// "cache_backend" param. Nodes sharing a backend are keyed by node name.
+//
+// Example:
+//
+//	flow.RegisterCache("shared", flow.NewRedisCache("localhost:6379", "memo:"))
+//
+//	geocode.SetName("geocode")
+//	geocode.SetParams(map[string]interface{}{"cache": true, "cache_backend": "shared"})
+func RegisterCache(name string, c Cache) {
+	cacheRegistry.mu.Lock()
+	defer cacheRegistry.mu.Unlock()
+	cacheRegistry.caches[name] = c
+}
+
+// lookupCache returns the cache registered under name, or nil if none exists
+func lookupCache(name string) Cache {
+	cacheRegistry.mu.RLock()
+	defer cacheRegistry.mu.RUnlock()
+	return cacheRegistry.caches[name]
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used entry once
+// it holds capacity entries
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // most recently used at the front
+	entries  map[string]*list.Element
+}
+
+// lruEntry is the value of an LRUCache list element
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time // zero for no expiry
+}
+
+// NewLRUCache creates an in-memory cache holding at most capacity entries
+// (unbounded if capacity <= 0)
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored under key, if present and not expired
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set stores value under key, expiring it after ttl (never if ttl <= 0)
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &lruEntry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete removes key
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DeletePattern removes every key matching the glob pattern, which is matched
+// like Redis MATCH: "*" spans any characters, including "/"
+func (c *LRUCache) DeletePattern(pattern string) error {
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if re.MatchString(key) {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// globRegexp compiles a glob pattern to an anchored regexp: "*" matches any run
+// of characters, "?" any one, "[...]" a class ("[^...]" or "[!...]" negated), and
+// a backslash escapes the next character
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("(?s)^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("flow: unterminated class in pattern %q", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			expr.WriteByte('[')
+			if strings.HasPrefix(class, "!") || strings.HasPrefix(class, "^") {
+				expr.WriteByte('^')
+				class = class[1:]
+			}
+			expr.WriteString(class)
+			expr.WriteByte(']')
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteByte('$')
+	return regexp.Compile(expr.String())
+}
+
+// remove deletes elem; callers must hold the lock
+func (c *LRUCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
+
+// RedisCache is a Cache stored in Redis and shared by every process using the same
+// server and prefix. Values are stored as JSON, so Get returns decoded JSON values
+// (objects as map[string]interface{}, integral numbers as int).
+type RedisCache struct {
+	client *redisClient
+	prefix string
+}
+
+// NewRedisCache creates a cache on the Redis server at addr ("host:port") storing
+// keys under prefix
+func NewRedisCache(addr, prefix string) *RedisCache {
+	return &RedisCache{client: newRedisClient(addr), prefix: prefix}
+}
+
+// Get returns the value stored under key, if present
+func (c *RedisCache) Get(key string) (interface{}, bool) {
+	reply, err := c.client.do("GET", c.prefix+key)
+	encoded, ok := reply.(string)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(encoded), &value); err != nil {
+		return nil, false
+	}
+	return normalizeJSON(value), true
+}
+
+// Set stores value under key as JSON, expiring it after ttl (never if ttl <= 0).
+// Values that cannot be encoded are not cached.
+func (c *RedisCache) Set(key string, value interface{}, ttl time.Duration) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	args := append([]string{"SET", c.prefix + key, string(encoded)}, redisTTLArgs(ttl)...)
+	_, _ = c.client.do(args...) // A failed write is a future miss
+}
+
+// Delete removes key
+func (c *RedisCache) Delete(key string) {
+	_, _ = c.client.do("DEL", c.prefix+key)
+}
+
+// DeletePattern removes every key under the prefix matching the glob pattern,
+// scanning the keyspace incrementally
+func (c *RedisCache) DeletePattern(pattern string) error {
+	cursor := "0"
+	for {
+		reply, err := c.client.do("SCAN", cursor, "MATCH", c.prefix+pattern, "COUNT", "100")
+		if err != nil {
+			return fmt.Errorf("flow: redis cache: %w", err)
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("flow: redis cache: unexpected SCAN reply %v", reply)
+		}
+		cursor, _ = page[0].(string)
+		if keys, _ := page[1].([]interface{}); len(keys) > 0 {
+			args := []string{"DEL"}
+			for _, key := range keys {
+				args = append(args, key.(string))
+			}
+			if _, err := c.client.do(args...); err != nil {
+				return fmt.Errorf("flow: redis cache: %w", err)
+			}
+		}
+		if cursor == "0" {
+			return nil
+		}
+	}
+}
+
+// Ping checks connectivity to the Redis server, for use as a Runner health check
+func (c *RedisCache) Ping() error {
+	if err := c.client.ping(); err != nil {
+		return fmt.Errorf("flow: redis cache: %w", err)
+	}
+	return nil
+}
+
+// defaultMemoCapacity bounds the per-node memoization cache
+const defaultMemoCapacity = 1024
+
+// memoCache returns the cache backing the node's memoization and the prefix its
+// keys are stored under: the registered "cache_backend", shared by node name, or
+// the node's own LRU cache
+func (n *Node) memoCache(ctx context.Context) (Cache, string) {
+	if name := n.getStringParam(ctx, "cache_backend"); name != "" {
+		backend := lookupCache(name)
+		if backend == nil {
+			panic(fmt.Errorf("flow: cache backend %q is not registered", name))
+		}
+		return backend, n.name + ":"
+	}
+	n.memoOnce.Do(func() { n.memo = NewLRUCache(defaultMemoCapacity) })
+	return n.memo, ""
+}
@@ -0,0 +1,108 @@
+package Flow
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLRUCache tests eviction of the least recently used entry and expiry
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 0)
+	cache.Get("a") // b is now the least recently used
+	cache.Set("c", 3, 0)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected a to survive, got %v", value)
+	}
+
+	cache.Set("short", "lived", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Error("Expected the entry to expire")
+	}
+
+	// Getting a made c the least recently used, so short evicted it
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok || cache.Len() != 0 {
+		t.Errorf("Expected a to be deleted, %d entries left", cache.Len())
+	}
+}
+
+// TestLRUCacheDeletePattern tests that "*" matches across "/" like Redis MATCH
+func TestLRUCacheDeletePattern(t *testing.T) {
+	cache := NewLRUCache(10)
+	for _, key := range []string{"geo:eu/paris/fr", "geo:eu/lyon/fr", "geo:eu/berlin/de", "geo:a.b", "geo:axb"} {
+		cache.Set(key, true, 0)
+	}
+
+	if err := cache.DeletePattern("geo:*/fr"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cache.DeletePattern("geo:a.[!x]"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for key, kept := range map[string]bool{"geo:eu/paris/fr": false, "geo:eu/lyon/fr": false, "geo:eu/berlin/de": true, "geo:a.b": false, "geo:axb": true} {
+		if _, ok := cache.Get(key); ok != kept {
+			t.Errorf("Expected %s kept=%v", key, kept)
+		}
+	}
+
+	if err := cache.DeletePattern("geo:[a-"); err == nil {
+		t.Error("Expected an error for an unterminated class")
+	}
+}
+
+// TestRedisCache tests that values round-trip as JSON with the TTL
+func TestRedisCache(t *testing.T) {
+	server := startFakeRedis(t)
+	cache := NewRedisCache(server.addr, "memo:")
+
+	cache.Set("user:1", map[string]interface{}{"name": "Ada", "age": 36}, time.Minute)
+	value, ok := cache.Get("user:1")
+	user, _ := value.(map[string]interface{})
+	if !ok || user["name"] != "Ada" || user["age"] != 36 {
+		t.Errorf("Unexpected cached value: %v", value)
+	}
+	if server.ttls["memo:user:1"] != "60000" {
+		t.Errorf("Expected a one minute TTL, got %q", server.ttls["memo:user:1"])
+	}
+
+	cache.Delete("user:1")
+	if _, ok := cache.Get("user:1"); ok {
+		t.Error("Expected the value to be deleted")
+	}
+
+	unreachable := NewRedisCache("127.0.0.1:1", "")
+	if _, ok := unreachable.Get("user:1"); ok {
+		t.Error("Expected an unreachable backend to miss")
+	}
+}
+
+// TestCacheBackendSharedByNodes tests that nodes sharing a backend are keyed by node name
+func TestCacheBackendSharedByNodes(t *testing.T) {
+	backend := NewLRUCache(0)
+	RegisterCache("test-shared", backend)
+
+	node := func(name, result string) *Node {
+		n := NewNode()
+		n.SetName(name)
+		n.SetParams(map[string]interface{}{"cache": true, "cache_backend": "test-shared"})
+		n.SetExecFunc(func(prep interface{}) (interface{}, error) { return result, nil })
+		return n
+	}
+
+	if action := node("geocode", "coords").Run(NewSharedState()); action != "coords" {
+		t.Errorf("Expected coords, got %s", action)
+	}
+	if action := node("weather", "sunny").Run(NewSharedState()); action != "sunny" {
+		t.Errorf("Expected the weather node not to see geocode's result, got %s", action)
+	}
+	if value, ok := backend.Get("geocode:<nil>"); !ok || value != "coords" {
+		t.Errorf("Expected the result under the node's prefix, got %v", value)
+	}
+}
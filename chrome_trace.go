@@ -0,0 +1,137 @@
+package Flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// chromeEvent is one entry of the Chrome trace event format
+type chromeEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat,omitempty"`
+	Ph   string                 `json:"ph"`
+	Ts   float64                `json:"ts"`
+	Dur  float64                `json:"dur,omitempty"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// traceSpan is a node run or batch item reconstructed from events
+type traceSpan struct {
+	name       string
+	cat        string
+	group      string // process the span is drawn in
+	start, end time.Time
+	args       map[string]interface{}
+}
+
+// WriteChromeTrace writes the node runs and batch items of a recorded run to w
+// in the Chrome trace event format, for chrome://tracing or ui.perfetto.dev.
+// Node runs are drawn in a "nodes" process and the items of each batch node in a
+// process of their own, with spans that overlap in time on separate rows, so
+// the rows show how far parallel batches actually ran concurrently. Errors pass
+// through the redactors registered with AddRedactor.
+//
+// Example:
+//
+//	journal := flow.NewJournal()
+//	pipeline.Subscribe(journal.Record)
+//	pipeline.Run(state)
+//	file, _ := os.Create("trace.json")
+//	defer file.Close()
+//	err := flow.WriteChromeTrace(file, journal.Events())
+func WriteChromeTrace(w io.Writer, events []Event) error {
+	spans := traceSpans(events)
+	var origin time.Time
+	if len(spans) > 0 {
+		origin = spans[0].start
+	}
+	micros := func(t time.Time) float64 {
+		return float64(t.Sub(origin)) / float64(time.Microsecond)
+	}
+
+	// Processes are numbered in order of first appearance, with nodes first
+	pids := map[string]int{"nodes": 1}
+	trace := []chromeEvent{{Name: "process_name", Ph: "M", Pid: 1, Args: map[string]interface{}{"name": "nodes"}}}
+	lanes := make(map[string][]time.Time) // end of the last span on each row, by process
+	for _, span := range spans {
+		pid, ok := pids[span.group]
+		if !ok {
+			pid = len(pids) + 1
+			pids[span.group] = pid
+			trace = append(trace, chromeEvent{Name: "process_name", Ph: "M", Pid: pid, Args: map[string]interface{}{"name": span.group}})
+		}
+		tid := freeLane(lanes, span)
+		trace = append(trace, chromeEvent{
+			Name: span.name, Cat: span.cat, Ph: "X", Pid: pid, Tid: tid,
+			Ts: micros(span.start), Dur: micros(span.end) - micros(span.start), Args: span.args,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeEvent `json:"traceEvents"`
+		DisplayTimeUnit string        `json:"displayTimeUnit"`
+	}{trace, "ms"})
+}
+
+// freeLane returns the first row of span's process that is free at its start,
+// and marks it busy until its end
+func freeLane(lanes map[string][]time.Time, span traceSpan) int {
+	rows := lanes[span.group]
+	for i, end := range rows {
+		if !end.After(span.start) {
+			rows[i] = span.end
+			return i + 1
+		}
+	}
+	lanes[span.group] = append(rows, span.end)
+	return len(rows) + 1
+}
+
+// traceSpans pairs the events of each node run into spans, sorted by start.
+// Node runs still open at the last event, such as a failed node, end there.
+func traceSpans(events []Event) []traceSpan {
+	var spans []traceSpan
+	open := make(map[string][]int) // indexes of running node spans, by flow and node
+	for _, e := range events {
+		key := e.Flow + "/" + e.Node
+		name := debugNodeName(e.Node)
+		switch e.Type {
+		case EventNodeStarted:
+			open[key] = append(open[key], len(spans))
+			spans = append(spans, traceSpan{name: name, cat: "node", group: "nodes", start: e.Time, args: map[string]interface{}{}})
+		case EventActionChosen:
+			if visits := open[key]; len(visits) > 0 {
+				span := &spans[visits[len(visits)-1]]
+				span.end = e.Time
+				span.args["action"] = e.Action
+				open[key] = visits[:len(visits)-1]
+			}
+		case EventBatchItemDone:
+			args := map[string]interface{}{"index": e.Index}
+			if e.Err != nil {
+				args["error"] = redactError(e.Err)
+			}
+			spans = append(spans, traceSpan{
+				name: fmt.Sprintf("%s[%d]", name, e.Index), cat: "batch_item", group: name + " items",
+				start: e.Time.Add(-e.Elapsed), end: e.Time, args: args,
+			})
+		}
+	}
+	if len(events) > 0 {
+		last := events[len(events)-1].Time
+		for _, visits := range open {
+			for _, i := range visits {
+				spans[i].end = last
+				spans[i].args["unfinished"] = true
+			}
+		}
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	return spans
+}
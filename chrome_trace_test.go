@@ -0,0 +1,56 @@
+package Flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestWriteChromeTrace tests node and batch item spans, with overlapping items on separate rows
+func TestWriteChromeTrace(t *testing.T) {
+	node := NewNode()
+	node.SetName("fetch")
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return item, nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"batch": true, "parallel": true, "data": []int{1, 2, 3}})
+	journal := NewJournal()
+	pipeline.Subscribe(journal.Record)
+	pipeline.Run(NewSharedState())
+
+	var buf bytes.Buffer
+	if err := WriteChromeTrace(&buf, journal.Events()); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, buf.String())
+	}
+
+	var nodeSpan *chromeEvent
+	rows := make(map[int]bool)
+	items := 0
+	for i, e := range trace.TraceEvents {
+		switch e.Cat {
+		case "node":
+			nodeSpan = &trace.TraceEvents[i]
+		case "batch_item":
+			items++
+			rows[e.Tid] = true
+			if e.Pid == 1 || e.Dur < float64(20*time.Millisecond/time.Microsecond) {
+				t.Errorf("Expected a ~20ms item span in its own process, got %+v", e)
+			}
+		}
+	}
+	if nodeSpan == nil || nodeSpan.Name != "fetch" || nodeSpan.Args["action"] != BatchCompleteAction {
+		t.Fatalf("Expected a span for the fetch node, got %+v", trace.TraceEvents)
+	}
+	if items != 3 || len(rows) != 3 {
+		t.Errorf("Expected 3 concurrent items on 3 rows, got %d items on %d rows", items, len(rows))
+	}
+}
@@ -0,0 +1,146 @@
+package Flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// paramPlaceholder matches "${env:NAME}", "${env:NAME:default}", "${config:key}" and
+// "${config:key:default}"
+var paramPlaceholder = regexp.MustCompile(`\$\{(env|config):([^:}]+)(?::([^}]*))?\}`)
+
+// ParamConfig resolves deployment placeholders in node params from environment
+// variables and config files, so tuning a deployment doesn't require code changes.
+//
+// Placeholders take the forms "${env:NAME:default}" and "${config:key:default}"
+// (the default is optional). An env placeholder uses the environment variable
+// NAME if set, then the config value NAME, then the default; a config placeholder
+// skips the environment. Params without placeholders are explicit and used as
+// given. A param that is exactly one placeholder is converted to an int, bool,
+// time.Duration or float64 when the resolved text parses as one, so
+// "${env:FLOW_RETRIES:3}" yields the int 3 a "retries" param expects.
+//
+// Example:
+//
+//	config, err := flow.LoadParamConfig("flow.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	params, err := config.Resolve(map[string]interface{}{
+//		"retries":     "${env:FLOW_RETRIES:3}",
+//		"retry_delay": "${config:retry_delay:500ms}",
+//		"batch":       true,
+//	})
+//	node.SetParams(params)
+type ParamConfig struct {
+	values map[string]interface{}
+}
+
+// LoadParamConfig reads JSON config files holding an object of values; keys in
+// later files override earlier ones
+func LoadParamConfig(paths ...string) (*ParamConfig, error) {
+	c := &ParamConfig{values: make(map[string]interface{})}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("flow: reading param config: %w", err)
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("flow: parsing param config %s: %w", path, err)
+		}
+		for key, value := range values {
+			c.values[key] = normalizeJSON(value)
+		}
+	}
+	return c, nil
+}
+
+// ResolveParams resolves placeholders in params from environment variables only.
+// See ParamConfig for the placeholder syntax.
+func ResolveParams(params map[string]interface{}) (map[string]interface{}, error) {
+	return (&ParamConfig{}).Resolve(params)
+}
+
+// Resolve returns a copy of params with every placeholder resolved. It returns an
+// error if a placeholder has no value and no default.
+func (c *ParamConfig) Resolve(params map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		text, ok := value.(string)
+		if !ok || !paramPlaceholder.MatchString(text) {
+			resolved[key] = value
+			continue
+		}
+		v, err := c.resolve(text)
+		if err != nil {
+			return nil, fmt.Errorf("flow: param %q: %w", key, err)
+		}
+		resolved[key] = v
+	}
+	return resolved, nil
+}
+
+// resolve substitutes the placeholders in text
+func (c *ParamConfig) resolve(text string) (interface{}, error) {
+	// A param that is a single placeholder keeps the type of its value
+	if m := paramPlaceholder.FindStringSubmatchIndex(text); m[0] == 0 && m[1] == len(text) {
+		value, err := c.lookup(paramPlaceholder.FindStringSubmatch(text))
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := value.(string); ok {
+			return parseParamValue(s), nil
+		}
+		return value, nil
+	}
+
+	var firstErr error
+	result := paramPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		value, err := c.lookup(paramPlaceholder.FindStringSubmatch(placeholder))
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return fmt.Sprint(value)
+	})
+	return result, firstErr
+}
+
+// lookup returns the value of a placeholder submatch: source, name, default
+func (c *ParamConfig) lookup(match []string) (interface{}, error) {
+	source, name := match[1], match[2]
+	if source == "env" {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, nil
+		}
+	}
+	if value, ok := c.values[name]; ok {
+		return value, nil
+	}
+	// The default group is empty both when absent and when explicitly empty
+	if len(match[0]) > len("${"+source+":"+name+"}") {
+		return match[3], nil
+	}
+	return nil, fmt.Errorf("%s %s is not set and has no default", source, name)
+}
+
+// parseParamValue converts resolved placeholder text to the param type it spells
+func parseParamValue(text string) interface{} {
+	if i, err := strconv.Atoi(text); err == nil {
+		return i
+	}
+	if b, err := strconv.ParseBool(text); err == nil {
+		return b
+	}
+	if d, err := time.ParseDuration(text); err == nil {
+		return d
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	return text
+}
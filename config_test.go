@@ -0,0 +1,59 @@
+package Flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestParamConfigPrecedence tests that explicit params win over env, which wins over config and defaults
+func TestParamConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flow.json")
+	if err := os.WriteFile(path, []byte(`{"FLOW_TEST_LIMIT": 8, "retry_delay": "250ms"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FLOW_TEST_RETRIES", "5")
+	t.Setenv("FLOW_TEST_LIMIT", "4")
+
+	config, err := LoadParamConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	params, err := config.Resolve(map[string]interface{}{
+		"retries":        "${env:FLOW_TEST_RETRIES:3}",
+		"parallel_limit": "${env:FLOW_TEST_LIMIT}",
+		"retry_delay":    "${config:retry_delay:1s}",
+		"parallel":       "${env:FLOW_TEST_UNSET:true}",
+		"name":           "worker-${env:FLOW_TEST_RETRIES}",
+		"batch":          true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"retries":        5,
+		"parallel_limit": 4,
+		"retry_delay":    250 * time.Millisecond,
+		"parallel":       true,
+		"name":           "worker-5",
+		"batch":          true,
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("Expected %s = %v (%T), got %v (%T)", key, value, value, params[key], params[key])
+		}
+	}
+}
+
+// TestResolveParamsMissing tests that a placeholder without value or default is an error
+func TestResolveParamsMissing(t *testing.T) {
+	if _, err := ResolveParams(map[string]interface{}{"retries": "${env:FLOW_TEST_UNSET}"}); err == nil {
+		t.Error("Expected an error for an unset placeholder without default")
+	}
+	params, err := ResolveParams(map[string]interface{}{"prefix": "${env:FLOW_TEST_UNSET:}"})
+	if err != nil || params["prefix"] != "" {
+		t.Errorf("Expected an explicit empty default, got %v, %v", params["prefix"], err)
+	}
+}
@@ -0,0 +1,36 @@
+package Flow
+
+import "context"
+
+// ContextDeadlineKey is the SharedState key under which RunContext exposes the
+// deadline of its context as a time.Time, when the context has one
+const ContextDeadlineKey = "ctx_deadline"
+
+// SetContextExtractor sets a function that selects values from the context passed
+// to RunContext or RunAsync, such as trace or request IDs, and copies them into
+// the SharedState before the first node runs, so exec funcs can read them without
+// plumbing. The context deadline is always exposed under ContextDeadlineKey.
+//
+// Example:
+//
+//	pipeline.SetContextExtractor(func(ctx context.Context) map[string]interface{} {
+//		return map[string]interface{}{"request_id": ctx.Value(requestIDKey{})}
+//	})
+func (f *Flow) SetContextExtractor(fn func(ctx context.Context) map[string]interface{}) {
+	f.extractor = fn
+}
+
+// bridgeContext copies the deadline and extracted values of ctx into shared
+func (f *Flow) bridgeContext(ctx context.Context, shared *SharedState) {
+	if deadline, ok := ctx.Deadline(); ok {
+		shared.Set(ContextDeadlineKey, deadline)
+	}
+	if f.extractor == nil {
+		return
+	}
+	for key, value := range f.extractor(ctx) {
+		if value != nil {
+			shared.Set(key, value)
+		}
+	}
+}
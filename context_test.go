@@ -0,0 +1,49 @@
+package Flow
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type requestIDKey struct{}
+
+type traceIDKey struct{}
+
+// TestContextBridgedIntoState tests that ctx deadline and extracted values reach exec funcs
+func TestContextBridgedIntoState(t *testing.T) {
+	var requestID interface{}
+	var deadline time.Time
+
+	node := NewNode()
+	node.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		requestID = shared.Get("request_id")
+		deadline, _ = shared.Get(ContextDeadlineKey).(time.Time)
+		return "done", nil
+	})
+
+	pipeline := NewFlow().Start(node)
+	pipeline.SetContextExtractor(func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{
+			"request_id": ctx.Value(requestIDKey{}),
+			"trace_id":   ctx.Value(traceIDKey{}),
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), requestIDKey{}, "req-42"), time.Minute)
+	defer cancel()
+	state := NewSharedState()
+	if _, err := pipeline.RunContext(ctx, state); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if requestID != "req-42" {
+		t.Errorf("Expected request ID 'req-42', got %v", requestID)
+	}
+	if want, _ := ctx.Deadline(); !deadline.Equal(want) {
+		t.Errorf("Expected deadline %v, got %v", want, deadline)
+	}
+	if state.Get("trace_id") != nil {
+		t.Error("Expected nil extracted values to be skipped")
+	}
+}
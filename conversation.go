@@ -0,0 +1,86 @@
+package Flow
+
+import "unicode/utf8"
+
+// Message is one turn of a conversation history
+type Message struct {
+	// Role is who produced the turn, e.g. "system", "user" or "assistant"
+	Role string `json:"role"`
+	// Content is the text of the turn
+	Content string `json:"content"`
+	// Summary marks a system message written by a summary node in place of older turns
+	Summary bool `json:"summary,omitempty"`
+}
+
+// messageOverhead approximates the tokens a chat format spends framing each message
+const messageOverhead = 4
+
+// EstimateTokens approximates the number of tokens in text at four characters a
+// token, the usual rule of thumb for English text with BPE tokenizers
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// NewSummaryNode creates a memory node that keeps the []Message history stored under
+// historyKey within maxTokens. While the history fits it is left alone; once it
+// exceeds maxTokens, every turn but the keepRecent most recent ones is passed to
+// summarize, typically a model call, and replaced by a single "system" message
+// holding the summary. A leading system prompt is never summarized, and an
+// earlier summary is folded into the next one. Tokens are estimated with
+// EstimateTokens. The summarize call runs in the exec phase, so retries apply to
+// it. The node always returns "default".
+//
+// Parameters:
+//   - historyKey: The SharedState key holding the []Message history
+//   - maxTokens: The token budget that triggers summarization
+//   - keepRecent: The number of most recent turns kept verbatim
+//   - summarize: Condenses older turns into a summary
+//
+// Returns:
+//   - *Node: The memory node
+//
+// Example:
+//
+//	memory := flow.NewSummaryNode("history", 3000, 6, func(turns []flow.Message) (string, error) {
+//		return complete("Summarize this conversation:", turns)
+//	})
+//	memory.Next(chat, "default")
+func NewSummaryNode(historyKey string, maxTokens, keepRecent int, summarize func(older []Message) (string, error)) *Node {
+	if keepRecent < 0 {
+		keepRecent = 0
+	}
+	n := NewNode()
+	n.SetPrepFunc(func(shared *SharedState) interface{} {
+		history, _ := shared.Get(historyKey).([]Message)
+		return history
+	})
+	n.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		history := prep.([]Message)
+		if countMessages(EstimateTokenizer, history) <= maxTokens {
+			return history, nil
+		}
+
+		start := 0
+		if len(history) > 0 && history[0].Role == "system" && !history[0].Summary {
+			start = 1
+		}
+		end := len(history) - keepRecent
+		if end-start <= 0 {
+			// Nothing old enough to summarize
+			return history, nil
+		}
+
+		summary, err := summarize(append([]Message(nil), history[start:end]...))
+		if err != nil {
+			return nil, err
+		}
+		compacted := append([]Message(nil), history[:start]...)
+		compacted = append(compacted, Message{Role: "system", Content: summary, Summary: true})
+		return append(compacted, history[end:]...), nil
+	})
+	n.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set(historyKey, exec.([]Message))
+		return "default"
+	})
+	return n
+}
@@ -0,0 +1,69 @@
+package Flow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSummaryNode tests that older turns are summarized once the history exceeds its budget
+func TestSummaryNode(t *testing.T) {
+	var summarized [][]Message
+	memory := NewSummaryNode("history", 40, 2, func(older []Message) (string, error) {
+		summarized = append(summarized, older)
+		return "summary of " + older[len(older)-1].Content, nil
+	})
+
+	turn := func(role, text string) Message {
+		return Message{Role: role, Content: text + strings.Repeat(".", 36)}
+	}
+	state := NewSharedState()
+	state.Set("history", []Message{{Role: "system", Content: "be brief"}, turn("user", "hi")})
+	if action := memory.Run(state); action != "default" {
+		t.Fatalf("Expected default, got %q", action)
+	}
+	if len(summarized) != 0 || len(state.Get("history").([]Message)) != 2 {
+		t.Fatalf("Expected a history within budget to be left alone, got %v", state.Get("history"))
+	}
+
+	history := []Message{{Role: "system", Content: "be brief"}, turn("user", "q1"), turn("assistant", "a1"), turn("user", "q2"), turn("assistant", "a2")}
+	state.Set("history", history)
+	memory.Run(state)
+
+	compacted := state.Get("history").([]Message)
+	if len(compacted) != 4 || compacted[0].Content != "be brief" || !compacted[1].Summary || compacted[2] != history[3] {
+		t.Fatalf("Expected system prompt, summary and 2 recent turns, got %+v", compacted)
+	}
+	if len(summarized[0]) != 2 || summarized[0][0] != history[1] {
+		t.Errorf("Expected the 2 older turns to be summarized, got %+v", summarized[0])
+	}
+
+	// The next summary folds in the previous one
+	state.Set("history", append(compacted, turn("user", "q3")))
+	memory.Run(state)
+	if len(summarized) != 2 || !summarized[1][0].Summary {
+		t.Errorf("Expected the earlier summary to be summarized again, got %+v", summarized)
+	}
+}
+
+// TestSummaryNodeError tests that a failing summarizer fails the node and keeps the history
+func TestSummaryNodeError(t *testing.T) {
+	memory := NewSummaryNode("history", 1, 0, func([]Message) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+	state := NewSharedState()
+	history := []Message{{Role: "user", Content: "a long enough question"}}
+	state.Set("history", history)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the node to panic")
+			}
+		}()
+		memory.Run(state)
+	}()
+	if got := state.Get("history").([]Message); len(got) != 1 || got[0] != history[0] {
+		t.Errorf("Expected the history to be unchanged, got %+v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package Flow
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	// CorrelationIDKey is the SharedState key holding the run's correlation ID
+	CorrelationIDKey = "correlation_id"
+	// CorrelationHeader is the HTTP header InjectCorrelationID sets on outgoing requests
+	CorrelationHeader = "X-Correlation-ID"
+)
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context carrying id. Flows run with RunContext or
+// RunAsync copy it into SharedState under CorrelationIDKey, and it is attached to
+// events, audit entries, profiler labels and node samples, so traces across
+// services line up.
+//
+// Example:
+//
+//	ctx := flow.WithCorrelationID(r.Context(), r.Header.Get(flow.CorrelationHeader))
+//	action, err := pipeline.RunContext(ctx, state)
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationIDFrom returns the correlation ID carried by ctx, or ""
+func CorrelationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// SetCorrelationID stores id in shared state, for runs started with Run
+func SetCorrelationID(shared *SharedState, id string) {
+	shared.Set(CorrelationIDKey, id)
+}
+
+// CorrelationID returns the correlation ID stored in shared state, or ""
+func CorrelationID(shared *SharedState) string {
+	id, _ := shared.Get(CorrelationIDKey).(string)
+	return id
+}
+
+// InjectCorrelationID sets CorrelationHeader on header from shared state, for exec
+// funcs that call other services. It does nothing when the run has no ID.
+func InjectCorrelationID(header http.Header, shared *SharedState) {
+	if id := CorrelationID(shared); id != "" {
+		header.Set(CorrelationHeader, id)
+	}
+}
+
+// withCorrelation reconciles the correlation ID of ctx and shared: an ID in ctx is
+// copied into a state without one, and an ID in state is carried by the returned ctx
+func withCorrelation(ctx context.Context, shared *SharedState) context.Context {
+	fromState := CorrelationID(shared)
+	fromCtx := CorrelationIDFrom(ctx)
+	switch {
+	case fromCtx != "" && fromState == "":
+		SetCorrelationID(shared, fromCtx)
+		return ctx
+	case fromState != "" && fromState != fromCtx:
+		return WithCorrelationID(ctx, fromState)
+	default:
+		return ctx
+	}
+}
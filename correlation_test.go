@@ -0,0 +1,54 @@
+package Flow
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// TestCorrelationIDPropagation tests that a ctx correlation ID reaches state, events, audit, and headers
+func TestCorrelationIDPropagation(t *testing.T) {
+	header := http.Header{}
+	node := NewNode()
+	node.SetName("call")
+	node.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		InjectCorrelationID(header, shared)
+		return "done", nil
+	})
+
+	pipeline := NewFlow().Start(node)
+	sink := NewMemoryAuditSink()
+	pipeline.SetAuditSink(sink)
+	var events []Event
+	pipeline.Subscribe(func(e Event) { events = append(events, e) })
+
+	state := NewSharedState()
+	ctx := WithCorrelationID(context.Background(), "corr-7")
+	if _, err := pipeline.RunContext(ctx, state); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if CorrelationID(state) != "corr-7" {
+		t.Errorf("Expected state correlation ID 'corr-7', got '%s'", CorrelationID(state))
+	}
+	if header.Get(CorrelationHeader) != "corr-7" {
+		t.Errorf("Expected header 'corr-7', got '%s'", header.Get(CorrelationHeader))
+	}
+	for _, e := range events {
+		if e.CorrelationID != "corr-7" {
+			t.Errorf("Expected %s event to carry the correlation ID, got '%s'", e.Type, e.CorrelationID)
+		}
+	}
+	if entries := sink.Entries(); len(entries) != 1 || entries[0].CorrelationID != "corr-7" {
+		t.Errorf("Expected audit entry with correlation ID, got %+v", entries)
+	}
+
+	// An ID stored in state is used by Run
+	events = nil
+	state = NewSharedState()
+	SetCorrelationID(state, "corr-8")
+	pipeline.Run(state)
+	if len(events) == 0 || events[0].CorrelationID != "corr-8" {
+		t.Errorf("Expected events to carry the state correlation ID, got %+v", events)
+	}
+}
@@ -0,0 +1,199 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiCyan   = "\033[36m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiRed    = "\033[31m"
+	ansiDim    = "\033[2m"
+)
+
+// debugValueLimit truncates state values in the trace
+const debugValueLimit = 80
+
+// Debug runs the flow like Run while printing a colorized trace of every node,
+// retry, batch item, state change and chosen action to standard error. It is
+// meant for development; use DebugTo to choose the writer or disable color.
+//
+// Example:
+//
+//	action := flow.Debug(pipeline, state)
+func Debug(f *Flow, shared *SharedState) string {
+	return DebugTo(os.Stderr, true, f, shared)
+}
+
+// DebugTo runs the flow like Run, writing an indented trace to w. Each node is
+// printed with its retries, failed batch items, the state keys it wrote and
+// the action it chose. ANSI colors are used when color is true.
+//
+// Example trace:
+//
+//	▶ fetch
+//	  ↻ attempt 1 failed: timeout (retry in 10ms)
+//	  Δ page = "<html>…"
+//	  → parsed
+func DebugTo(w io.Writer, color bool, f *Flow, shared *SharedState) string {
+	t := &debugTracer{w: w, color: color, shared: shared, seqs: make(map[string]uint64)}
+	ctx := withSubscribers(context.Background(), []func(Event){t.event})
+	action, _, _ := f.orchestrate(ctx, shared, f.startNode, nil)
+	return action
+}
+
+// debugTracer formats events into the Debug trace
+type debugTracer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	color  bool
+	shared *SharedState
+	seqs   map[string]uint64 // state sequence when each running node started
+}
+
+func (t *debugTracer) event(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch e.Type {
+	case EventNodeStarted:
+		t.seqs[e.Node] = t.shared.sequence()
+		t.line(0, ansiCyan, "▶ %s", debugNodeName(e.Node))
+	case EventRetryScheduled:
+		t.line(1, ansiYellow, "↻ attempt %d failed: %s (retry in %v)", e.Attempt, redactError(e.Err), e.Delay)
+	case EventExecAttempt:
+		if e.Err == nil && e.Attempt > 1 {
+			t.line(1, ansiGreen, "✓ attempt %d succeeded", e.Attempt)
+		}
+	case EventBatchItemDone:
+		if e.Err != nil {
+			t.line(1, ansiRed, "✗ item %d failed: %s", e.Index, redactError(e.Err))
+		}
+	case EventActionChosen:
+		changes := t.shared.changesSince(t.seqs[e.Node])
+		delete(t.seqs, e.Node)
+		keys := make([]string, 0, len(changes))
+		for key := range changes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			t.line(1, ansiDim, "Δ %s = %s", key, debugValue(changes[key]))
+		}
+		t.line(1, ansiGreen, "→ %s", e.Action)
+	}
+}
+
+// line writes one indented trace line, colored when enabled
+func (t *debugTracer) line(indent int, color, format string, args ...interface{}) {
+	text := strings.Repeat("  ", indent) + fmt.Sprintf(format, args...)
+	if t.color {
+		text = color + text + ansiReset
+	}
+	fmt.Fprintln(t.w, text)
+}
+
+func debugNodeName(name string) string {
+	if name == "" {
+		return "(unnamed)"
+	}
+	return name
+}
+
+// debugValue formats a state value for the trace, truncating long values
+func debugValue(v interface{}) string {
+	text := fmt.Sprintf("%#v", v)
+	if s, ok := v.(string); ok {
+		text = fmt.Sprintf("%q", s)
+	}
+	if runes := []rune(text); len(runes) > debugValueLimit {
+		text = string(runes[:debugValueLimit]) + "…"
+	}
+	return text
+}
+
+// ErrDebugAbort is returned when a Debugger aborts a run at a breakpoint
+var ErrDebugAbort = errors.New("flow: run aborted by debugger")
+
+// DebugDecision tells the flow how to proceed from a breakpoint
+type DebugDecision int
+
+const (
+	// DebugContinue runs the breakpointed node normally
+	DebugContinue DebugDecision = iota
+	// DebugSkip does not run the node and routes from it with "default"
+	DebugSkip
+	// DebugAbort stops the run before the node, as if it had been interrupted
+	DebugAbort
+)
+
+// Debugger is invoked before a breakpointed node runs. It may inspect and mutate
+// the shared state, then decides whether to run the node, skip it, or abort.
+type Debugger interface {
+	Break(node *Node, shared *SharedState, lastAction string) DebugDecision
+}
+
+// DebuggerFunc adapts a function to the Debugger interface
+type DebuggerFunc func(node *Node, shared *SharedState, lastAction string) DebugDecision
+
+// Break calls fn
+func (fn DebuggerFunc) Break(node *Node, shared *SharedState, lastAction string) DebugDecision {
+	return fn(node, shared, lastAction)
+}
+
+// SetDebugger sets the debugger invoked at breakpoints set with SetBreakpoint
+func (f *Flow) SetDebugger(d Debugger) {
+	f.debugMu.Lock()
+	defer f.debugMu.Unlock()
+	f.debugger = d
+}
+
+// SetBreakpoint makes the flow's debugger run before every node with the given name.
+// Aborting at a breakpoint makes RunContext return an error wrapping ErrDebugAbort
+// and Run return the last action.
+//
+// Example:
+//
+//	pipeline.SetBreakpoint("charge")
+//	pipeline.SetDebugger(flow.DebuggerFunc(func(node *flow.Node, shared *flow.SharedState, last string) flow.DebugDecision {
+//		if shared.GetInt("amount") > 1000 {
+//			return flow.DebugAbort
+//		}
+//		return flow.DebugContinue
+//	}))
+func (f *Flow) SetBreakpoint(nodeName string) {
+	f.debugMu.Lock()
+	defer f.debugMu.Unlock()
+	if f.breakpoints == nil {
+		f.breakpoints = make(map[string]bool)
+	}
+	f.breakpoints[nodeName] = true
+}
+
+// ClearBreakpoint removes the breakpoint on nodes with the given name
+func (f *Flow) ClearBreakpoint(nodeName string) {
+	f.debugMu.Lock()
+	defer f.debugMu.Unlock()
+	delete(f.breakpoints, nodeName)
+}
+
+// breakAt consults the debugger if curr has a breakpoint, returning DebugContinue otherwise
+func (f *Flow) breakAt(curr *Node, shared *SharedState, lastAction string) DebugDecision {
+	f.debugMu.RLock()
+	debugger := f.debugger
+	hit := f.breakpoints[curr.name]
+	f.debugMu.RUnlock()
+	if debugger == nil || !hit {
+		return DebugContinue
+	}
+	return debugger.Break(curr, shared, lastAction)
+}
@@ -0,0 +1,111 @@
+package Flow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDebugTrace tests the trace of nodes, retries, state changes, and actions
+func TestDebugTrace(t *testing.T) {
+	counter := &mockCounter{}
+
+	fetch := NewNode()
+	fetch.SetName("fetch")
+	fetch.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if counter.increment() == 1 {
+			return nil, fmt.Errorf("timeout")
+		}
+		return "page", nil
+	})
+	fetch.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("page", exec)
+		return "parsed"
+	})
+	fetch.Next(NewNode(), "parsed")
+
+	pipeline := NewFlow().Start(fetch)
+	pipeline.SetParams(map[string]interface{}{"retries": 2, "retry_delay": time.Millisecond})
+
+	var buf bytes.Buffer
+	action := DebugTo(&buf, false, pipeline, NewSharedState())
+	if action != DefaultAction {
+		t.Errorf("Expected action 'default', got '%s'", action)
+	}
+
+	trace := buf.String()
+	for _, want := range []string{
+		"▶ fetch\n",
+		"  ↻ attempt 1 failed: timeout (retry in ",
+		"  ✓ attempt 2 succeeded\n",
+		"  Δ page = \"page\"\n",
+		"  → parsed\n",
+		"▶ (unnamed)\n",
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("Expected trace to contain %q, got:\n%s", want, trace)
+		}
+	}
+	if strings.Contains(trace, "\033[") {
+		t.Error("Expected no color codes when color is disabled")
+	}
+}
+
+// TestBreakpoints tests continuing, skipping, and aborting at breakpoints
+func TestBreakpoints(t *testing.T) {
+	var ran []string
+	step := func(name string) *Node {
+		node := NewNode()
+		node.SetName(name)
+		node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+			ran = append(ran, name)
+			return DefaultAction
+		})
+		return node
+	}
+	validate := step("validate")
+	charge := step("charge")
+	validate.Next(charge, DefaultAction).Next(step("notify"), DefaultAction)
+
+	pipeline := NewFlow().Start(validate)
+	pipeline.SetBreakpoint("charge")
+
+	decision := DebugContinue
+	var seenAction string
+	pipeline.SetDebugger(DebuggerFunc(func(node *Node, shared *SharedState, lastAction string) DebugDecision {
+		seenAction = lastAction
+		shared.Set("inspected", node.Name())
+		return decision
+	}))
+
+	state := NewSharedState()
+	pipeline.Run(state)
+	if fmt.Sprint(ran) != "[validate charge notify]" || state.Get("inspected") != "charge" || seenAction != DefaultAction {
+		t.Errorf("Unexpected continue run: ran %v, inspected %v, last action %q", ran, state.Get("inspected"), seenAction)
+	}
+
+	ran, decision = nil, DebugSkip
+	pipeline.Run(NewSharedState())
+	if fmt.Sprint(ran) != "[validate notify]" {
+		t.Errorf("Expected charge to be skipped, ran %v", ran)
+	}
+
+	ran, decision = nil, DebugAbort
+	if _, err := pipeline.RunContext(context.Background(), NewSharedState()); !errors.Is(err, ErrDebugAbort) {
+		t.Errorf("Expected ErrDebugAbort, got %v", err)
+	}
+	if fmt.Sprint(ran) != "[validate]" {
+		t.Errorf("Expected the run to stop before charge, ran %v", ran)
+	}
+
+	pipeline.ClearBreakpoint("charge")
+	ran = nil
+	pipeline.Run(NewSharedState())
+	if len(ran) != 3 {
+		t.Errorf("Expected the cleared breakpoint to be ignored, ran %v", ran)
+	}
+}
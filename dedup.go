@@ -0,0 +1,194 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Dedup remembers the idempotency keys of items whose side effects already
+// happened. Batch items (and single executions) of a node with a "dedup" param
+// are skipped when their key has been seen, so replays and overlapping runs
+// don't repeat side-effectful work such as charging a card or sending an email.
+// A key is marked once its exec call succeeds; failed items remain eligible.
+type Dedup interface {
+	// Seen reports whether key has been marked
+	Seen(key string) bool
+	// Mark records key as processed
+	Mark(key string)
+}
+
+// DedupReserver is implemented by Dedup stores that can claim a key atomically
+// before its exec call runs, so overlapping runs can't both execute an item
+// between one's Seen and Mark. A reserved key is marked once the call succeeds
+// and released if it fails. MemoryDedup and RedisDedup implement it.
+type DedupReserver interface {
+	// Reserve claims key, reporting false if it is already marked or reserved
+	Reserve(key string) bool
+	// Release frees a reserved key whose exec call failed
+	Release(key string)
+}
+
+var dedupRegistry = struct {
+	mu     sync.RWMutex
+	stores map[string]Dedup
+}{
+	stores: make(map[string]Dedup),
+}
+
+// RegisterDedup registers a deduplication store under name, replacing any store
+// previously registered with that name, so nodes can reference it through the
+// "dedup" param.
+//
+// Example:
+//
+//	flow.RegisterDedup("payments", flow.NewRedisDedup("localhost:6379", "payments:", 24*time.Hour))
+//
+//	charge.SetParams(map[string]interface{}{
+//		"data":  orders,
+//		"batch": true,
+//		"dedup": "payments",
+//	})
+//	charge.SetIdempotencyKeyFunc(func(item interface{}) string {
+//		return item.(Order).ID
+//	})
+func RegisterDedup(name string, d Dedup) {
+	dedupRegistry.mu.Lock()
+	defer dedupRegistry.mu.Unlock()
+	dedupRegistry.stores[name] = d
+}
+
+// lookupDedup returns the store registered under name, or nil if none exists
+func lookupDedup(name string) Dedup {
+	dedupRegistry.mu.RLock()
+	defer dedupRegistry.mu.RUnlock()
+	return dedupRegistry.stores[name]
+}
+
+// SetIdempotencyKeyFunc sets the function deriving an item's idempotency key for
+// the node's "dedup" store. Without one, items are keyed by fmt.Sprint(item).
+func (n *Node) SetIdempotencyKeyFunc(fn func(item interface{}) string) {
+	n.idempotencyKey = fn
+}
+
+// dedup returns the node's deduplication store and the key of input, or a nil
+// store if the node has no "dedup" param
+func (n *Node) dedup(ctx context.Context, input interface{}) (Dedup, string) {
+	name := n.getStringParam(ctx, "dedup")
+	if name == "" {
+		return nil, ""
+	}
+	store := lookupDedup(name)
+	if store == nil {
+		panic(fmt.Errorf("flow: dedup store %q is not registered", name))
+	}
+	if n.idempotencyKey != nil {
+		return store, n.idempotencyKey(input)
+	}
+	return store, fmt.Sprint(input)
+}
+
+// MemoryDedup is an in-process Dedup, suitable for a single long-lived process
+type MemoryDedup struct {
+	mu   sync.RWMutex
+	seen map[string]bool
+}
+
+// NewMemoryDedup creates an empty in-memory deduplication store
+func NewMemoryDedup() *MemoryDedup {
+	return &MemoryDedup{seen: make(map[string]bool)}
+}
+
+// Seen reports whether key has been marked
+func (d *MemoryDedup) Seen(key string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.seen[key]
+}
+
+// Mark records key as processed
+func (d *MemoryDedup) Mark(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seen[key] = true
+}
+
+// Reserve claims key, reporting false if it is already marked or reserved
+func (d *MemoryDedup) Reserve(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen[key] {
+		return false
+	}
+	d.seen[key] = true
+	return true
+}
+
+// Release frees a reserved key
+func (d *MemoryDedup) Release(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, key)
+}
+
+// RedisDedup is a Dedup stored in Redis, shared by every process using the same
+// server and prefix. Keys are reserved with SET NX, so only one process executes
+// each item, and expire after the configured TTL, if any; a reservation left by
+// a crashed process is held until it expires. Redis errors panic, so a store
+// outage fails the node rather than repeating side effects.
+type RedisDedup struct {
+	client *redisClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisDedup creates a deduplication store on the Redis server at addr
+// ("host:port"). Keys are stored under prefix and expire after ttl (never if ttl <= 0).
+func NewRedisDedup(addr, prefix string, ttl time.Duration) *RedisDedup {
+	return &RedisDedup{client: newRedisClient(addr), prefix: prefix, ttl: ttl}
+}
+
+// Seen reports whether key has been marked
+func (d *RedisDedup) Seen(key string) bool {
+	reply, err := d.client.do("EXISTS", d.prefix+key)
+	if err != nil {
+		panic(fmt.Errorf("flow: redis dedup: %w", err))
+	}
+	count, _ := reply.(int64)
+	return count > 0
+}
+
+// Mark records key as processed
+func (d *RedisDedup) Mark(key string) {
+	args := append([]string{"SET", d.prefix + key, "1"}, redisTTLArgs(d.ttl)...)
+	if _, err := d.client.do(args...); err != nil {
+		panic(fmt.Errorf("flow: redis dedup: %w", err))
+	}
+}
+
+// Reserve claims key with a single SET NX, reporting false if it is already
+// marked or reserved
+func (d *RedisDedup) Reserve(key string) bool {
+	args := append([]string{"SET", d.prefix + key, "1", "NX"}, redisTTLArgs(d.ttl)...)
+	reply, err := d.client.do(args...)
+	if err != nil {
+		panic(fmt.Errorf("flow: redis dedup: %w", err))
+	}
+	return reply == "OK"
+}
+
+// Release frees a reserved key
+func (d *RedisDedup) Release(key string) {
+	if _, err := d.client.do("DEL", d.prefix+key); err != nil {
+		panic(fmt.Errorf("flow: redis dedup: %w", err))
+	}
+}
+
+// Ping checks connectivity to the Redis server, for use as a Runner health check
+func (d *RedisDedup) Ping() error {
+	if err := d.client.ping(); err != nil {
+		return fmt.Errorf("flow: redis dedup: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package Flow
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDedupSkipsProcessedItems tests that re-running a batch skips items whose side effects happened
+func TestDedupSkipsProcessedItems(t *testing.T) {
+	RegisterDedup("test-payments", NewMemoryDedup())
+	var charges int64
+
+	type order struct{ ID string }
+	charge := NewNode()
+	charge.SetParams(map[string]interface{}{
+		"data":     []interface{}{order{"a"}, order{"b"}, order{"c"}},
+		"batch":    true,
+		"parallel": true,
+		"dedup":    "test-payments",
+	})
+	charge.SetIdempotencyKeyFunc(func(item interface{}) string {
+		return item.(order).ID
+	})
+	charge.SetExecFunc(func(item interface{}) (interface{}, error) {
+		atomic.AddInt64(&charges, 1)
+		return "charged " + item.(order).ID, nil
+	})
+
+	charge.Run(NewSharedState())
+	state := NewSharedState()
+	charge.Run(state)
+
+	if charges != 3 {
+		t.Errorf("Expected each order to be charged once, got %d charges", charges)
+	}
+	for _, result := range state.GetSlice(BatchResultsKey) {
+		if result != nil {
+			t.Errorf("Expected skipped items to have nil results, got %v", result)
+		}
+	}
+}
+
+// TestDedupUnregistered tests that referencing an unknown store panics
+func TestDedupUnregistered(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"dedup": "test-missing"})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) { return nil, nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for an unregistered dedup store")
+		}
+	}()
+	node.Run(NewSharedState())
+}
+
+// TestRedisDedup tests that marks are stored under the prefix with the TTL
+func TestRedisDedup(t *testing.T) {
+	server := startFakeRedis(t)
+	dedup := NewRedisDedup(server.addr, "emails:", time.Hour)
+
+	if dedup.Seen("welcome:42") {
+		t.Error("Expected an unmarked key not to be seen")
+	}
+	dedup.Mark("welcome:42")
+	if !dedup.Seen("welcome:42") {
+		t.Error("Expected a marked key to be seen")
+	}
+	if server.ttls["emails:welcome:42"] != "3600000" {
+		t.Errorf("Expected a one hour TTL, got %q", server.ttls["emails:welcome:42"])
+	}
+}
+
+// TestRedisDedupReserve tests that a key is reserved once and released for a retry
+func TestRedisDedupReserve(t *testing.T) {
+	server := startFakeRedis(t)
+	dedup := NewRedisDedup(server.addr, "charges:", time.Minute)
+
+	if !dedup.Reserve("order-1") {
+		t.Fatal("Expected the first reservation to succeed")
+	}
+	if dedup.Reserve("order-1") || !dedup.Seen("order-1") {
+		t.Error("Expected a reserved key to be taken")
+	}
+	dedup.Release("order-1")
+	if dedup.Seen("order-1") || !dedup.Reserve("order-1") {
+		t.Error("Expected a released key to be reservable again")
+	}
+}
+
+// TestDedupOverlappingRuns tests that concurrent runs execute each item once
+func TestDedupOverlappingRuns(t *testing.T) {
+	server := startFakeRedis(t)
+	RegisterDedup("test-overlap", NewRedisDedup(server.addr, "overlap:", time.Minute))
+	var charges int64
+	release := make(chan struct{})
+	failing := int64(1)
+
+	charge := NewNode()
+	charge.SetParams(map[string]interface{}{
+		"data":     []interface{}{"a", "b", "c", "d"},
+		"batch":    true,
+		"parallel": true,
+		"dedup":    "test-overlap",
+	})
+	charge.SetExecFunc(func(item interface{}) (interface{}, error) {
+		<-release
+		if item == "d" && atomic.CompareAndSwapInt64(&failing, 1, 0) {
+			return nil, errors.New("card declined")
+		}
+		atomic.AddInt64(&charges, 1)
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { recover() }()
+			charge.Run(NewSharedState())
+		}()
+	}
+	// Give every run a chance to reserve before any exec returns
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		server.mu.Lock()
+		reserved := len(server.data)
+		server.mu.Unlock()
+		if reserved == 4 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if charges != 3 {
+		t.Errorf("Expected the overlapping runs to charge a, b and c once, got %d charges", charges)
+	}
+	// The failed item was released, so the next run executes it
+	charge.Run(NewSharedState())
+	if charges != 4 {
+		t.Errorf("Expected the failed item to run again, got %d charges", charges)
+	}
+}
@@ -0,0 +1,260 @@
+package Flow
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fanInAction labels the edges from a StartAll join node to its branches in a FlowDiff
+const fanInAction = "(branch)"
+
+// FlowDiff is the structural difference between two versions of a flow graph,
+// as computed by Diff. Nodes are matched by name; unnamed nodes are matched by
+// their position among the unnamed nodes in breadth-first order, and repeated
+// names by their position among the nodes sharing the name, so naming nodes
+// keeps the diff meaningful when the graph is reshaped.
+type FlowDiff struct {
+	// StartBefore and StartAfter name the start nodes when the start node changed
+	StartBefore, StartAfter string
+	// AddedNodes and RemovedNodes are sorted by name
+	AddedNodes, RemovedNodes []string
+	// Edges are the added, removed and rerouted edges, sorted by node and action
+	Edges []EdgeChange
+	// Params are the changed params of nodes in both versions, sorted by node and key
+	Params []ParamChange
+}
+
+// EdgeChange is an edge whose target differs between the versions. Before is ""
+// for an added edge and After is "" for a removed one. Weighted candidates are
+// listed with their weights, e.g. "stable (95), canary (5)".
+type EdgeChange struct {
+	From, Action  string
+	Before, After string
+}
+
+// ParamChange is a node param whose value differs between the versions. Added
+// params have a nil Before and removed params have a nil After. ParamFunc values
+// are opaque and only compared for presence.
+type ParamChange struct {
+	Node, Key     string
+	Before, After interface{}
+}
+
+// Diff compares the graphs reachable from the start nodes of a and b, from a to
+// b, e.g. to review what routing a change to a declarative workflow makes.
+//
+// Example:
+//
+//	diff := flow.Diff(current, proposed)
+//	if !diff.Empty() {
+//		fmt.Print(diff)
+//	}
+func Diff(a, b *Flow) *FlowDiff {
+	before, after := diffNodes(a), diffNodes(b)
+	d := &FlowDiff{}
+	if start, next := startName(a, before), startName(b, after); start != next {
+		d.StartBefore, d.StartAfter = start, next
+	}
+
+	for _, id := range sortedKeys(before.byID) {
+		if _, ok := after.byID[id]; !ok {
+			d.RemovedNodes = append(d.RemovedNodes, id)
+		}
+	}
+	for _, id := range sortedKeys(after.byID) {
+		if _, ok := before.byID[id]; !ok {
+			d.AddedNodes = append(d.AddedNodes, id)
+		}
+	}
+
+	for _, id := range sortedKeys(mergeKeys(before.byID, after.byID)) {
+		oldEdges, newEdges := before.edges(id), after.edges(id)
+		for _, action := range sortedKeys(mergeKeys(oldEdges, newEdges)) {
+			if oldEdges[action] != newEdges[action] {
+				d.Edges = append(d.Edges, EdgeChange{From: id, Action: action, Before: oldEdges[action], After: newEdges[action]})
+			}
+		}
+
+		oldNode, inBefore := before.byID[id]
+		newNode, inAfter := after.byID[id]
+		if !inBefore || !inAfter {
+			continue
+		}
+		oldParams, newParams := oldNode.paramsCopy(), newNode.paramsCopy()
+		for _, key := range sortedKeys(mergeKeys(oldParams, newParams)) {
+			if !sameParam(oldParams[key], newParams[key]) {
+				d.Params = append(d.Params, ParamChange{Node: id, Key: key, Before: oldParams[key], After: newParams[key]})
+			}
+		}
+	}
+	return d
+}
+
+// Empty reports whether the versions have the same graph and params
+func (d *FlowDiff) Empty() bool {
+	return d.StartBefore == d.StartAfter && len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 &&
+		len(d.Edges) == 0 && len(d.Params) == 0
+}
+
+// String renders the diff one change per line, prefixed "+" for additions, "-"
+// for removals and "~" for changes
+func (d *FlowDiff) String() string {
+	var b strings.Builder
+	if d.StartBefore != d.StartAfter {
+		fmt.Fprintf(&b, "~ start: %s -> %s\n", orNone(d.StartBefore), orNone(d.StartAfter))
+	}
+	for _, id := range d.AddedNodes {
+		fmt.Fprintf(&b, "+ node %s\n", id)
+	}
+	for _, id := range d.RemovedNodes {
+		fmt.Fprintf(&b, "- node %s\n", id)
+	}
+	for _, e := range d.Edges {
+		switch {
+		case e.Before == "":
+			fmt.Fprintf(&b, "+ edge %s -[%s]-> %s\n", e.From, e.Action, e.After)
+		case e.After == "":
+			fmt.Fprintf(&b, "- edge %s -[%s]-> %s\n", e.From, e.Action, e.Before)
+		default:
+			fmt.Fprintf(&b, "~ edge %s -[%s]-> %s (was %s)\n", e.From, e.Action, e.After, e.Before)
+		}
+	}
+	for _, p := range d.Params {
+		switch {
+		case p.Before == nil:
+			fmt.Fprintf(&b, "+ param %s.%s: %s\n", p.Node, p.Key, formatParam(p.After))
+		case p.After == nil:
+			fmt.Fprintf(&b, "- param %s.%s: %s\n", p.Node, p.Key, formatParam(p.Before))
+		default:
+			fmt.Fprintf(&b, "~ param %s.%s: %s -> %s\n", p.Node, p.Key, formatParam(p.Before), formatParam(p.After))
+		}
+	}
+	return b.String()
+}
+
+// diffGraph is a flow's reachable nodes keyed by their diff identity
+type diffGraph struct {
+	byID map[string]*Node
+	ids  map[*Node]string
+}
+
+// diffNodes identifies the nodes reachable from f's start node
+func diffNodes(f *Flow) diffGraph {
+	g := diffGraph{byID: make(map[string]*Node), ids: make(map[*Node]string)}
+	seen := make(map[string]int)
+	for _, n := range f.nodes() {
+		id := debugNodeName(n.name)
+		seen[id]++
+		if n.name == "" || seen[id] > 1 {
+			id = fmt.Sprintf("%s#%d", id, seen[id])
+		}
+		g.byID[id] = n
+		g.ids[n] = id
+	}
+	return g
+}
+
+// edges returns the targets of the node's edges by action, or nil if the node
+// isn't in the graph
+func (g diffGraph) edges(id string) map[string]string {
+	n, ok := g.byID[id]
+	if !ok {
+		return nil
+	}
+	n.edgesMu.RLock()
+	defer n.edgesMu.RUnlock()
+	edges := make(map[string]string, len(n.successors))
+	for action, next := range n.successors {
+		if candidates := n.weighted[action]; len(candidates) > 0 {
+			targets := make([]string, len(candidates))
+			for i, c := range candidates {
+				targets[i] = fmt.Sprintf("%s (%g)", g.ids[c.node], c.weight)
+			}
+			edges[action] = strings.Join(targets, ", ")
+			continue
+		}
+		if next != nil {
+			edges[action] = g.ids[next]
+		}
+	}
+	if n.fanIn != nil {
+		branches := make([]string, len(n.fanIn.branches))
+		for i, branch := range n.fanIn.branches {
+			branches[i] = g.ids[branch]
+		}
+		edges[fanInAction] = strings.Join(branches, ", ")
+	}
+	return edges
+}
+
+// paramsCopy returns a copy of the node's params as set, without evaluating ParamFuncs
+func (n *Node) paramsCopy() map[string]interface{} {
+	n.paramsMu.RLock()
+	defer n.paramsMu.RUnlock()
+	params := make(map[string]interface{}, len(n.params))
+	for key, value := range n.params {
+		params[key] = value
+	}
+	return params
+}
+
+// startName returns the diff identity of f's start node, or "" if it has none
+func startName(f *Flow, g diffGraph) string {
+	if start := f.StartNode(); start != nil {
+		return g.ids[start]
+	}
+	return ""
+}
+
+// sameParam reports whether two param values are equal, treating any two
+// functions as equal since they can't be compared
+func sameParam(a, b interface{}) bool {
+	if a != nil && b != nil && reflect.TypeOf(a).Kind() == reflect.Func && reflect.TypeOf(b).Kind() == reflect.Func {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// formatParam renders a param value for FlowDiff.String
+func formatParam(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	if reflect.TypeOf(value).Kind() == reflect.Func {
+		return "(func)"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// orNone renders a missing start node for FlowDiff.String
+func orNone(name string) string {
+	if name == "" {
+		return "(none)"
+	}
+	return name
+}
+
+// mergeKeys returns the union of the keys of a and b
+func mergeKeys[V any](a, b map[string]V) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+// sortedKeys returns the keys of m in lexical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
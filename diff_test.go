@@ -0,0 +1,77 @@
+package Flow
+
+import (
+	"testing"
+)
+
+// diffTestFlow builds a small triage flow, optionally with the proposed changes
+func diffTestFlow(proposed bool) *Flow {
+	named := func(name string) *Node {
+		n := NewNode()
+		n.SetName(name)
+		return n
+	}
+	classify, reply, archive := named("classify"), named("reply"), named("archive")
+	classify.SetParams(map[string]interface{}{"retries": 3, "model": "small", "cache": true})
+	classify.Next(reply, "default")
+	classify.Next(archive, "spam")
+	if proposed {
+		escalate := named("escalate")
+		classify.SetParams(map[string]interface{}{"retries": 5, "model": "small", "timeout": "10s"})
+		classify.Next(escalate, "urgent")
+		classify.RemoveNext("spam")
+		reply.NextWeighted(named("review"), "check", 90)
+		reply.NextWeighted(escalate, "check", 10)
+	}
+	return NewFlow().Start(classify)
+}
+
+// TestDiff tests the structured diff between two versions of a flow
+func TestDiff(t *testing.T) {
+	d := Diff(diffTestFlow(false), diffTestFlow(true))
+
+	if len(d.AddedNodes) != 2 || d.AddedNodes[0] != "escalate" || d.AddedNodes[1] != "review" {
+		t.Errorf("Expected escalate and review to be added, got %v", d.AddedNodes)
+	}
+	if len(d.RemovedNodes) != 1 || d.RemovedNodes[0] != "archive" {
+		t.Errorf("Expected archive to be removed, got %v", d.RemovedNodes)
+	}
+	if d.StartBefore != "" || d.StartAfter != "" {
+		t.Errorf("Expected the start node to be unchanged, got %q -> %q", d.StartBefore, d.StartAfter)
+	}
+
+	want := `+ node escalate
++ node review
+- node archive
+- edge classify -[spam]-> archive
++ edge classify -[urgent]-> escalate
++ edge reply -[check]-> review (90), escalate (10)
+- param classify.cache: true
+~ param classify.retries: 3 -> 5
++ param classify.timeout: "10s"
+`
+	if got := d.String(); got != want {
+		t.Errorf("Expected rendering:\n%s\ngot:\n%s", want, got)
+	}
+
+	if same := Diff(diffTestFlow(true), diffTestFlow(true)); !same.Empty() {
+		t.Errorf("Expected identical flows to have an empty diff, got:\n%s", same)
+	}
+}
+
+// TestDiffUnnamedNodes tests that unnamed nodes are matched by position
+func TestDiffUnnamedNodes(t *testing.T) {
+	build := func(action string) *Flow {
+		first, second := NewNode(), NewNode()
+		first.Next(second, action)
+		return NewFlow().Start(first)
+	}
+	d := Diff(build("default"), build("done"))
+	if len(d.AddedNodes) != 0 || len(d.RemovedNodes) != 0 {
+		t.Errorf("Expected the unnamed nodes to match, got added %v and removed %v", d.AddedNodes, d.RemovedNodes)
+	}
+	want := "- edge (unnamed)#1 -[default]-> (unnamed)#2\n+ edge (unnamed)#1 -[done]-> (unnamed)#2\n"
+	if got := d.String(); got != want {
+		t.Errorf("Expected rendering:\n%s\ngot:\n%s", want, got)
+	}
+}
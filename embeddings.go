@@ -0,0 +1,106 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+)
+
+// embeddingChunksKey returns the state key holding the input chunks of an
+// embedding flow writing to outputKey
+func embeddingChunksKey(outputKey string) string {
+	return outputKey + ":chunks"
+}
+
+// embedChunksAction routes from an embedding node to its batch node
+const embedChunksAction = "embed_chunks"
+
+// NewEmbeddingNode creates a node that embeds the []string (or []interface{} of
+// strings) stored under inputKey in batches of at most maxBatch inputs, the
+// provider's limit on inputs per request, and stores the [][]float64 vectors
+// under outputKey in input order before returning "default". Each batch is one
+// exec call of an "embed" map node that the node loops through, so the flow's
+// params apply per request: "limiter" waits for a slot or token of a registered
+// limiter, "retries" retries a failed batch alone, and "parallel" with
+// "parallel_limit" sends batches concurrently. embed must return one vector per
+// input; a mismatch fails the batch without retrying. Pending batches are kept
+// under outputKey + ":chunks", and their results under BatchResultsKey (or
+// "results_key").
+//
+// Parameters:
+//   - inputKey: The SharedState key holding the texts to embed
+//   - outputKey: The SharedState key to store the vectors under
+//   - maxBatch: The most inputs per embed call (all at once if <= 0)
+//   - embed: Embeds one batch of texts
+//
+// Returns:
+//   - *Node: The embedding node, to connect "default" from
+//
+// Example:
+//
+//	flow.RateLimiter("openai", 50, time.Minute)
+//	embedder := flow.NewEmbeddingNode("chunks", "vectors", 2048, func(batch []string) ([][]float64, error) {
+//		return openai.Embed("text-embedding-3-small", batch)
+//	})
+//	embedder.Next(index, "default")
+//	pipeline := flow.NewFlow().Start(embedder)
+//	pipeline.SetParams(map[string]interface{}{"limiter": "openai", "retries": 3, "parallel": true, "parallel_limit": 4})
+func NewEmbeddingNode(inputKey, outputKey string, maxBatch int, embed func(batch []string) ([][]float64, error)) *Node {
+	chunksKey := embeddingChunksKey(outputKey)
+
+	// The node splits the input on its first visit and assembles the vectors when
+	// the batch node loops back, telling the two apart by the pending chunks
+	n := NewNode()
+	n.SetName("embeddings")
+	n.SetPrepFunc(func(shared *SharedState) interface{} {
+		if shared.Get(chunksKey) != nil {
+			return nil
+		}
+		return toStrings(shared.Get(inputKey))
+	})
+	n.postFunc = func(ctx context.Context, shared *SharedState, prep, exec interface{}) string {
+		if texts, ok := prep.([]string); ok {
+			shared.Set(chunksKey, chunkStrings(texts, maxBatch))
+			return embedChunksAction
+		}
+		vectors := [][]float64{}
+		for _, result := range shared.GetSlice(n.resultsKey(ctx)) {
+			vectors = append(vectors, result.([][]float64)...)
+		}
+		shared.Set(chunksKey, nil)
+		shared.Set(outputKey, vectors)
+		return DefaultAction
+	}
+
+	batches := NewMapNode(chunksKey, func(item interface{}) (interface{}, error) {
+		batch := item.([]string)
+		vectors, err := embed(batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, Permanent(fmt.Errorf("flow: embedding returned %d vectors for %d inputs", len(vectors), len(batch)))
+		}
+		return vectors, nil
+	})
+	batches.SetName("embed")
+
+	n.Next(batches, embedChunksAction)
+	batches.Next(n, BatchCompleteAction)
+	return n
+}
+
+// chunkStrings splits items into consecutive batches of at most size items
+func chunkStrings(items []string, size int) []interface{} {
+	if size <= 0 {
+		size = len(items)
+	}
+	var chunks []interface{}
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end])
+	}
+	return chunks
+}
@@ -0,0 +1,101 @@
+package Flow
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestEmbeddingNode tests batching to the provider limit and reassembly in input order
+func TestEmbeddingNode(t *testing.T) {
+	Limiter("test-embeddings", 2)
+
+	var mu sync.Mutex
+	var sizes []int
+	inFlight, peak, failures := 0, 0, 0
+	embedder := NewEmbeddingNode("texts", "vectors", 3, func(batch []string) ([][]float64, error) {
+		mu.Lock()
+		sizes = append(sizes, len(batch))
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		fail := batch[0] == "t3" && failures == 0
+		if fail {
+			failures++
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		if fail {
+			return nil, errors.New("429 rate limited")
+		}
+		vectors := make([][]float64, len(batch))
+		for i, text := range batch {
+			vectors[i] = []float64{float64(len(text)), float64(text[1] - '0')}
+		}
+		return vectors, nil
+	})
+
+	done := NewNode()
+	reached := false
+	done.SetExecFunc(func(interface{}) (interface{}, error) {
+		reached = true
+		return "default", nil
+	})
+	embedder.Next(done, DefaultAction)
+
+	state := NewSharedState()
+	state.Set("texts", []string{"t0", "t1", "t2", "t3", "t4", "t5", "t6", "t7"})
+	pipeline := NewFlow().Start(embedder)
+	pipeline.SetParams(map[string]interface{}{"limiter": "test-embeddings", "parallel": true, "retries": 2})
+	pipeline.Run(state)
+
+	vectors := state.Get("vectors").([][]float64)
+	if len(vectors) != 8 {
+		t.Fatalf("Expected 8 vectors, got %d", len(vectors))
+	}
+	for i, v := range vectors {
+		if int(v[1]) != i {
+			t.Errorf("Expected vector %d in input order, got %v", i, v)
+		}
+	}
+	if len(sizes) != 4 || failures != 1 {
+		t.Errorf("Expected batches of 3, 3 and 2 plus one retried batch, got %v", sizes)
+	}
+	if peak > 2 {
+		t.Errorf("Expected the limiter to hold concurrent batches to 2, got %d", peak)
+	}
+	if !reached {
+		t.Error("Expected the flow to continue after embedding")
+	}
+}
+
+// TestEmbeddingNodeMismatch tests that a batch with the wrong number of vectors fails without retrying
+func TestEmbeddingNodeMismatch(t *testing.T) {
+	calls := 0
+	embedder := NewEmbeddingNode("texts", "vectors", 10, func(batch []string) ([][]float64, error) {
+		calls++
+		return [][]float64{{1}}, nil
+	})
+	pipeline := NewFlow().Start(embedder)
+	pipeline.SetParams(map[string]interface{}{"retries": 3})
+
+	state := NewSharedState()
+	state.Set("texts", []interface{}{"a", "b"})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the mismatched batch to fail the flow")
+			}
+		}()
+		pipeline.Run(state)
+	}()
+	if calls != 1 {
+		t.Errorf("Expected one embed call, got %d", calls)
+	}
+}
@@ -0,0 +1,76 @@
+package Flow
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// ErrDecryptionFailed is returned when persisted data cannot be decrypted, because
+// it was tampered with, truncated, or encrypted with a different key
+var ErrDecryptionFailed = errors.New("flow: decryption failed")
+
+// Encrypter protects state before it is persisted, so PII and API payloads
+// captured in SharedState aren't written to disk or external stores in plaintext.
+// It is used by WriteSnapshot and ReadSnapshot.
+type Encrypter interface {
+	// Encrypt returns the ciphertext of plaintext
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt returns the plaintext of ciphertext produced by Encrypt
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// AESGCMEncrypter is an Encrypter using AES-GCM authenticated encryption. Each
+// ciphertext carries its own random nonce, so the same key can protect many
+// snapshots, and any modification is detected on decryption.
+type AESGCMEncrypter struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMEncrypter creates an encrypter from a 16, 24 or 32 byte key, selecting
+// AES-128, AES-192 or AES-256
+//
+// Example:
+//
+//	key, _ := hex.DecodeString(os.Getenv("STATE_KEY")) // 32 random bytes
+//	enc, err := flow.NewAESGCMEncrypter(key)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	flow.WriteSnapshot(file, state, enc)
+func NewAESGCMEncrypter(key []byte) (*AESGCMEncrypter, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("flow: invalid encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCMEncrypter{aead: aead}, nil
+}
+
+// Encrypt seals plaintext, prefixing the ciphertext with its nonce
+func (e *AESGCMEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize(), e.aead.NonceSize()+len(plaintext)+e.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens ciphertext produced by Encrypt, returning an error wrapping
+// ErrDecryptionFailed if it is not authentic
+func (e *AESGCMEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := e.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecryptionFailed)
+	}
+	plaintext, err := e.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptionFailed, err)
+	}
+	return plaintext, nil
+}
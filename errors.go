@@ -0,0 +1,180 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for branching on failure categories with errors.Is
+var (
+	// ErrRetriesExhausted matches a node failure after every attempt allowed by
+	// "retries" (or its "retry_budget") has failed
+	ErrRetriesExhausted = errors.New("flow: retries exhausted")
+	// ErrBatchPartialFailure matches a batch that stopped because one of its items failed
+	ErrBatchPartialFailure = errors.New("flow: batch item failed")
+	// ErrFlowTimeout matches a run stopped because its ctx deadline passed
+	ErrFlowTimeout = errors.New("flow: run timed out")
+	// ErrNoStartNode is returned by RunContext and Runner when a flow without a
+	// start node is run; Run returns "" for it instead
+	ErrNoStartNode = errors.New("flow: flow has no start node")
+)
+
+// NodeError wraps a failure with the place in the graph where it happened, so
+// logs and error trackers show which node, batch item and attempt failed rather
+// than a bare "API temporarily unavailable". Errors returned by exec and error
+// values panicked from a node are raised as *NodeError; use errors.As to inspect
+// it and errors.Is to match the underlying error or a failure category such as
+// ErrRetriesExhausted.
+//
+// Example:
+//
+//	var nodeErr *flow.NodeError
+//	if errors.As(err, &nodeErr) {
+//		log.Printf("node %s failed on item %d attempt %d: %v", nodeErr.Node, nodeErr.Index, nodeErr.Attempt, nodeErr.Err)
+//	}
+type NodeError struct {
+	// Node is the name of the failing node
+	Node string
+	// Flow is the name of the flow running the node, if any
+	Flow string
+	// Index is the position of the failing item in a batch or pipeline (0 outside batch mode)
+	Index int
+	// Total is the number of items in the batch (1 outside batch mode, 0 for pipelines)
+	Total int
+	// Attempt is the exec attempt that failed, or 0 if the node failed outside exec
+	Attempt int
+	// Err is the underlying error
+	Err error
+
+	// kinds are the sentinel failure categories the error matches
+	kinds []error
+}
+
+// Error describes the failure with its location in the graph
+func (e *NodeError) Error() string {
+	var b strings.Builder
+	b.WriteString("flow: ")
+	if e.Flow != "" {
+		fmt.Fprintf(&b, "flow %q ", e.Flow)
+	}
+	if e.Node == "" {
+		b.WriteString("unnamed node")
+	} else {
+		fmt.Fprintf(&b, "node %q", e.Node)
+	}
+	if e.Total != 1 {
+		fmt.Fprintf(&b, " item %d", e.Index)
+	}
+	if e.Attempt > 0 {
+		fmt.Fprintf(&b, " attempt %d", e.Attempt)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error belongs to the target failure category
+func (e *NodeError) Is(target error) bool {
+	for _, kind := range e.kinds {
+		if kind == target {
+			return true
+		}
+	}
+	return false
+}
+
+// execError wraps an exec failure with the node, item and attempt it happened on
+func (n *Node) execError(ctx context.Context, meta ItemMeta, err error) *NodeError {
+	return &NodeError{
+		Node:    n.name,
+		Flow:    flowNameFrom(ctx),
+		Index:   meta.Index,
+		Total:   meta.Total,
+		Attempt: meta.Attempt,
+		Err:     err,
+	}
+}
+
+// batchFailure marks the failure of a batch item as ErrBatchPartialFailure
+func batchFailure(err error) error {
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) {
+		nodeErr.kinds = append(nodeErr.kinds, ErrBatchPartialFailure)
+	}
+	return err
+}
+
+// wrapPanic re-raises an error panicking out of the node as a *NodeError, unless it
+// already carries a node's context. Non-error panic values propagate unchanged.
+// It must be deferred directly.
+func (n *Node) wrapPanic(ctx context.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err, ok := r.(error)
+	var nodeErr *NodeError
+	if !ok || errors.As(err, &nodeErr) {
+		panic(r)
+	}
+	panic(&NodeError{Node: n.name, Flow: flowNameFrom(ctx), Total: 1, Err: err})
+}
+
+// Permanent marks err as not worth retrying, such as a rejected request (HTTP
+// 400) or invalid input, so a retrying node fails on the first attempt instead of
+// spending its retries. The returned error unwraps to err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err, retryable: false}
+}
+
+// Transient marks err as worth retrying, overriding the default classification
+// (for example of a context error raised by a dependency's own timeout). The
+// returned error unwraps to err.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err, retryable: true}
+}
+
+// IsRetryable reports whether retrying nodes retry err. The outermost Permanent or
+// Transient mark in err's chain decides; otherwise context cancellation and
+// deadline errors are permanent, errors with a Temporary() bool method (such as
+// net.Error) are classified by it, and all other errors are retried.
+func IsRetryable(err error) bool {
+	var classified *classifiedError
+	if errors.As(err, &classified) {
+		return classified.retryable
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) {
+		return temporary.Temporary()
+	}
+	return true
+}
+
+// classifiedError carries an explicit retry classification
+type classifiedError struct {
+	err       error
+	retryable bool
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,214 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// nodeErrorCause returns the message of the error wrapped by a *NodeError, or "" if err is not one
+func nodeErrorCause(err error) string {
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) {
+		return ""
+	}
+	return nodeErr.Err.Error()
+}
+
+// TestNodeErrorCarriesLocation tests that exec failures report node, flow, item and attempt
+func TestNodeErrorCarriesLocation(t *testing.T) {
+	unavailable := errors.New("API temporarily unavailable")
+
+	node := NewNode()
+	node.SetName("enrich")
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) == 2 {
+			return nil, unavailable
+		}
+		return item, nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetName("ingest")
+	pipeline.SetParams(map[string]interface{}{
+		"data":    []int{0, 1, 2},
+		"batch":   true,
+		"retries": 2,
+	})
+
+	defer func() {
+		err, _ := recover().(error)
+		var nodeErr *NodeError
+		if !errors.As(err, &nodeErr) {
+			t.Fatalf("Expected *NodeError, got %v", err)
+		}
+		if nodeErr.Node != "enrich" || nodeErr.Flow != "ingest" || nodeErr.Index != 2 || nodeErr.Attempt != 2 {
+			t.Errorf("Unexpected error location: %+v", nodeErr)
+		}
+		if !errors.Is(err, unavailable) {
+			t.Error("Expected the underlying error to be matchable with errors.Is")
+		}
+		if want := `flow: flow "ingest" node "enrich" item 2 attempt 2: API temporarily unavailable`; err.Error() != want {
+			t.Errorf("Expected %q, got %q", want, err.Error())
+		}
+	}()
+	pipeline.Run(NewSharedState())
+}
+
+// TestNodeErrorWrapsPanics tests that error panics outside exec are wrapped once
+func TestNodeErrorWrapsPanics(t *testing.T) {
+	node := NewNode()
+	node.SetName("finalize")
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		panic(fmt.Errorf("disk full"))
+	})
+
+	defer func() {
+		r := recover()
+		nodeErr, ok := r.(*NodeError)
+		if !ok {
+			t.Fatalf("Expected *NodeError, got %T", r)
+		}
+		if nodeErr.Attempt != 0 || nodeErr.Err.Error() != "disk full" {
+			t.Errorf("Unexpected error: %+v", nodeErr)
+		}
+		if nodeErr.Error() != `flow: node "finalize": disk full` {
+			t.Errorf("Unexpected message: %v", nodeErr)
+		}
+	}()
+	node.Run(NewSharedState())
+}
+
+// TestSentinelErrors tests that failure categories can be matched with errors.Is
+func TestSentinelErrors(t *testing.T) {
+	failing := func() *Node {
+		node := NewNode()
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("upstream down")
+		})
+		return node
+	}
+	recovered := func(fn func()) (err error) {
+		defer func() { err, _ = recover().(error) }()
+		fn()
+		return nil
+	}
+
+	t.Run("RetriesExhausted", func(t *testing.T) {
+		node := failing()
+		node.SetParams(map[string]interface{}{"retries": 2})
+		err := recovered(func() { node.Run(NewSharedState()) })
+		if !errors.Is(err, ErrRetriesExhausted) || errors.Is(err, ErrBatchPartialFailure) {
+			t.Errorf("Expected only ErrRetriesExhausted, got %v", err)
+		}
+
+		single := failing()
+		if err := recovered(func() { single.Run(NewSharedState()) }); errors.Is(err, ErrRetriesExhausted) {
+			t.Error("Expected a node without retries not to match ErrRetriesExhausted")
+		}
+	})
+
+	t.Run("BatchPartialFailure", func(t *testing.T) {
+		for _, parallel := range []bool{false, true} {
+			node := failing()
+			node.SetParams(map[string]interface{}{"data": []int{1, 2}, "batch": true, "parallel": parallel})
+			if err := recovered(func() { node.Run(NewSharedState()) }); !errors.Is(err, ErrBatchPartialFailure) {
+				t.Errorf("Expected ErrBatchPartialFailure (parallel: %v), got %v", parallel, err)
+			}
+		}
+	})
+
+	t.Run("FlowTimeout", func(t *testing.T) {
+		slow := NewNode()
+		slow.SetExecFunc(func(prep interface{}) (interface{}, error) {
+			time.Sleep(20 * time.Millisecond)
+			return DefaultAction, nil
+		})
+		slow.Next(NewNode(), DefaultAction)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		_, err := NewFlow().Start(slow).RunContext(ctx, NewSharedState())
+		if !errors.Is(err, ErrFlowTimeout) || !errors.Is(err, ErrRunInterrupted) {
+			t.Errorf("Expected ErrFlowTimeout, got %v", err)
+		}
+
+		cancelled, cancelNow := context.WithCancel(context.Background())
+		cancelNow()
+		if _, err := NewFlow().Start(slow).RunContext(cancelled, NewSharedState()); errors.Is(err, ErrFlowTimeout) {
+			t.Error("Expected cancellation not to match ErrFlowTimeout")
+		}
+	})
+
+	t.Run("NoStartNode", func(t *testing.T) {
+		if err := recovered(func() {
+			if action := NewFlow().Run(NewSharedState()); action != "" {
+				t.Errorf("Expected Run to return an empty action, got %q", action)
+			}
+		}); err != nil {
+			t.Errorf("Expected Run not to panic, got %v", err)
+		}
+		if _, err := NewFlow().RunContext(context.Background(), NewSharedState()); !errors.Is(err, ErrNoStartNode) {
+			t.Errorf("Expected RunContext to return ErrNoStartNode, got %v", err)
+		}
+		if _, err := NewRunner().Run(NewFlow(), NewSharedState()); !errors.Is(err, ErrNoStartNode) {
+			t.Errorf("Expected Runner.Run to return ErrNoStartNode, got %v", err)
+		}
+	})
+}
+
+// temporaryError mimics a net.Error reporting whether it is temporary
+type temporaryError struct{ temporary bool }
+
+func (e temporaryError) Error() string   { return "network error" }
+func (e temporaryError) Temporary() bool { return e.temporary }
+
+// TestIsRetryable tests the default error classification and explicit marks
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{errors.New("unavailable"), true},
+		{Permanent(errors.New("bad request")), false},
+		{fmt.Errorf("wrapped: %w", Permanent(errors.New("bad request"))), false},
+		{context.Canceled, false},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), false},
+		{Transient(context.DeadlineExceeded), true},
+		{Permanent(Transient(errors.New("outer mark wins"))), false},
+		{temporaryError{temporary: true}, true},
+		{temporaryError{temporary: false}, false},
+	}
+	for _, c := range cases {
+		if got := IsRetryable(c.err); got != c.retryable {
+			t.Errorf("IsRetryable(%v) = %v, expected %v", c.err, got, c.retryable)
+		}
+	}
+	if Permanent(nil) != nil || Transient(nil) != nil {
+		t.Error("Expected nil errors to stay nil")
+	}
+}
+
+// TestPermanentErrorStopsRetries tests that a permanent failure is not retried
+func TestPermanentErrorStopsRetries(t *testing.T) {
+	errBadRequest := errors.New("bad request")
+	attempts := 0
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"retries": 5})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		attempts++
+		return nil, Permanent(errBadRequest)
+	})
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, errBadRequest) || errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("Expected a permanent failure without exhausting retries, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected 1 attempt, got %d", attempts)
+		}
+	}()
+	node.Run(NewSharedState())
+}
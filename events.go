@@ -0,0 +1,104 @@
+package Flow
+
+import (
+	"context"
+	"time"
+)
+
+// EventType identifies the kind of execution event
+type EventType string
+
+const (
+	// EventNodeStarted is emitted when a node begins executing
+	EventNodeStarted EventType = "node_started"
+	// EventExecAttempt is emitted after every exec call with its result or error
+	EventExecAttempt EventType = "exec_attempt"
+	// EventRetryScheduled is emitted before sleeping ahead of a retry
+	EventRetryScheduled EventType = "retry_scheduled"
+	// EventBatchItemDone is emitted when a batch item finishes, successfully or not
+	EventBatchItemDone EventType = "batch_item_done"
+	// EventActionChosen is emitted when a node finishes and returns its action
+	EventActionChosen EventType = "action_chosen"
+)
+
+// Event is a structured record of one step of flow execution.
+// Fields that don't apply to an event type are left at their zero value.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Flow and Node identify where the event happened
+	Flow string
+	Node string
+	// CorrelationID is the run's correlation ID, if any
+	CorrelationID string
+	// Index is the batch item index (0 outside of batch mode)
+	Index int
+	// Attempt is the one-based exec attempt (ExecAttempt, RetryScheduled)
+	Attempt int
+	// Delay is the backoff before the next attempt (RetryScheduled)
+	Delay time.Duration
+	// Elapsed is the time since the item started, including retries (BatchItemDone)
+	Elapsed time.Duration
+	// Result is the exec result (ExecAttempt, BatchItemDone)
+	Result interface{}
+	// Err is the exec error, if any (ExecAttempt, BatchItemDone, RetryScheduled)
+	Err error
+	// Action is the action returned by the node (ActionChosen)
+	Action string
+}
+
+// eventsKey carries the subscribers of the running flow in a context
+type eventsKey struct{}
+
+// Subscribe registers a function that receives every Event emitted while the flow
+// runs, so external debuggers and UIs can observe execution in real time.
+// Subscribers are called synchronously and, for parallel batches, concurrently,
+// so they must be fast and safe for concurrent use.
+//
+// Example:
+//
+//	pipeline.Subscribe(func(e flow.Event) {
+//		log.Printf("%s %s/%s attempt=%d err=%v", e.Type, e.Flow, e.Node, e.Attempt, e.Err)
+//	})
+func (f *Flow) Subscribe(fn func(Event)) {
+	f.subscribers = append(f.subscribers, fn)
+}
+
+// ChannelSubscriber adapts a channel into a subscriber for Subscribe.
+// Sends block, so the channel must be drained (or buffered) for the flow to progress.
+//
+// Example:
+//
+//	events := make(chan flow.Event, 100)
+//	pipeline.Subscribe(flow.ChannelSubscriber(events))
+func ChannelSubscriber(ch chan<- Event) func(Event) {
+	return func(e Event) {
+		ch <- e
+	}
+}
+
+// withSubscribers attaches event subscribers to ctx, after any it already carries
+func withSubscribers(ctx context.Context, subscribers []func(Event)) context.Context {
+	if len(subscribers) == 0 {
+		return ctx
+	}
+	existing, _ := ctx.Value(eventsKey{}).([]func(Event))
+	combined := make([]func(Event), 0, len(existing)+len(subscribers))
+	combined = append(append(combined, existing...), subscribers...)
+	return context.WithValue(ctx, eventsKey{}, combined)
+}
+
+// emit delivers an event from node n to the subscribers carried by ctx
+func (n *Node) emit(ctx context.Context, e Event) {
+	subscribers, ok := ctx.Value(eventsKey{}).([]func(Event))
+	if !ok {
+		return
+	}
+	e.Time = time.Now()
+	e.Flow = flowNameFrom(ctx)
+	e.Node = n.name
+	e.CorrelationID = CorrelationIDFrom(ctx)
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
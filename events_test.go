@@ -0,0 +1,100 @@
+package Flow
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEventJournal tests the sequence of events emitted by a retrying flow
+func TestEventJournal(t *testing.T) {
+	counter := &mockCounter{}
+
+	node := NewNode()
+	node.SetName("call")
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if counter.increment() == 1 {
+			return nil, fmt.Errorf("transient")
+		}
+		return "ok", nil
+	})
+
+	pipeline := NewFlow().Start(node)
+	pipeline.SetName("journal")
+	// Flow params are applied to every node it runs
+	pipeline.SetParams(map[string]interface{}{
+		"retries":     2,
+		"retry_delay": time.Millisecond,
+	})
+
+	events := make(chan Event, 20)
+	pipeline.Subscribe(ChannelSubscriber(events))
+	pipeline.Run(NewSharedState())
+	close(events)
+
+	expected := []EventType{
+		EventNodeStarted,
+		EventExecAttempt,
+		EventRetryScheduled,
+		EventExecAttempt,
+		EventActionChosen,
+	}
+	got := make([]Event, 0)
+	for e := range events {
+		got = append(got, e)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %v", len(expected), len(got), got)
+	}
+	for i, e := range got {
+		if e.Type != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, expected[i], e.Type)
+		}
+		if e.Flow != "journal" || e.Node != "call" {
+			t.Errorf("Event %d: unexpected identity %s/%s", i, e.Flow, e.Node)
+		}
+	}
+	if got[1].Err == nil || got[1].Attempt != 1 {
+		t.Errorf("Expected first attempt to fail, got %+v", got[1])
+	}
+	if got[2].Delay <= 0 {
+		t.Errorf("Expected positive retry delay, got %v", got[2].Delay)
+	}
+	if got[3].Result != "ok" || got[3].Attempt != 2 {
+		t.Errorf("Expected second attempt to succeed, got %+v", got[3])
+	}
+	if got[4].Action != "ok" {
+		t.Errorf("Expected action 'ok', got '%s'", got[4].Action)
+	}
+}
+
+// TestBatchItemEvents tests that every batch item reports completion
+func TestBatchItemEvents(t *testing.T) {
+	node := NewNode()
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return item.(int) * 10, nil
+	})
+
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{
+		"data":     []int{1, 2, 3},
+		"batch":    true,
+		"parallel": true,
+	})
+
+	var mu sync.Mutex
+	done := make(map[int]interface{})
+	pipeline.Subscribe(func(e Event) {
+		if e.Type == EventBatchItemDone {
+			mu.Lock()
+			done[e.Index] = e.Result
+			mu.Unlock()
+		}
+	})
+	pipeline.Run(NewSharedState())
+
+	if len(done) != 3 || done[0] != 10 || done[2] != 30 {
+		t.Errorf("Unexpected batch item events: %v", done)
+	}
+}
@@ -35,13 +35,13 @@ func initializeConversation() []openai.ChatCompletionMessageParamUnion {
 }
 
 // createChatNode creates and configures the chat node with execution logic
-func createChatNode(client openai.Client, state *flow.SharedState) *flow.Node {
+func createChatNode(client openai.Client) *flow.Node {
 	chatNode := flow.NewNode()
 	chatNode.SetParams(map[string]interface{}{
 		"retries": 2,
 	})
 
-	chatNode.SetExecFunc(func(prep interface{}) (interface{}, error) {
+	chatNode.SetExecFuncWithState(func(state *flow.SharedState, prep interface{}) (interface{}, error) {
 		userInput := prep.(string)
 
 		// Get conversation history from state (client is passed as parameter)
@@ -91,7 +91,7 @@ func main() {
 	state.Set("conversation_history", initializeConversation())
 
 	// Create chatbot node with retry capability
-	chatNode := createChatNode(client, state)
+	chatNode := createChatNode(client)
 
 	scanner := bufio.NewScanner(os.Stdin)
 
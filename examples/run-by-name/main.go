@@ -0,0 +1,65 @@
+// Command run-by-name runs a library-defined flow chosen by name, reading the
+// initial state as a JSON document from stdin and printing the terminal action
+// and final state as JSON.
+//
+//	echo '{"input": 21}' | go run ./examples/run-by-name -flow double
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	flow "github.com/joemocha/flow"
+)
+
+// newDoubleFlow creates a flow that doubles the "input" value
+func newDoubleFlow() *flow.Flow {
+	double := flow.NewNode()
+	double.SetName("double")
+	double.SetPostFunc(func(shared *flow.SharedState, prep, exec interface{}) string {
+		shared.Set("result", shared.GetInt("input")*2)
+		return "doubled"
+	})
+
+	f := flow.NewFlow().Start(double)
+	f.SetName("double")
+	return f
+}
+
+// newGreetFlow creates a flow that greets the "name" value
+func newGreetFlow() *flow.Flow {
+	greet := flow.NewNode()
+	greet.SetName("greet")
+	greet.SetPostFunc(func(shared *flow.SharedState, prep, exec interface{}) string {
+		name, _ := shared.Get("name").(string)
+		if name == "" {
+			name = "World"
+		}
+		shared.Set("greeting", fmt.Sprintf("Hello, %s!", name))
+		return "greeted"
+	})
+
+	f := flow.NewFlow().Start(greet)
+	f.SetName("greet")
+	return f
+}
+
+func main() {
+	name := flag.String("flow", "", "name of the flow to run (double, greet)")
+	flag.Parse()
+
+	runner := flow.NewRunner()
+	runner.Register(newDoubleFlow())
+	runner.Register(newGreetFlow())
+
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "usage: run-by-name -flow NAME < state.json")
+		os.Exit(2)
+	}
+
+	if err := runner.RunDocument(*name, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 "fmt"
+"math"
 
 flow "github.com/joemocha/flow"
 )
@@ -28,31 +29,10 @@ return processed
 
 // NewValidatorNode creates a node that validates processed data
 func NewValidatorNode() *flow.Node {
-	node := flow.NewNode()
-	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
-return "validation_complete", nil
-})
-	
-	node.SetPrepFunc(func(shared *flow.SharedState) interface{} {
-value := shared.GetInt("processed_value")
-return value
-})
-	
-	node.SetPostFunc(func(shared *flow.SharedState, prepResult interface{}, execResult interface{}) string {
-value := prepResult.(int)
-
-if value > 10 {
-			shared.Set("validation_result", "valid")
-			fmt.Printf("Validation: %d is valid (> 10)\n", value)
-			return "valid"
-		} else {
-			shared.Set("validation_result", "invalid")
-			fmt.Printf("Validation: %d is invalid (<= 10)\n", value)
-			return "invalid"
-		}
-	})
-	
-	return node
+	return flow.NewValidationNode("processed_value",
+		flow.Required(""),
+		flow.Range("", 11, math.MaxInt64),
+	)
 }
 
 // NewOutputNode creates a node that outputs final results
@@ -63,15 +43,21 @@ return "output_complete", nil
 })
 	
 	node.SetPrepFunc(func(shared *flow.SharedState) interface{} {
-validationResult := shared.Get("validation_result")
+violations := shared.Get(flow.ViolationsKey).([]flow.Violation)
 processedValue := shared.GetInt("processed_value")
 
+validationResult := "valid"
+if len(violations) > 0 {
+validationResult = "invalid"
+}
+shared.Set("validation_result", validationResult)
+
 if validationResult == "valid" {
 result := fmt.Sprintf("SUCCESS: Processed value %d is valid", processedValue)
 shared.Set("final_result", result)
 fmt.Println(result)
 } else {
-result := fmt.Sprintf("REJECTED: Processed value %d is invalid", processedValue)
+result := fmt.Sprintf("REJECTED: Processed value %d is invalid (%v)", processedValue, violations[0])
 shared.Set("final_result", result)
 fmt.Println(result)
 }
@@ -94,8 +80,8 @@ func main() {
 
 	// Chain nodes with conditional branching
 	processor.Next(validator, "processed")
-	validator.Next(validOutput, "valid")
-	validator.Next(invalidOutput, "invalid")
+	validator.Next(validOutput, flow.ValidAction)
+	validator.Next(invalidOutput, flow.InvalidAction)
 
 	// Create and run flow
 	workflow := flow.NewFlow().Start(processor)
@@ -0,0 +1,92 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ModelUsageKey is the SharedState key under which nodes with a SetModelExecFunc
+// function store the ModelUsage of their latest call. Named nodes use
+// ModelUsageKey + ":" + name, as with RetryTelemetryKey.
+const ModelUsageKey = "model_usage"
+
+// ModelUsage records which model of a node's "models" preference list served a
+// call, and the models that failed before it
+type ModelUsage struct {
+	// Model is the model that served the response, or "" if every model failed
+	Model string
+	// Latency is the duration of the call to Model
+	Latency time.Duration
+	// Failures lists the models tried before Model, in order
+	Failures []ModelFailure
+}
+
+// ModelFailure is one model of a preference list that failed a call
+type ModelFailure struct {
+	Model   string
+	Latency time.Duration
+	Err     error
+}
+
+// modelUsageKey returns the state key holding model usage for the named node
+func modelUsageKey(name string) string {
+	if name == "" {
+		return ModelUsageKey
+	}
+	return ModelUsageKey + ":" + name
+}
+
+// SetModelExecFunc sets a business logic function that calls a model by name,
+// failing over along the ordered "models" param (or the single "model" param)
+// for OpenRouter-style setups with several providers. Each exec attempt tries the
+// models in order until one succeeds: retryable errors such as provider outages
+// and rate limiting (see IsRetryable) fall through to the next model, while
+// Permanent errors fail the attempt at once. If every model fails, the attempt
+// fails with all of their errors joined, and the "retries" param starts the list
+// over. The ModelUsage of each call is stored under ModelUsageKey. It replaces
+// any previously set exec function.
+//
+// Example:
+//
+//	node.SetModelExecFunc(func(model string, prompt interface{}) (interface{}, error) {
+//		return openrouter.Complete(model, prompt.(string))
+//	})
+//	node.SetParams(map[string]interface{}{
+//		"models": []string{"anthropic/claude-3.5-sonnet", "openai/gpt-4o", "meta-llama/llama-3-70b"},
+//	})
+func (n *Node) SetModelExecFunc(fn func(model string, input interface{}) (interface{}, error)) {
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(ctx context.Context, shared *SharedState, input interface{}, _ ItemMeta) (interface{}, error) {
+		models := n.runParams(ctx).Strings("models")
+		if len(models) == 0 {
+			models = []string{n.getStringParam(ctx, "model")}
+		}
+
+		var usage ModelUsage
+		defer func() { shared.Set(modelUsageKey(n.name), usage) }()
+		var errs []error
+		for _, model := range models {
+			start := time.Now()
+			result, err := fn(model, input)
+			latency := time.Since(start)
+			if err == nil {
+				usage.Model, usage.Latency = model, latency
+				return result, nil
+			}
+			usage.Failures = append(usage.Failures, ModelFailure{Model: model, Latency: latency, Err: err})
+			if !IsRetryable(err) {
+				return nil, err
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", model, err))
+		}
+		if len(errs) == 1 {
+			return nil, usage.Failures[0].Err
+		}
+		return nil, fmt.Errorf("flow: all %d models failed: %w", len(errs), errors.Join(errs...))
+	}
+}
@@ -0,0 +1,69 @@
+package Flow
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestModelFailover tests falling through the preference list and recording the serving model
+func TestModelFailover(t *testing.T) {
+	var tried []string
+	node := NewNode()
+	node.SetName("chat")
+	node.SetModelExecFunc(func(model string, input interface{}) (interface{}, error) {
+		tried = append(tried, model)
+		if model == "primary" {
+			return nil, errors.New("429 too many requests")
+		}
+		return model + ": hi", nil
+	})
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("reply", exec)
+		return "default"
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"models": []interface{}{"primary", "secondary", "tertiary"}})
+
+	state := NewSharedState()
+	pipeline.Run(state)
+	if state.Get("reply") != "secondary: hi" || len(tried) != 2 {
+		t.Fatalf("Expected the secondary model to serve after one failure, got %v after %v", state.Get("reply"), tried)
+	}
+	usage := state.Get(ModelUsageKey + ":chat").(ModelUsage)
+	if usage.Model != "secondary" || len(usage.Failures) != 1 || usage.Failures[0].Model != "primary" {
+		t.Errorf("Unexpected model usage: %+v", usage)
+	}
+}
+
+// TestModelFailoverPermanent tests that permanent errors don't fall through, and that exhausting the list fails
+func TestModelFailoverPermanent(t *testing.T) {
+	var tried []string
+	node := NewNode()
+	node.SetModelExecFunc(func(model string, input interface{}) (interface{}, error) {
+		tried = append(tried, model)
+		return nil, Permanent(errors.New("400 invalid request"))
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"models": []string{"a", "b"}})
+	func() {
+		defer func() { recover() }()
+		pipeline.Run(NewSharedState())
+	}()
+	if len(tried) != 1 {
+		t.Errorf("Expected a permanent error to stop at the first model, tried %v", tried)
+	}
+
+	outage := errors.New("503 unavailable")
+	node.SetModelExecFunc(func(model string, input interface{}) (interface{}, error) {
+		return nil, outage
+	})
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, outage) {
+				t.Errorf("Expected the joined model errors, got %v", err)
+			}
+		}()
+		pipeline.Run(NewSharedState())
+	}()
+}
@@ -0,0 +1,49 @@
+package Flow
+
+import "errors"
+
+// SetFallbacks registers nodes whose exec functions are tried in order when the
+// node's own exec fails, e.g. a secondary vendor and then cached data. Each
+// fallback is attempted under its own retry parameters ("retries", "retry_delay",
+// ...) and its own limiter, breaker and hedging parameters; its prep and post
+// functions are not used. If every exec function fails, the error joins the
+// final error of each, the primary's first. Fallbacks are not tried once ctx is
+// done, and their results are not cached.
+//
+// Example:
+//
+//	secondary := flow.NewNode()
+//	secondary.SetParams(map[string]interface{}{"retries": 2})
+//	secondary.SetExecFunc(callSecondaryVendor)
+//
+//	stale := flow.NewNode()
+//	stale.SetExecFunc(readCachedAnswer)
+//
+//	primary.SetFallbacks(secondary, stale)
+func (n *Node) SetFallbacks(fallbacks ...*Node) {
+	n.fallbacks = fallbacks
+}
+
+// fail records the final error of the exec function being attempted and moves on
+// to the next fallback, or completes the call with every failure joined
+func (c *execCall) fail(err error) {
+	c.failures = append(c.failures, err)
+	for len(c.fallbacks) > 0 && c.ctx.Err() == nil {
+		next := c.fallbacks[0]
+		c.fallbacks = c.fallbacks[1:]
+		if next.execFunc == nil {
+			continue
+		}
+		c.exec = next
+		c.usePolicy(next.retryPolicy(c.ctx))
+		return
+	}
+
+	c.done = true
+	c.release()
+	if len(c.failures) == 1 {
+		c.err = err
+		return
+	}
+	c.err = errors.Join(c.failures...)
+}
@@ -0,0 +1,81 @@
+package Flow
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestFallbackChain tests that fallbacks are tried in order under their own retries
+func TestFallbackChain(t *testing.T) {
+	errPrimary := errors.New("primary unavailable")
+	errSecondary := errors.New("secondary unavailable")
+	calls := map[string]int{}
+
+	secondary := NewNode()
+	secondary.SetName("secondary")
+	secondary.SetParams(map[string]interface{}{"retries": 3})
+	secondary.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		calls["secondary"]++
+		return nil, errSecondary
+	})
+	stale := NewNode()
+	stale.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		calls["stale"]++
+		return "stale answer", nil
+	})
+
+	primary := NewNode()
+	primary.SetParams(map[string]interface{}{"retries": 2})
+	primary.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		calls["primary"]++
+		return nil, errPrimary
+	})
+	primary.SetFallbacks(secondary, stale)
+
+	if action := primary.Run(NewSharedState()); action != "stale answer" {
+		t.Errorf("Expected the last fallback's result, got %q", action)
+	}
+	if calls["primary"] != 2 || calls["secondary"] != 3 || calls["stale"] != 1 {
+		t.Errorf("Expected 2, 3 and 1 attempts, got %v", calls)
+	}
+
+	// When every fallback fails the error joins each failure, the primary's first
+	stale.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return nil, Permanent(errors.New("no cached answer"))
+	})
+	defer func() {
+		err, _ := recover().(error)
+		var nodeErr *NodeError
+		if !errors.As(err, &nodeErr) || nodeErr.Err != errPrimary {
+			t.Errorf("Expected the primary failure first, got %v", err)
+		}
+		if !errors.Is(err, errSecondary) || !errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("Expected the fallback failures to be joined, got %v", err)
+		}
+	}()
+	primary.Run(NewSharedState())
+}
+
+// TestFallbackInBatch tests per-item fallbacks in a parallel batch
+func TestFallbackInBatch(t *testing.T) {
+	fallback := NewNode()
+	fallback.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return -item.(int), nil
+	})
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"batch": true, "parallel": true, "data": []int{1, 2, 3}})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) == 2 {
+			return nil, errors.New("unavailable")
+		}
+		return item, nil
+	})
+	node.SetFallbacks(fallback)
+
+	state := NewSharedState()
+	node.Run(state)
+	results := state.GetSlice(BatchResultsKey)
+	if len(results) != 3 || results[0] != 1 || results[1] != -2 || results[2] != 3 {
+		t.Errorf("Expected [1 -2 3], got %v", results)
+	}
+}
@@ -28,6 +28,14 @@
 //	result := node.Run(state)
 package Flow
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
 const (
 	// DefaultAction represents the default action when no specific action is provided
 	DefaultAction = "default"
@@ -42,6 +50,15 @@ const (
 type Flow struct {
 	*Node
 	startNode *Node
+	version   string
+	extractor func(context.Context) map[string]interface{}
+
+	debugMu     sync.RWMutex
+	debugger    Debugger
+	breakpoints map[string]bool
+	audit       AuditSink
+	subscribers []func(Event)
+	overhead    overheadStats
 }
 
 // NewFlow creates a new Flow instance.
@@ -71,51 +88,313 @@ func (f *Flow) Start(node *Node) *Flow {
 	return f
 }
 
+// StartAll sets several entry branches that start concurrently on the same shared
+// state, for fan-in initialization such as loading config, warming a cache and
+// fetching the user in parallel. Each branch runs from its node until it reaches a
+// terminal node, and the flow continues only after every branch has finished. It
+// returns the join node, whose successors (and optional prep/exec/post functions)
+// define what happens after the join; it routes with "default" unless told otherwise.
+// If any branch panics, the first panic is re-raised once all branches finish.
+//
+// Example:
+//
+//	pipeline := flow.NewFlow()
+//	pipeline.StartAll(loadConfig, warmCache, fetchUser).Next(handleRequest, "default")
+//	pipeline.Run(state)
+func (f *Flow) StartAll(nodes ...*Node) *Node {
+	join := NewNode()
+	join.SetName("start_all")
+	join.fanIn = &fanIn{flow: f, branches: nodes}
+	f.startNode = join
+	return join
+}
+
+// fanIn holds the concurrent entry branches of a node created by Flow.StartAll
+type fanIn struct {
+	flow     *Flow
+	branches []*Node
+}
+
+// run orchestrates every branch concurrently and waits for all of them.
+// A branch stopped because ctx is done panics with an error wrapping ctx.Err(),
+// like an interrupted batch.
+func (fi *fanIn) run(ctx context.Context, shared *SharedState) {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first interface{}
+
+	for _, branch := range fi.branches {
+		wg.Add(1)
+		go func(start *Node) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() { first = r })
+				}
+			}()
+			if _, _, err := fi.flow.orchestrate(ctx, shared, start, nil); err != nil {
+				panic(fmt.Errorf("flow: start branch interrupted: %w", err))
+			}
+		}(branch)
+	}
+	wg.Wait()
+
+	if first != nil {
+		panic(first)
+	}
+}
+
 // StartNode returns the current starting node of this flow.
 // Returns nil if no starting node has been set.
 func (f *Flow) StartNode() *Node {
 	return f.startNode
 }
 
-// Run executes the flow starting from the start node (like PocketFlow's _orch)
+// SetVersion sets the version of the flow definition. Runs record the version
+// they started on so a Runner can resume checkpointed runs on the same graph
+// after a deploy registers a new version.
+func (f *Flow) SetVersion(version string) {
+	f.version = version
+}
+
+// Version returns the version of the flow definition
+func (f *Flow) Version() string {
+	return f.version
+}
+
+// Run executes the flow starting from the start node (like PocketFlow's _orch).
+// A flow without a start node runs nothing and returns "".
 func (f *Flow) Run(shared *SharedState) string {
-	curr := f.startNode
-	params := f.params
+	action, _, _ := f.orchestrate(context.Background(), shared, f.startNode, nil)
+	return action
+}
+
+// RunContext executes the flow like Run, but stops once ctx is done: no further
+// nodes are started, running batches stop starting new items, and pending retry
+// backoffs are abandoned. It returns
+// the last action and, when stopped early, an error wrapping both
+// ErrRunInterrupted and ctx.Err(), which also matches ErrFlowTimeout when the
+// deadline passed. It returns ErrNoStartNode if no start node has been set.
+// Panics from node execution propagate.
+// The ctx deadline and values selected by SetContextExtractor are copied into
+// shared before the first node runs.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	action, err := pipeline.RunContext(ctx, state)
+//	if errors.Is(err, flow.ErrFlowTimeout) {
+//		// The flow did not finish in time
+//	}
+func (f *Flow) RunContext(ctx context.Context, shared *SharedState) (string, error) {
+	return f.runContext(ctx, shared, nil)
+}
+
+// runContext implements RunContext, calling onNode before each node runs
+func (f *Flow) runContext(ctx context.Context, shared *SharedState, onNode func(*Node, string)) (string, error) {
+	if f.startNode == nil {
+		return "", ErrNoStartNode
+	}
+	f.bridgeContext(ctx, shared)
+	action, _, err := f.runInterruptible(ctx, shared, f.startNode, onNode)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return action, fmt.Errorf("%w: %w: %w", ErrRunInterrupted, ErrFlowTimeout, err)
+	}
+	if err != nil {
+		return action, fmt.Errorf("%w: %w", ErrRunInterrupted, err)
+	}
+	return action, nil
+}
+
+// runInterruptible orchestrates the flow from start and converts a batch stopped by
+// ctx into an ordinary interruption. It returns the last completed action, the
+// node to resume from (nil when the flow finished), and ctx.Err() if it was stopped.
+func (f *Flow) runInterruptible(ctx context.Context, shared *SharedState, start *Node, onNode func(*Node, string)) (action string, next *Node, err error) {
+	var current *Node
+	var lastAction string
+	defer func() {
+		if r := recover(); r != nil {
+			rErr, ok := r.(error)
+			if !ok || ctx.Err() == nil || !errors.Is(rErr, ctx.Err()) {
+				panic(r)
+			}
+			// A batch was stopped mid-way; the interrupted node is the one to resume
+			action, next, err = lastAction, current, ctx.Err()
+		}
+	}()
+
+	return f.orchestrate(ctx, shared, start, func(curr *Node, prevAction string) {
+		current, lastAction = curr, prevAction
+		if onNode != nil {
+			onNode(curr, prevAction)
+		}
+	})
+}
+
+// orchestrate traverses the graph from start until no successor matches or ctx is
+// done. onNode, if non-nil, is called before each node runs with the action
+// returned by the previous node. When ctx is done
+// between nodes, orchestrate returns the last action, the node that would have run
+// next, and ctx.Err(); a node interrupted mid-batch panics instead. A debugger
+// aborting at a breakpoint stops the run the same way with ErrDebugAbort.
+func (f *Flow) orchestrate(ctx context.Context, shared *SharedState, start *Node, onNode func(curr *Node, lastAction string)) (string, *Node, error) {
+	ctx, finish := f.prepareRun(ctx, shared)
+	defer func() {
+		if err := finish(); err != nil {
+			if r := recover(); r != nil {
+				panic(r)
+			}
+			panic(err)
+		}
+	}()
+
+	curr := start
 	var lastAction string
 
 	for curr != nil {
-		// Set params on current node
-		if params != nil {
-			curr.SetParams(params)
+		// Stop before starting another node once ctx is done
+		if err := ctx.Err(); err != nil {
+			return lastAction, curr, err
 		}
 
-		// Execute current node using Run method
-		lastAction = curr.Run(shared)
+		if onNode != nil {
+			onNode(curr, lastAction)
+		}
+
+		switch f.breakAt(curr, shared, lastAction) {
+		case DebugAbort:
+			return lastAction, curr, ErrDebugAbort
+		case DebugSkip:
+			lastAction, curr = DefaultAction, f.getNextNode(shared, curr, DefaultAction)
+			continue
+		}
 
-		// Get next node based on the action
-		curr = f.getNextNode(curr, lastAction)
+		lastAction, curr = f.step(ctx, shared, curr)
 	}
 
-	return lastAction
+	return lastAction, nil, nil
+}
+
+// prepareRun attaches the flow's run-scoped values to ctx. The outermost
+// orchestration of a run also attaches its subscribers, run ID and sink flush set;
+// the returned finish function flushes those sinks and must be called when the
+// run ends. For nested orchestrations of branches and sources it does nothing.
+func (f *Flow) prepareRun(ctx context.Context, shared *SharedState) (context.Context, func() error) {
+	ctx = withFlow(withFlowName(ctx, f.name), f)
+	ctx = withCorrelation(ctx, shared)
+	finish := func() error { return nil }
+	if flushersFrom(ctx) == nil {
+		// Nested orchestrations of branches and sources inherit the subscribers
+		ctx = withSubscribers(ctx, f.subscribers)
+		if runIDFrom(ctx) == "" {
+			ctx = withRunID(ctx, newRunID())
+		}
+
+		// Sinks written during the run are flushed when the outermost orchestration ends
+		flushers := &flushSet{}
+		ctx = context.WithValue(ctx, flushersKey{}, flushers)
+		finish = func() error {
+			if err := flushers.flush(); err != nil {
+				return fmt.Errorf("flow: sink flush failed: %w", err)
+			}
+			return nil
+		}
+	}
+	if f.audit != nil {
+		ctx = withAuditSequence(ctx, f)
+	}
+	shared.runScope().setRunID(runIDFrom(ctx))
+	return ctx, finish
 }
 
-// getNextNode gets the next node based on action (like PocketFlow's get_next_node)
-func (f *Flow) getNextNode(curr *Node, action string) *Node {
+// step runs a single node of a run and returns its action and the node to run
+// next, or nil when the flow is finished
+func (f *Flow) step(ctx context.Context, shared *SharedState, curr *Node) (string, *Node) {
+	measured := f.overhead.enabled.Load()
+	var began, applied, entered time.Time
+	if measured {
+		began = time.Now()
+	}
+	// Set params on current node
+	curr.adoptParams(f.Node)
+	if measured {
+		applied = time.Now()
+	}
+	shared.runScope().enter(f.name, curr.name)
+	if measured {
+		entered = time.Now()
+	}
+
+	// Execute current node using Run method. Audited nodes run on a view of the
+	// state collecting their own writes, apart from those of concurrent branches.
+	view := shared
+	if f.audit != nil {
+		view = shared.recording()
+	}
+	action := curr.run(ctx, view)
+	if f.audit != nil {
+		f.recordAudit(ctx, curr, action, view.recorded())
+	}
+
+	// Get next node based on the action
+	if !measured {
+		return action, f.getNextNode(shared, curr, action)
+	}
+	ran := time.Now()
+	next := f.getNextNode(shared, curr, action)
+	f.overhead.record(applied.Sub(began), entered.Sub(applied), time.Since(ran))
+	return action, next
+}
+
+// getNextNode gets the next node based on action (like PocketFlow's get_next_node),
+// consulting the node's NextFunc before its static successors
+func (f *Flow) getNextNode(shared *SharedState, curr *Node, action string) *Node {
 	if action == "" {
 		action = DefaultAction
 	}
 
-	successors := curr.GetSuccessors()
-	if next, exists := successors[action]; exists {
+	if nextFunc := curr.resolver(); nextFunc != nil {
+		if next := nextFunc(shared, action); next != nil {
+			return next
+		}
+	}
+
+	if next, exists := curr.successor(action); exists {
 		return next
 	}
 
-	// Try default if specific action not found
-	if action != DefaultAction {
-		if defaultNext, exists := successors[DefaultAction]; exists {
+	// Try default if specific action not found; suppressed nodes end the flow instead
+	if action != DefaultAction && action != SuppressedAction {
+		if defaultNext, exists := curr.successor(DefaultAction); exists {
 			return defaultNext
 		}
 	}
 
+	if action != SuppressedAction && len(curr.GetSuccessors()) > 0 {
+		curr.warn(shared, WarnMissingTransition, "flow: no successor for action, ending flow",
+			map[string]interface{}{"action": action})
+	}
 	return nil
 }
+
+// nodes returns every node reachable from the start node in breadth-first order.
+// Successors are visited in lexical order of their actions so the result is stable.
+func (f *Flow) nodes() []*Node {
+	if f.startNode == nil {
+		return nil
+	}
+
+	visited := map[*Node]bool{f.startNode: true}
+	order := []*Node{f.startNode}
+	for i := 0; i < len(order); i++ {
+		curr := order[i]
+		for _, next := range curr.children() {
+			if next != nil && !visited[next] {
+				visited[next] = true
+				order = append(order, next)
+			}
+		}
+	}
+	return order
+}
@@ -0,0 +1,196 @@
+package Flow
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Layout of the nodes drawn by GraphView, in pixels
+const (
+	graphNodeWidth  = 150
+	graphNodeHeight = 36
+	graphColumn     = 220
+	graphRow        = 70
+	graphMargin     = 30
+)
+
+// GraphView is a live, auto-refreshing HTML view of a flow's graph for demos and
+// debugging. Register it with Flow.Subscribe(view.Record) and serve it over
+// HTTP: nodes light up while they execute and show their visit and error counts,
+// and each edge shows how often its action was chosen. Nodes are matched to
+// events by name, so nodes sharing a name (or unnamed nodes) share their counts.
+// Only names, actions and counts are shown, never state.
+//
+// Example:
+//
+//	view := flow.NewGraphView(pipeline)
+//	pipeline.Subscribe(view.Record)
+//	go http.ListenAndServe("localhost:8080", view)
+//	pipeline.Run(state)
+type GraphView struct {
+	flow *Flow
+
+	mu      sync.Mutex
+	running map[string]int
+	visits  map[string]int
+	errors  map[string]int
+	chosen  map[string]map[string]int // times each action was chosen, by node
+}
+
+// NewGraphView creates a view of the graph reachable from f's start node
+func NewGraphView(f *Flow) *GraphView {
+	return &GraphView{
+		flow:    f,
+		running: make(map[string]int),
+		visits:  make(map[string]int),
+		errors:  make(map[string]int),
+		chosen:  make(map[string]map[string]int),
+	}
+}
+
+// Record updates the view from an event; it is safe to use as a flow subscriber
+func (v *GraphView) Record(e Event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch e.Type {
+	case EventNodeStarted:
+		v.running[e.Node]++
+		v.visits[e.Node]++
+	case EventExecAttempt:
+		if e.Err != nil {
+			v.errors[e.Node]++
+		}
+	case EventActionChosen:
+		if v.running[e.Node] > 0 {
+			v.running[e.Node]--
+		}
+		if v.chosen[e.Node] == nil {
+			v.chosen[e.Node] = make(map[string]int)
+		}
+		v.chosen[e.Node][e.Action]++
+	}
+}
+
+// graphViewNode is a node as drawn by the view
+type graphViewNode struct {
+	Name                  string
+	X, Y                  int
+	Running               bool
+	Visits, Errors        int
+	TextX, TextY, CountsY int
+}
+
+// graphViewEdge is an edge as drawn by the view
+type graphViewEdge struct {
+	Action                 string
+	X1, Y1, X2, Y2, LX, LY int
+	Count                  int
+}
+
+// ServeHTTP renders the graph as an HTML page that refreshes every second
+func (v *GraphView) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !allowGet(w, req) {
+		return
+	}
+
+	// Nodes are laid out in columns by their distance from the start node
+	layers := make(map[*Node]int)
+	rows := make(map[int]int)
+	positions := make(map[*Node][2]int)
+	var order []*Node
+	if start := v.flow.StartNode(); start != nil {
+		layers[start] = 0
+		queue := []*Node{start}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			order = append(order, n)
+			positions[n] = [2]int{graphMargin + layers[n]*graphColumn, graphMargin + rows[layers[n]]*graphRow}
+			rows[layers[n]]++
+			for _, next := range n.children() {
+				if _, seen := layers[next]; next != nil && !seen {
+					layers[next] = layers[n] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	v.mu.Lock()
+	var nodes []graphViewNode
+	var edges []graphViewEdge
+	width, height := 0, 0
+	for _, n := range order {
+		pos := positions[n]
+		name := debugNodeName(n.name)
+		nodes = append(nodes, graphViewNode{
+			Name: name, X: pos[0], Y: pos[1],
+			Running: v.running[n.name] > 0, Visits: v.visits[n.name], Errors: v.errors[n.name],
+			TextX: pos[0] + graphNodeWidth/2, TextY: pos[1] + 15, CountsY: pos[1] + 29,
+		})
+		width = max(width, pos[0]+graphNodeWidth+graphMargin)
+		height = max(height, pos[1]+graphNodeHeight+graphMargin)
+		successors := n.GetSuccessors()
+		actions := make([]string, 0, len(successors))
+		for action := range successors {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			to, ok := positions[successors[action]]
+			if !ok {
+				continue
+			}
+			x1, y1 := pos[0]+graphNodeWidth, pos[1]+graphNodeHeight/2
+			x2, y2 := to[0], to[1]+graphNodeHeight/2
+			edges = append(edges, graphViewEdge{
+				Action: action, Count: v.chosen[n.name][action],
+				X1: x1, Y1: y1, X2: x2, Y2: y2, LX: (x1 + x2) / 2, LY: (y1+y2)/2 - 4,
+			})
+		}
+	}
+	v.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = graphViewTemplate.Execute(w, struct {
+		Title         string
+		Width, Height int
+		NodeW, NodeH  int
+		Nodes         []graphViewNode
+		Edges         []graphViewEdge
+	}{debugNodeName(v.flow.name), width, height, graphNodeWidth, graphNodeHeight, nodes, edges})
+}
+
+var graphViewTemplate = template.Must(template.New("graph").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; }
+rect { fill: #eee; stroke: #888; }
+rect.visited { fill: #cfe8cf; }
+rect.running { fill: #ffd54f; stroke: #f57f17; stroke-width: 2; }
+rect.failed { stroke: #c62828; stroke-width: 2; }
+line { stroke: #888; }
+line.taken { stroke: #2e7d32; stroke-width: 2; }
+text { font-size: 12px; text-anchor: middle; }
+text.counts { fill: #555; font-size: 10px; }
+</style>
+</head>
+<body>
+<svg width="{{.Width}}" height="{{.Height}}">
+<defs><marker id="arrow" markerWidth="8" markerHeight="8" refX="8" refY="4" orient="auto"><path d="M0,0 L8,4 L0,8 z" fill="#888"/></marker></defs>
+{{range .Edges}}<line class="{{if .Count}}taken{{end}}" x1="{{.X1}}" y1="{{.Y1}}" x2="{{.X2}}" y2="{{.Y2}}" marker-end="url(#arrow)"/>
+<text x="{{.LX}}" y="{{.LY}}">{{.Action}}{{if .Count}} ×{{.Count}}{{end}}</text>
+{{end}}{{$w := .NodeW}}{{$h := .NodeH}}{{range .Nodes}}<rect class="{{if .Running}}running{{else if .Visits}}visited{{end}}{{if .Errors}} failed{{end}}" x="{{.X}}" y="{{.Y}}" width="{{$w}}" height="{{$h}}" rx="6"/>
+<text x="{{.TextX}}" y="{{.TextY}}">{{.Name}}</text>
+<text class="counts" x="{{.TextX}}" y="{{.CountsY}}">runs {{.Visits}}{{if .Errors}}, errors {{.Errors}}{{end}}</text>
+{{end}}</svg>
+</body>
+</html>
+`))
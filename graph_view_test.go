@@ -0,0 +1,60 @@
+package Flow
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGraphView tests that the page shows visited, running and failing nodes and chosen edges
+func TestGraphView(t *testing.T) {
+	var view *GraphView
+	var during string
+	render := func() string {
+		rec := httptest.NewRecorder()
+		view.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	attempts := 0
+	fetch := NewNode()
+	fetch.SetName("fetch")
+	fetch.SetExecFunc(func(interface{}) (interface{}, error) {
+		if attempts++; attempts == 1 {
+			return nil, errors.New("timeout")
+		}
+		return "ok", nil
+	})
+	store := NewNode()
+	store.SetName("store")
+	store.SetExecFunc(func(interface{}) (interface{}, error) {
+		during = render()
+		return "default", nil
+	})
+	fetch.Next(store, "ok")
+	fetch.Next(NewNode(), "skip")
+
+	pipeline := NewFlow().Start(fetch)
+	pipeline.SetParams(map[string]interface{}{"retries": 2})
+	view = NewGraphView(pipeline)
+	pipeline.Subscribe(view.Record)
+	pipeline.Run(NewSharedState())
+
+	if !strings.Contains(during, `class="running"`) {
+		t.Errorf("Expected the store node to be highlighted while running:\n%s", during)
+	}
+	after := render()
+	for _, want := range []string{">fetch<", ">store<", "ok ×1", ">skip<", "runs 1, errors 1", `class="taken"`} {
+		if !strings.Contains(after, want) {
+			t.Errorf("Expected %q in the page:\n%s", want, after)
+		}
+	}
+	if strings.Contains(after, `class="running"`) {
+		t.Error("Expected no running nodes after the run")
+	}
+}
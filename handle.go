@@ -0,0 +1,78 @@
+package Flow
+
+import "context"
+
+// RunHandle controls a flow run started with RunAsync.
+// It lets callers abort runaway flows programmatically and collect the result
+// once the run finishes.
+type RunHandle struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	progress *progressTracker
+	action   string
+	err      error
+}
+
+// RunAsync starts the flow in a new goroutine and returns a handle to it.
+// The run stops early when ctx is done or Cancel is called, exactly as with
+// RunContext. A panic during the run is recovered and reported as the error
+// from Result.
+//
+// Example:
+//
+//	handle := pipeline.RunAsync(context.Background(), state)
+//	select {
+//	case <-handle.Done():
+//	case <-time.After(time.Minute):
+//		handle.Cancel()
+//	}
+//	action, err := handle.Result()
+func (f *Flow) RunAsync(ctx context.Context, shared *SharedState) *RunHandle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &RunHandle{
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		progress: newProgressTracker(f.startNode),
+	}
+
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		completed := false
+		defer func() {
+			h.progress.finish(completed)
+			if r := recover(); r != nil {
+				h.err = panicToError(r)
+			}
+		}()
+		h.action, h.err = f.runContext(ctx, shared, func(curr *Node, _ string) {
+			h.progress.enter(curr)
+		})
+		completed = h.err == nil
+	}()
+
+	return h
+}
+
+// Cancel stops the run from starting further nodes or batch items.
+// It does not wait for the run to finish; use Done or Result for that.
+func (h *RunHandle) Cancel() {
+	h.cancel()
+}
+
+// Done returns a channel that is closed when the run finishes
+func (h *RunHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Progress returns a snapshot of how far the run has advanced
+func (h *RunHandle) Progress() Progress {
+	return h.progress.snapshot()
+}
+
+// Result waits for the run to finish and returns its last action and error.
+// The error wraps ErrRunInterrupted when the run was cancelled.
+func (h *RunHandle) Result() (string, error) {
+	<-h.done
+	return h.action, h.err
+}
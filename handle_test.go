@@ -0,0 +1,80 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// loopingFlow builds a flow whose single node routes back to itself forever
+func loopingFlow(counter *mockCounter) *Flow {
+	node := NewNode()
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		counter.increment()
+		time.Sleep(time.Millisecond)
+		return "again", nil
+	})
+	node.Next(node, "again")
+	return NewFlow().Start(node)
+}
+
+// TestRunHandleCancel tests that a runaway flow can be cancelled through its handle
+func TestRunHandleCancel(t *testing.T) {
+	counter := &mockCounter{}
+	handle := loopingFlow(counter).RunAsync(context.Background(), NewSharedState())
+
+	time.Sleep(time.Millisecond * 20)
+	handle.Cancel()
+
+	select {
+	case <-handle.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected run to finish after Cancel")
+	}
+
+	action, err := handle.Result()
+	if !errors.Is(err, ErrRunInterrupted) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected interrupted/canceled error, got %v", err)
+	}
+	if action != "again" {
+		t.Errorf("Expected last action 'again', got '%s'", action)
+	}
+	if counter.count == 0 {
+		t.Error("Expected the node to have run before cancellation")
+	}
+}
+
+// TestRunContextDeadline tests that RunContext stops when the deadline passes
+func TestRunContextDeadline(t *testing.T) {
+	counter := &mockCounter{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	_, err := loopingFlow(counter).RunContext(ctx, NewSharedState())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+// TestRunHandleResult tests results and recovered panics from async runs
+func TestRunHandleResult(t *testing.T) {
+	ok := NewNode()
+	ok.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "finished", nil
+	})
+	action, err := NewFlow().Start(ok).RunAsync(context.Background(), NewSharedState()).Result()
+	if action != "finished" || err != nil {
+		t.Errorf("Expected 'finished' with no error, got '%s', %v", action, err)
+	}
+
+	failing := NewNode()
+	failing.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("exec failed")
+	})
+	_, err = NewFlow().Start(failing).RunAsync(context.Background(), NewSharedState()).Result()
+	if nodeErrorCause(err) != "exec failed" {
+		t.Errorf("Expected 'exec failed' error, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package Flow
+
+import (
+	"sort"
+	"time"
+)
+
+// HealthStatus summarizes the health of a Runner
+type HealthStatus string
+
+const (
+	// HealthOK means the runner is accepting runs and nothing needs attention
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded means runs are stuck or a circuit breaker is not closed, but
+	// the runner can still accept work
+	HealthDegraded HealthStatus = "degraded"
+	// HealthUnavailable means the runner is shutting down or a health check failed,
+	// so it should not receive new work
+	HealthUnavailable HealthStatus = "unavailable"
+)
+
+// Health is a structured status report returned by Runner.Health, suitable for
+// readiness probes and dashboards
+type Health struct {
+	// Status is the overall verdict
+	Status HealthStatus `json:"status"`
+	// Checked is when the report was taken
+	Checked time.Time `json:"checked"`
+	// Active is the number of runs in flight
+	Active int `json:"active"`
+	// Stuck lists runs in flight for longer than the expected duration set with SetStuckAfter
+	Stuck []StuckRun `json:"stuck,omitempty"`
+	// Breakers maps every registered circuit breaker to its state
+	Breakers map[string]BreakerState `json:"breakers,omitempty"`
+	// Queues maps every run queue (scheduler name, or "pool") to its depth
+	Queues map[string]int `json:"queues,omitempty"`
+	// Checks maps every health check added with AddHealthCheck to its error
+	// message, or "" if it passed
+	Checks map[string]string `json:"checks,omitempty"`
+	// ShuttingDown reports whether Shutdown has been called
+	ShuttingDown bool `json:"shutting_down,omitempty"`
+}
+
+// StuckRun is a run that has exceeded the runner's expected run duration
+type StuckRun struct {
+	RunInfo
+	// CurrentNode is the name of the node the run is executing
+	CurrentNode string `json:"current_node"`
+	// Elapsed is how long the run has been in flight
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Ready reports whether the runner should receive new work
+func (h Health) Ready() bool {
+	return h.Status != HealthUnavailable
+}
+
+// SetStuckAfter sets how long a run is expected to take at most; Health reports
+// runs in flight for longer as stuck. A duration <= 0 (the default) disables the check.
+func (r *Runner) SetStuckAfter(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stuckAfter = d
+}
+
+// AddHealthCheck registers a named check run by Health, typically the Ping of a
+// persistence backend. A failing check makes the runner unavailable. Adding a
+// check under an existing name replaces it.
+//
+// Example:
+//
+//	cache := flow.NewRedisCache("localhost:6379", "flow:")
+//	runner.AddHealthCheck("redis-cache", cache.Ping)
+func (r *Runner) AddHealthCheck(name string, check func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.checks == nil {
+		r.checks = make(map[string]func() error)
+	}
+	r.checks[name] = check
+}
+
+// Health reports stuck runs, circuit breaker states, run queue depths and the
+// result of every health check. Checks run sequentially on the calling goroutine.
+func (r *Runner) Health() Health {
+	r.mu.Lock()
+	health := Health{
+		Checked:      time.Now(),
+		Active:       len(r.runs),
+		ShuttingDown: r.closing,
+	}
+	stuckAfter := r.stuckAfter
+	runs := make([]*activeRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, run)
+	}
+	checks := make(map[string]func() error, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.Unlock()
+
+	if stuckAfter > 0 {
+		for _, run := range runs {
+			progress := run.progress.snapshot()
+			if progress.Elapsed > stuckAfter {
+				health.Stuck = append(health.Stuck, StuckRun{RunInfo: run.info, CurrentNode: progress.CurrentNode, Elapsed: progress.Elapsed})
+			}
+		}
+		sort.Slice(health.Stuck, func(i, j int) bool {
+			return health.Stuck[i].Started.Before(health.Stuck[j].Started)
+		})
+	}
+
+	degraded := len(health.Stuck) > 0
+	for _, b := range registeredBreakers() {
+		if health.Breakers == nil {
+			health.Breakers = make(map[string]BreakerState)
+		}
+		state := b.State()
+		health.Breakers[b.Name()] = state
+		if state != BreakerClosed {
+			degraded = true
+		}
+	}
+
+	for _, g := range Gauges() {
+		if g.Name != GaugeQueuedRuns {
+			continue
+		}
+		if health.Queues == nil {
+			health.Queues = make(map[string]int)
+		}
+		health.Queues[g.LabelValue] = int(g.Value)
+	}
+
+	failed := false
+	for name, check := range checks {
+		if health.Checks == nil {
+			health.Checks = make(map[string]string)
+		}
+		health.Checks[name] = ""
+		if err := check(); err != nil {
+			health.Checks[name] = err.Error()
+			failed = true
+		}
+	}
+
+	switch {
+	case failed || health.ShuttingDown:
+		health.Status = HealthUnavailable
+	case degraded:
+		health.Status = HealthDegraded
+	default:
+		health.Status = HealthOK
+	}
+	return health
+}
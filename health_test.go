@@ -0,0 +1,98 @@
+package Flow
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunnerHealthStuckRuns tests that runs over the expected duration are reported as stuck
+func TestRunnerHealthStuckRuns(t *testing.T) {
+	runner := NewRunner()
+	runner.SetStuckAfter(time.Millisecond * 20)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	node := NewNode()
+	node.SetName("wedged")
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return "done", nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetName("health-test")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = runner.Run(pipeline, NewSharedState())
+	}()
+	<-started
+
+	if health := runner.Health(); len(health.Stuck) != 0 || health.Active != 1 {
+		t.Errorf("Expected 1 active run and none stuck yet, got %+v", health)
+	}
+
+	time.Sleep(time.Millisecond * 30)
+	health := runner.Health()
+	if len(health.Stuck) != 1 {
+		t.Fatalf("Expected 1 stuck run, got %+v", health.Stuck)
+	}
+	stuck := health.Stuck[0]
+	if stuck.Flow != "health-test" || stuck.CurrentNode != "wedged" || stuck.Elapsed < time.Millisecond*20 {
+		t.Errorf("Unexpected stuck run: %+v", stuck)
+	}
+	if health.Status != HealthDegraded || !health.Ready() {
+		t.Errorf("Expected a degraded but ready runner, got %s", health.Status)
+	}
+
+	close(release)
+	<-done
+	if health := runner.Health(); len(health.Stuck) != 0 || health.Active != 0 {
+		t.Errorf("Expected no runs after completion, got %+v", health)
+	}
+}
+
+// TestRunnerHealthChecks tests breaker states and failing health checks
+func TestRunnerHealthChecks(t *testing.T) {
+	runner := NewRunner()
+	CircuitBreaker("test-health-breaker", 1, time.Minute).record(errors.New("boom"))
+
+	var failing error
+	runner.AddHealthCheck("store", func() error { return failing })
+
+	health := runner.Health()
+	if health.Breakers["test-health-breaker"] != BreakerOpen {
+		t.Errorf("Expected the breaker to be reported open, got %q", health.Breakers["test-health-breaker"])
+	}
+	if msg, ok := health.Checks["store"]; !ok || msg != "" {
+		t.Errorf("Expected a passing store check, got %q (%v)", msg, ok)
+	}
+	if health.Status != HealthDegraded {
+		t.Errorf("Expected degraded status with an open breaker, got %s", health.Status)
+	}
+
+	failing = errors.New("connection refused")
+	health = runner.Health()
+	if health.Checks["store"] != "connection refused" {
+		t.Errorf("Expected the check error, got %q", health.Checks["store"])
+	}
+	if health.Status != HealthUnavailable || health.Ready() {
+		t.Errorf("Expected an unavailable runner, got %s", health.Status)
+	}
+}
+
+// TestRedisPing tests the health checks of the Redis-backed stores
+func TestRedisPing(t *testing.T) {
+	server := startFakeRedis(t)
+	if err := NewRedisCache(server.addr, "p:").Ping(); err != nil {
+		t.Errorf("Unexpected cache ping error: %v", err)
+	}
+	if err := NewRedisDedup(server.addr, "p:", 0).Ping(); err != nil {
+		t.Errorf("Unexpected dedup ping error: %v", err)
+	}
+	if err := NewRedisCache("127.0.0.1:1", "p:").Ping(); err == nil {
+		t.Error("Expected an error pinging an unreachable server")
+	}
+}
@@ -0,0 +1,69 @@
+package Flow
+
+import (
+	"context"
+	"time"
+)
+
+// hedgeOutcome carries the result of one hedged exec call back to the caller
+type hedgeOutcome struct {
+	result    interface{}
+	err       error
+	recovered interface{}
+}
+
+// callHedged runs exec and, if it has not completed within hedgeAfter, launches a
+// second identical call. The first successful result wins; if both calls fail the
+// last error is returned. Each call runs with its own context, which is canceled
+// once callHedged returns, so an exec function set with SetExecFuncWithContext
+// can stop the losing call. Other exec functions run to completion in the
+// background and their result is discarded.
+func (n *Node) callHedged(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta, hedgeAfter time.Duration) (interface{}, error) {
+	// Buffered so an abandoned call never blocks on send
+	outcomes := make(chan hedgeOutcome, 2)
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	launch := func() {
+		ctx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					outcomes <- hedgeOutcome{recovered: r}
+				}
+			}()
+			result, err := n.callGuarded(ctx, shared, input, meta)
+			outcomes <- hedgeOutcome{result: result, err: err}
+		}()
+	}
+
+	launch()
+	pending := 1
+	timer := time.NewTimer(hedgeAfter)
+	defer timer.Stop()
+
+	var last hedgeOutcome
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			launch()
+			pending++
+			continue
+		case last = <-outcomes:
+			pending--
+		}
+
+		if last.recovered != nil {
+			panic(last.recovered)
+		}
+		if last.err == nil {
+			return last.result, nil
+		}
+		// A call that fails before the hedge fires is left to the retry logic
+	}
+	return last.result, last.err
+}
@@ -0,0 +1,91 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestHedgedExecutionCutsTailLatency tests that a slow first call is hedged by a fast second
+func TestHedgedExecutionCutsTailLatency(t *testing.T) {
+	counter := &mockCounter{}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"hedge_after": time.Millisecond * 20,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if counter.increment() == 1 {
+			time.Sleep(time.Millisecond * 200)
+			return "slow", nil
+		}
+		return "fast", nil
+	})
+
+	start := time.Now()
+	result := node.Run(NewSharedState())
+	elapsed := time.Since(start)
+
+	if result != "fast" {
+		t.Errorf("Expected hedged result 'fast', got '%s'", result)
+	}
+	if elapsed > time.Millisecond*150 {
+		t.Errorf("Hedged execution took too long: %v", elapsed)
+	}
+}
+
+// TestHedgeNotLaunchedForFastCalls tests that calls finishing before hedge_after run once
+func TestHedgeNotLaunchedForFastCalls(t *testing.T) {
+	counter := &mockCounter{}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"hedge_after": time.Millisecond * 50,
+		"retries":     2,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if counter.increment() == 1 {
+			return nil, fmt.Errorf("fast failure")
+		}
+		return "ok", nil
+	})
+
+	if result := node.Run(NewSharedState()); result != "ok" {
+		t.Errorf("Expected 'ok', got '%s'", result)
+	}
+	if counter.count != 2 {
+		t.Errorf("Expected 2 calls (one failure, one retry), got %d", counter.count)
+	}
+}
+
+// TestHedgeCancelsLosingCall tests that the slower call's context is canceled once the other wins
+func TestHedgeCancelsLosingCall(t *testing.T) {
+	counter := &mockCounter{}
+	canceled := make(chan error, 1)
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"hedge_after": time.Millisecond * 20,
+	})
+	node.SetExecFuncWithContext(func(ctx context.Context, prep interface{}) (interface{}, error) {
+		if counter.increment() == 1 {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+		return "fast", nil
+	})
+
+	if result := node.Run(NewSharedState()); result != "fast" {
+		t.Errorf("Expected hedged result 'fast', got '%s'", result)
+	}
+	select {
+	case err := <-canceled:
+		if err != context.Canceled {
+			t.Errorf("Expected the losing call to be canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the losing call's context to be canceled")
+	}
+}
@@ -0,0 +1,225 @@
+package Flow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Handler returns an http.Handler exposing the runner's operational endpoints:
+//
+//   - GET /healthz: liveness; 200 while the process is serving requests
+//   - GET /readyz: the Health report as JSON; 503 when the runner is not ready
+//
+// The endpoints are not authorized, so they expose nothing about individual
+// runs; active runs are listed by AdminHandler.
+//
+// Example:
+//
+//	http.Handle("/flow/", http.StripPrefix("/flow", runner.Handler()))
+func (r *Runner) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		if !allowGet(w, req) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		if !allowGet(w, req) {
+			return
+		}
+		health := r.Health()
+		status := http.StatusOK
+		if !health.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, health)
+	})
+	return mux
+}
+
+// AdminHandler returns an http.Handler exposing the runner's admin operations:
+//
+//   - GET /runs: the active runs as a JSON array of ActiveRun, oldest first
+//   - GET /runs/failed: the checkpoints of failed runs, as RunInfo and next node
+//   - POST /runs/{id}/cancel: Cancel the run; 404 if it is not active
+//   - POST /runs/{id}/retry: Retry the run in the background; 202 with the
+//     RunInfo of the new run once it has started, 404 without a checkpoint
+//   - POST /runs/{id}/discard: Discard the run's checkpoint; 404 without one
+//   - GET /node-types: the names registered with RegisterNodeType, as a JSON array
+//
+// Every operation is checked with the runner's Authorizer, passing the request so
+// it can authenticate the caller; listings only include the runs the caller may
+// list. Without an Authorizer every request is denied with 403.
+//
+// Example:
+//
+//	http.Handle("/admin/", http.StripPrefix("/admin", runner.AdminHandler()))
+func (r *Runner) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/runs", func(w http.ResponseWriter, req *http.Request) {
+		if !allowGet(w, req) {
+			return
+		}
+		runs := []ActiveRun{}
+		for _, run := range r.Runs() {
+			if r.authorizeRun(req, AuthList, run.RunInfo) == nil {
+				runs = append(runs, run)
+			}
+		}
+		writeJSON(w, http.StatusOK, runs)
+	})
+	mux.HandleFunc("/runs/", func(w http.ResponseWriter, req *http.Request) {
+		rest := strings.TrimPrefix(req.URL.Path, "/runs/")
+		if rest == "failed" {
+			if !allowGet(w, req) {
+				return
+			}
+			var failed []Checkpoint
+			for _, cp := range r.Failed() {
+				if r.authorizeRun(req, AuthList, cp.RunInfo) == nil {
+					failed = append(failed, cp)
+				}
+			}
+			writeJSON(w, http.StatusOK, failedRuns(failed))
+			return
+		}
+
+		id, op, _ := strings.Cut(rest, "/")
+		if id == "" || (op != "cancel" && op != "retry" && op != "discard") {
+			http.NotFound(w, req)
+			return
+		}
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		info, ok := r.runInfo(id, op != "cancel")
+		if !ok {
+			writeError(w, fmt.Errorf("%w: %s", ErrUnknownRun, id))
+			return
+		}
+		if err := r.authorizeRun(req, op, info); err != nil {
+			writeError(w, err)
+			return
+		}
+		if op == "cancel" {
+			if err := r.Cancel(id); err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"id": id, "status": "cancelling"})
+			return
+		}
+		if op == "discard" {
+			if err := r.Discard(id); err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"id": id, "status": "discarded"})
+			return
+		}
+
+		retried, err := r.retryAsync(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, retried)
+	})
+	mux.HandleFunc("/node-types", func(w http.ResponseWriter, req *http.Request) {
+		if !allowGet(w, req) {
+			return
+		}
+		if err := r.authorize(req.Context(), AuthRequest{Action: AuthNodeTypes, HTTP: req}); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, append([]string{}, NodeTypes()...))
+	})
+	return mux
+}
+
+// authorizeRun checks an admin operation on a run on behalf of req
+func (r *Runner) authorizeRun(req *http.Request, action string, info RunInfo) error {
+	return r.authorize(req.Context(), AuthRequest{Action: action, Flow: info.Flow, Tenant: info.Tenant, RunID: info.ID, HTTP: req})
+}
+
+// runInfo returns the info of the active run id, or of its checkpoint if
+// checkpointed is true
+func (r *Runner) runInfo(id string, checkpointed bool) (RunInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !checkpointed {
+		run, ok := r.runs[id]
+		if !ok {
+			return RunInfo{}, false
+		}
+		return run.info, true
+	}
+	for _, list := range [][]Checkpoint{r.failed, r.interrupted} {
+		for _, cp := range list {
+			if cp.ID == id {
+				return cp.RunInfo, true
+			}
+		}
+	}
+	return RunInfo{}, false
+}
+
+// failedRun is an entry of the /runs/failed endpoint
+type failedRun struct {
+	RunInfo
+	LastAction string `json:"last_action"`
+	NextNode   string `json:"next_node,omitempty"`
+}
+
+// failedRuns converts checkpoints to their JSON form, leaving out the state
+func failedRuns(checkpoints []Checkpoint) []failedRun {
+	runs := make([]failedRun, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		run := failedRun{RunInfo: cp.RunInfo, LastAction: cp.LastAction}
+		if cp.NextNode != nil {
+			run.NextNode = cp.NextNode.Name()
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// writeError maps a runner error to an HTTP error response
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrUnknownRun):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrRunnerShutdown):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, ErrUnknownFlowVersion), errors.Is(err, ErrUnknownCheckpointNode):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// allowGet rejects requests other than GET and HEAD with 405
+func allowGet(w http.ResponseWriter, req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return false
+}
+
+// writeJSON writes value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,154 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRunnerHandler tests the liveness and readiness endpoints
+func TestRunnerHandler(t *testing.T) {
+	runner := NewRunner()
+	server := httptest.NewServer(runner.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected /healthz to return 200, got %d", resp.StatusCode)
+	}
+
+	// Active runs are only listed by the authorized admin API
+	resp, err = http.Get(server.URL + "/runs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected /runs not to be served, got %d", resp.StatusCode)
+	}
+
+	runner.AddHealthCheck("store", func() error { return errors.New("down") })
+	resp, err = http.Get(server.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || health.Status != HealthUnavailable || health.Checks["store"] != "down" {
+		t.Errorf("Expected an unavailable readiness report, got %d %+v", resp.StatusCode, health)
+	}
+
+	resp, err = http.Post(server.URL+"/healthz", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST /healthz, got %d", resp.StatusCode)
+	}
+}
+
+// TestRunnerAdminHandler tests cancelling and retrying runs over HTTP
+func TestRunnerAdminHandler(t *testing.T) {
+	runner := NewRunner()
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	first := NewNode()
+	first.SetName("first")
+	first.SetExecFunc(func(interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return "next", nil
+	})
+	finished := make(chan struct{})
+	second := NewNode()
+	second.SetName("second")
+	second.SetExecFunc(func(interface{}) (interface{}, error) {
+		close(finished)
+		return "done", nil
+	})
+	first.Next(second, "next")
+	pipeline := NewFlow().Start(first)
+	pipeline.SetName("admin-test")
+	runner.Register(pipeline)
+	runner.SetAuthorizer(AuthorizerFunc(func(context.Context, AuthRequest) error { return nil }))
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := runner.Run(pipeline, NewSharedState())
+		errs <- err
+	}()
+	<-started
+
+	server := httptest.NewServer(runner.AdminHandler())
+	defer server.Close()
+	id := runner.Active()[0].ID
+
+	resp, err := http.Post(server.URL+"/runs/"+id+"/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 cancelling an active run, got %d", resp.StatusCode)
+	}
+	release <- struct{}{}
+	if err := <-errs; !errors.Is(err, ErrRunInterrupted) {
+		t.Fatalf("Expected ErrRunInterrupted, got %v", err)
+	}
+
+	resp, err = http.Post(server.URL+"/runs/"+id+"/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 cancelling a finished run, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(server.URL+"/runs/"+id+"/retry", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var info RunInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || info.ID == "" || info.ID == id || info.Flow != "admin-test" {
+		t.Errorf("Expected 202 with the new run, got %d %+v", resp.StatusCode, info)
+	}
+	<-finished
+
+	resp, err = http.Get(server.URL + "/runs/failed")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var failed []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&failed); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	resp.Body.Close()
+	if len(failed) != 0 {
+		t.Errorf("Expected no failed runs, got %v", failed)
+	}
+
+	resp, err = http.Post(server.URL+"/runs/"+id+"/discard", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 discarding a retried run, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,58 @@
+package Flow
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// statePlaceholder matches "${state.key}"
+var statePlaceholder = regexp.MustCompile(`\$\{state\.([^}]+)\}`)
+
+// Interpolate replaces every "${state.key}" placeholder in text with the current
+// value of key, so URL, prompt and path templates can reference data produced by
+// earlier nodes. Placeholders for missing keys are left unchanged.
+//
+// Param values are interpolated only in the Params passed to a
+// SetExecFuncWithParams function. GetParam and the built-in params, such as
+// "stream_key" or "limiter", see the template text.
+//
+// Example:
+//
+//	state.Set("user_id", 42)
+//	state.Interpolate("https://api.example.com/users/${state.user_id}") // ".../users/42"
+func (s *SharedState) Interpolate(text string) string {
+	if !strings.Contains(text, "${state.") {
+		return text
+	}
+	return statePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		value := s.Get(statePlaceholder.FindStringSubmatch(placeholder)[1])
+		if value == nil {
+			return placeholder
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// withState returns params with "${state.key}" placeholders in string values
+// interpolated from shared
+func (p Params) withState(shared *SharedState) Params {
+	var resolved Params
+	for key, value := range p {
+		text, ok := value.(string)
+		if !ok || !statePlaceholder.MatchString(text) {
+			continue
+		}
+		if resolved == nil {
+			resolved = make(Params, len(p))
+			for k, v := range p {
+				resolved[k] = v
+			}
+		}
+		resolved[key] = shared.Interpolate(text)
+	}
+	if resolved == nil {
+		return p
+	}
+	return resolved
+}
@@ -0,0 +1,58 @@
+package Flow
+
+import "testing"
+
+// TestStateInterpolationInParams tests that string params reference state written by earlier nodes
+func TestStateInterpolationInParams(t *testing.T) {
+	login := NewNode()
+	login.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("user_id", 42)
+		shared.Set("lang", "fr")
+		return DefaultAction
+	})
+
+	var url, prompt string
+	fetch := NewNode()
+	fetch.SetExecFuncWithParams(func(params Params, prep interface{}) (interface{}, error) {
+		url, prompt = params.String("url"), params.String("prompt")
+		return nil, nil
+	})
+	login.Next(fetch, DefaultAction)
+
+	pipeline := NewFlow().Start(login)
+	pipeline.SetParams(map[string]interface{}{
+		"url":    "https://api.example.com/users/${state.user_id}",
+		"prompt": "Answer in ${state.lang} for ${state.missing}",
+	})
+	pipeline.Run(NewSharedState())
+
+	if url != "https://api.example.com/users/42" {
+		t.Errorf("Unexpected url: %s", url)
+	}
+	if prompt != "Answer in fr for ${state.missing}" {
+		t.Errorf("Expected missing keys to stay as placeholders, got %s", prompt)
+	}
+	if fetch.GetParam("url") != "https://api.example.com/users/${state.user_id}" {
+		t.Errorf("Expected node params to keep the template, got %v", fetch.GetParam("url"))
+	}
+}
+
+// TestStateInterpolationOnlyInExecParams pins that built-in params are not
+// interpolated, unlike the Params of a SetExecFuncWithParams function
+func TestStateInterpolationOnlyInExecParams(t *testing.T) {
+	node := NewNode()
+	node.SetStreamExecFunc(func(input interface{}, emit func(interface{})) (interface{}, error) {
+		emit("chunk")
+		return nil, nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"stream_key": "${state.target}"})
+
+	state := NewSharedState()
+	state.Set("target", "out")
+	pipeline.Run(state)
+
+	if state.Get("out") != nil || len(state.GetSlice("${state.target}")) != 1 {
+		t.Errorf("Expected the chunk under the literal key, got %v", state.Snapshot())
+	}
+}
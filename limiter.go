@@ -0,0 +1,187 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ConcurrencyLimiter bounds the number of concurrent executions across every node
+// that references it. Unlike "parallel_limit", which only applies within a single
+// node, a ConcurrencyLimiter is shared process-wide by name, so several parallel
+// nodes hitting the same backend collectively respect one limit.
+//
+// Limiters are created and registered with Limiter() and referenced from nodes
+// through the "limiter" parameter.
+//
+// Example:
+//
+//	flow.Limiter("db", 10)
+//
+//	node.SetParams(map[string]interface{}{
+//		"data":     rows,
+//		"batch":    true,
+//		"parallel": true,
+//		"limiter":  "db", // At most 10 concurrent calls across all "db" nodes
+//	})
+type ConcurrencyLimiter struct {
+	name string
+	sem  chan struct{}
+}
+
+var limiterRegistry = struct {
+	mu       sync.RWMutex
+	limiters map[string]*ConcurrencyLimiter
+}{
+	limiters: make(map[string]*ConcurrencyLimiter),
+}
+
+// Limiter registers a named concurrency limiter allowing at most limit concurrent
+// executions and returns it. If a limiter with the same name is already registered,
+// the existing limiter is returned unchanged so that every node referencing the
+// name shares the same slots. A limit <= 0 is treated as 1. It panics if the name
+// is already registered with RateLimiter().
+//
+// Parameters:
+//   - name: The name nodes use to reference the limiter via the "limiter" param
+//   - limit: The maximum number of concurrent executions
+//
+// Returns:
+//   - *ConcurrencyLimiter: The registered limiter
+func Limiter(name string, limit int) *ConcurrencyLimiter {
+	if lookupRateLimiter(name) != nil {
+		panic(fmt.Errorf("flow: limiter %q is already registered as a rate limiter", name))
+	}
+
+	limiterRegistry.mu.Lock()
+	defer limiterRegistry.mu.Unlock()
+
+	if existing, ok := limiterRegistry.limiters[name]; ok {
+		return existing
+	}
+
+	if limit <= 0 {
+		limit = 1
+	}
+	l := &ConcurrencyLimiter{
+		name: name,
+		sem:  make(chan struct{}, limit),
+	}
+	limiterRegistry.limiters[name] = l
+	return l
+}
+
+// Semaphores registers a named concurrency limiter for every entry of limits, so
+// the shared resources of a service can be declared in one place. Nodes hold a
+// slot of one of them through the "limiter" param, or of several at once through
+// the "semaphores" param, which acquires them in name order to avoid deadlocks.
+// Existing limiters are kept unchanged, as with Limiter().
+//
+// Example:
+//
+//	flow.Semaphores(map[string]int{"db_conns": 20, "gpu": 2})
+//
+//	node.SetParams(map[string]interface{}{
+//		"semaphores": []string{"db_conns", "gpu"}, // Hold one slot of each per call
+//	})
+func Semaphores(limits map[string]int) map[string]*ConcurrencyLimiter {
+	registered := make(map[string]*ConcurrencyLimiter, len(limits))
+	for name, limit := range limits {
+		registered[name] = Limiter(name, limit)
+	}
+	return registered
+}
+
+// lookupLimiter returns the registered limiter for name, or nil if none exists
+func lookupLimiter(name string) *ConcurrencyLimiter {
+	limiterRegistry.mu.RLock()
+	defer limiterRegistry.mu.RUnlock()
+	return limiterRegistry.limiters[name]
+}
+
+// Name returns the name the limiter was registered under
+func (l *ConcurrencyLimiter) Name() string {
+	return l.name
+}
+
+// Limit returns the maximum number of concurrent executions
+func (l *ConcurrencyLimiter) Limit() int {
+	return cap(l.sem)
+}
+
+// InFlight returns the number of executions currently holding a slot
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.sem)
+}
+
+// Acquire blocks until a slot is available
+func (l *ConcurrencyLimiter) Acquire() {
+	l.sem <- struct{}{}
+}
+
+// AcquireContext blocks until a slot is available or ctx is done, returning
+// ctx.Err() in the latter case
+func (l *ConcurrencyLimiter) AcquireContext(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot previously obtained with Acquire
+func (l *ConcurrencyLimiter) Release() {
+	<-l.sem
+}
+
+// semaphores returns the limiters a call of the node holds: those named by the
+// "semaphores" param and the "limiter" param when it names a ConcurrencyLimiter.
+// They are sorted by name and deduplicated, so that calls holding several never
+// acquire them in conflicting orders, or one of them twice.
+func (n *Node) semaphores(ctx context.Context) []*ConcurrencyLimiter {
+	names := n.runParams(ctx).Strings("semaphores")
+	if name := n.getStringParam(ctx, "limiter"); name != "" && lookupRateLimiter(name) == nil {
+		if lookupLimiter(name) == nil {
+			panic(fmt.Errorf("flow: limiter %q is not registered", name))
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	limiters := make([]*ConcurrencyLimiter, 0, len(names))
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+		limiter := lookupLimiter(name)
+		if limiter == nil {
+			panic(fmt.Errorf("flow: semaphore %q is not registered", name))
+		}
+		limiters = append(limiters, limiter)
+	}
+	return limiters
+}
+
+// acquireAll acquires a slot of every limiter in order, each recording its wait.
+// If ctx is done first, the slots already taken are released and ctx.Err() is
+// returned; otherwise the returned func releases every slot.
+func acquireAll(ctx context.Context, limiters []*ConcurrencyLimiter) (func(), error) {
+	for i, limiter := range limiters {
+		var err error
+		timeWait(limiter.Name(), func() { err = limiter.AcquireContext(ctx) })
+		if err != nil {
+			releaseAll(limiters[:i])
+			return nil, err
+		}
+	}
+	return func() { releaseAll(limiters) }, nil
+}
+
+// releaseAll releases a slot of every limiter
+func releaseAll(limiters []*ConcurrencyLimiter) {
+	for _, limiter := range limiters {
+		limiter.Release()
+	}
+}
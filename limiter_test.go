@@ -0,0 +1,207 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSharedLimiterAcrossNodes tests that a named limiter bounds concurrency across nodes
+func TestSharedLimiterAcrossNodes(t *testing.T) {
+	Limiter("test-shared-db", 2)
+
+	var inFlight, peak int64
+	exec := func(item interface{}) (interface{}, error) {
+		current := atomic.AddInt64(&inFlight, 1)
+		for {
+			old := atomic.LoadInt64(&peak)
+			if current <= old || atomic.CompareAndSwapInt64(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 20)
+		atomic.AddInt64(&inFlight, -1)
+		return item, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		node := NewNode()
+		node.SetParams(map[string]interface{}{
+			"data":           []int{1, 2, 3, 4},
+			"batch":          true,
+			"parallel":       true,
+			"parallel_limit": 4,
+			"limiter":        "test-shared-db",
+		})
+		node.SetExecFunc(exec)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.Run(NewSharedState())
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent executions, got %d", peak)
+	}
+}
+
+// TestLimiterRegistry tests limiter registration semantics
+func TestLimiterRegistry(t *testing.T) {
+	first := Limiter("test-registry", 3)
+	second := Limiter("test-registry", 7)
+
+	if first != second {
+		t.Error("Expected re-registration to return the existing limiter")
+	}
+	if second.Limit() != 3 {
+		t.Errorf("Expected limit 3, got %d", second.Limit())
+	}
+	if second.InFlight() != 0 {
+		t.Errorf("Expected 0 in flight, got %d", second.InFlight())
+	}
+}
+
+// TestUnknownLimiterPanics tests that referencing an unregistered limiter panics
+func TestUnknownLimiterPanics(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"limiter": "test-does-not-exist",
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "unreachable", nil
+	})
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic for unknown limiter")
+		}
+	}()
+
+	node.Run(NewSharedState())
+}
+
+// TestSemaphoresHeldTogether tests that the semaphores param bounds every named resource
+func TestSemaphoresHeldTogether(t *testing.T) {
+	semaphores := Semaphores(map[string]int{"test-sem-db": 2, "test-sem-gpu": 1})
+
+	var inFlight, peak int64
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":       []int{1, 2, 3, 4},
+		"batch":      true,
+		"parallel":   true,
+		"semaphores": []string{"test-sem-gpu", "test-sem-db"},
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if current := atomic.AddInt64(&inFlight, 1); current > atomic.LoadInt64(&peak) {
+			atomic.StoreInt64(&peak, current)
+		}
+		if semaphores["test-sem-gpu"].InFlight() != 1 || semaphores["test-sem-db"].InFlight() == 0 {
+			t.Error("Expected both semaphores to be held during exec")
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt64(&inFlight, -1)
+		return item, nil
+	})
+	node.Run(NewSharedState())
+
+	// The single gpu slot serializes calls
+	if peak != 1 {
+		t.Errorf("Expected 1 concurrent execution, got %d", peak)
+	}
+	if semaphores["test-sem-db"].InFlight() != 0 || semaphores["test-sem-gpu"].InFlight() != 0 {
+		t.Error("Expected every slot to be released")
+	}
+}
+
+// TestLimiterAcquireContext tests that waiting for a slot stops when ctx is done
+func TestLimiterAcquireContext(t *testing.T) {
+	limiter := Limiter("test-acquire-context", 1)
+	if err := limiter.AcquireContext(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer limiter.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.AcquireContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+// TestLimiterMergedWithSemaphores tests that a limiter also listed as a semaphore is held once
+func TestLimiterMergedWithSemaphores(t *testing.T) {
+	limiter := Limiter("test-merged", 1)
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"limiter":    "test-merged",
+		"semaphores": []string{"test-merged"},
+	})
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		if limiter.InFlight() != 1 {
+			t.Errorf("Expected one slot held, got %d", limiter.InFlight())
+		}
+		return nil, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		node.Run(NewSharedState())
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the node not to wait on its own slot")
+	}
+}
+
+// TestLimitersReleasedWhenCanceled tests that slots already taken are released
+// when ctx is done while waiting for the next one
+func TestLimitersReleasedWhenCanceled(t *testing.T) {
+	held := Semaphores(map[string]int{"test-cancel-a": 1, "test-cancel-b": 1})
+	held["test-cancel-b"].Acquire()
+	defer held["test-cancel-b"].Release()
+
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		t.Error("Expected exec not to run without every slot")
+		return nil, nil
+	})
+	// Flow params replace the params of the nodes it runs
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{
+		"limiter":    "test-cancel-b",
+		"semaphores": []string{"test-cancel-a"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pipeline.RunContext(ctx, NewSharedState()); err == nil {
+		t.Error("Expected the run to fail once ctx is done")
+	}
+	if held["test-cancel-a"].InFlight() != 0 {
+		t.Error("Expected the slot taken before the wait to be released")
+	}
+}
+
+// TestRateLimiterWaitContext tests that a canceled wait gives its token back
+func TestRateLimiterWaitContext(t *testing.T) {
+	limiter := RateLimiter("test-wait-context", 1, time.Hour)
+	limiter.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if wait := limiter.take(time.Now()); wait < 50*time.Minute {
+		t.Errorf("Expected the canceled wait's token to be returned, next token in %v", wait)
+	}
+}
@@ -0,0 +1,124 @@
+package Flow
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// runScope tracks which run, flow and node a SharedState is currently serving
+type runScope struct {
+	mu    sync.RWMutex
+	runID string
+	flow  string
+	node  string
+}
+
+var runLogHandler atomic.Pointer[slog.Handler]
+
+// SetRunLogHandler sets the handler that loggers returned by RunLogger write to.
+// Passing nil restores the default of slog.Default().Handler().
+func SetRunLogHandler(h slog.Handler) {
+	if h == nil {
+		runLogHandler.Store(nil)
+		return
+	}
+	runLogHandler.Store(&h)
+}
+
+// RunLogger returns a logger whose records carry the run ID, flow name, current
+// node name and correlation ID of the run using shared. The fields are read when
+// each record is logged, so a logger obtained once follows the orchestration as
+// it advances. Runs managed by a Runner use the runner's run ID; other runs get
+// a random ID when they start. With concurrent branches, the node is the most
+// recently started one.
+//
+// Example:
+//
+//	node.SetExecFuncWithState(func(shared *flow.SharedState, prep interface{}) (interface{}, error) {
+//		flow.RunLogger(shared).Info("calling upstream", "attempt", 1)
+//		...
+//	})
+func RunLogger(shared *SharedState) *slog.Logger {
+	var h slog.Handler
+	if stored := runLogHandler.Load(); stored != nil {
+		h = *stored
+	} else {
+		h = slog.Default().Handler()
+	}
+	return slog.New(&runHandler{inner: h, shared: shared})
+}
+
+// runHandler adds the run's fields to every record passed to inner
+type runHandler struct {
+	inner  slog.Handler
+	shared *SharedState
+}
+
+func (h *runHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *runHandler) Handle(ctx context.Context, r slog.Record) error {
+	scope := h.shared.runScope()
+	scope.mu.RLock()
+	attrs := []slog.Attr{
+		slog.String("run_id", scope.runID),
+		slog.String("flow", scope.flow),
+		slog.String("node", scope.node),
+	}
+	scope.mu.RUnlock()
+	if id := CorrelationID(h.shared); id != "" {
+		attrs = append(attrs, slog.String("correlation_id", id))
+	}
+
+	r = r.Clone()
+	r.AddAttrs(attrs...)
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *runHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &runHandler{inner: h.inner.WithAttrs(attrs), shared: h.shared}
+}
+
+func (h *runHandler) WithGroup(name string) slog.Handler {
+	return &runHandler{inner: h.inner.WithGroup(name), shared: h.shared}
+}
+
+type runIDKey struct{}
+
+// withRunID records the ID of the run in ctx
+func withRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// runIDFrom returns the run ID carried by ctx, or ""
+func runIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// newRunID returns a random ID for runs not managed by a Runner
+func newRunID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// setRunID records the ID of the run using the state
+func (s *runScope) setRunID(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runID = id
+}
+
+// enter records the flow and node about to run
+func (s *runScope) enter(flow, node string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flow = flow
+	s.node = node
+}
@@ -0,0 +1,64 @@
+package Flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestRunLogger tests that run loggers follow the orchestration as it advances
+func TestRunLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetRunLogHandler(slog.NewJSONHandler(&buf, nil))
+	defer SetRunLogHandler(nil)
+
+	logStep := func(name, action string) *Node {
+		node := NewNode()
+		node.SetName(name)
+		node.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+			RunLogger(shared).Info("working")
+			return action, nil
+		})
+		return node
+	}
+	fetch := logStep("fetch", "next")
+	fetch.Next(logStep("store", "done"), "next")
+
+	pipeline := NewFlow().Start(fetch)
+	pipeline.SetName("ingest")
+
+	runner := NewRunner()
+	state := NewSharedState()
+	SetCorrelationID(state, "corr-1")
+	if _, err := runner.Run(pipeline, state); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	for i, node := range []string{"fetch", "store"} {
+		record := records[i]
+		if record["node"] != node || record["flow"] != "ingest" || record["run_id"] != "run-1" || record["correlation_id"] != "corr-1" {
+			t.Errorf("Unexpected fields in record %d: %v", i, record)
+		}
+	}
+
+	// Runs outside a Runner get a generated run ID
+	buf.Reset()
+	pipeline.Run(NewSharedState())
+	if !strings.Contains(buf.String(), `"run_id":"`) || strings.Contains(buf.String(), `"run_id":""`) {
+		t.Errorf("Expected a generated run ID, got %s", buf.String())
+	}
+}
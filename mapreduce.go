@@ -0,0 +1,94 @@
+package Flow
+
+import "context"
+
+// BatchResultsKey is the SharedState key under which batch executions store their
+// results, unless the "results_key" param names another
+const BatchResultsKey = "batch_results"
+
+// resultsKey returns the state key the node stores (or, for reduce nodes, reads)
+// batch results under
+func (n *Node) resultsKey(ctx context.Context) string {
+	if key := n.getStringParam(ctx, "results_key"); key != "" {
+		return key
+	}
+	return BatchResultsKey
+}
+
+// storeResults stores a batch's results under the node's results key, raising a
+// WarnResultsOverwritten warning when they replace results stored by another node
+// in the same state, which usually means two batch nodes need distinct "results_key"s
+func (n *Node) storeResults(ctx context.Context, shared *SharedState, results []interface{}) {
+	key := n.resultsKey(ctx)
+	if previous := shared.claimResults(key, n); previous != nil && previous != n {
+		n.warn(shared, WarnResultsOverwritten, "flow: batch results overwritten by another node",
+			map[string]interface{}{"key": key, "previous_node": debugNodeName(previous.name)})
+	}
+	shared.Set(key, results)
+}
+
+// NewMapNode creates a node that applies fn to every item stored under key in
+// shared state, storing the results under BatchResultsKey like any batch node.
+// Parallelism, retries and the other batch parameters compose as usual through
+// the node's (or its flow's) params. A missing key maps over no items.
+//
+// Parameters:
+//   - key: The SharedState key holding the items, e.g. a []interface{} written by an earlier node
+//   - fn: The function applied to each item
+//
+// Returns:
+//   - *Node: The map node
+//
+// Example:
+//
+//	fetch := flow.NewMapNode("urls", func(item interface{}) (interface{}, error) {
+//		return download(item.(string))
+//	})
+//	fetch.SetParams(map[string]interface{}{"parallel": true, "retries": 3})
+//	fetch.Next(flow.NewReduceNode("total_bytes", 0, sumBytes), "default")
+func NewMapNode(key string, fn func(item interface{}) (interface{}, error)) *Node {
+	n := NewNode()
+	n.mapKey = key
+	n.SetExecFunc(fn)
+	return n
+}
+
+// NewReduceNode creates a node that folds the results stored under BatchResultsKey
+// (or its "results_key" param) by the preceding map or batch node, starting from
+// initial, and stores the final accumulator under key. The fold runs in the exec phase, so retries apply to it
+// and a returned error panics like any exec failure.
+//
+// Parameters:
+//   - key: The SharedState key to store the reduced value under
+//   - initial: The starting accumulator
+//   - fn: The function combining the accumulator with each result in order
+//
+// Returns:
+//   - *Node: The reduce node
+//
+// Example:
+//
+//	sum := flow.NewReduceNode("total", 0, func(acc, item interface{}) (interface{}, error) {
+//		return acc.(int) + item.(int), nil
+//	})
+func NewReduceNode(key string, initial interface{}, fn func(acc, item interface{}) (interface{}, error)) *Node {
+	n := NewNode()
+	n.prepFunc = func(ctx context.Context, shared *SharedState) interface{} {
+		return shared.GetSlice(n.resultsKey(ctx))
+	}
+	n.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		acc := initial
+		for _, item := range prep.([]interface{}) {
+			var err error
+			if acc, err = fn(acc, item); err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	})
+	n.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set(key, exec)
+		return DefaultAction
+	})
+	return n
+}
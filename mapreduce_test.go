@@ -0,0 +1,110 @@
+package Flow
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestMapReduceFlow tests a parallel map over state items folded by a reduce node
+func TestMapReduceFlow(t *testing.T) {
+	load := NewNode()
+	load.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("words", []string{"map", "reduce", "flow"})
+		return DefaultAction
+	})
+
+	lengths := NewMapNode("words", func(item interface{}) (interface{}, error) {
+		return len(item.(string)), nil
+	})
+	total := NewReduceNode("total", 0, func(acc, item interface{}) (interface{}, error) {
+		return acc.(int) + item.(int), nil
+	})
+	load.Next(lengths, DefaultAction)
+	lengths.Next(total, DefaultAction)
+
+	pipeline := NewFlow().Start(load)
+	// Flow params are applied to every node it runs
+	pipeline.SetParams(map[string]interface{}{"parallel": true, "retries": 2})
+
+	state := NewSharedState()
+	pipeline.Run(state)
+
+	if got := state.GetInt("total"); got != 13 {
+		t.Errorf("Expected total 13, got %d", got)
+	}
+}
+
+// TestMapReduceEdgeCases tests mapping over a missing key and a failing reduce
+func TestMapReduceEdgeCases(t *testing.T) {
+	state := NewSharedState()
+
+	NewMapNode("missing", func(item interface{}) (interface{}, error) {
+		t.Error("Expected no items to be mapped")
+		return nil, nil
+	}).Run(state)
+	if results := state.GetSlice(BatchResultsKey); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+
+	state.Set(BatchResultsKey, []interface{}{1, "two"})
+	sum := NewReduceNode("sum", 0, func(acc, item interface{}) (interface{}, error) {
+		n, ok := item.(int)
+		if !ok {
+			return nil, fmt.Errorf("not a number: %v", item)
+		}
+		return acc.(int) + n, nil
+	})
+	defer func() {
+		if err, _ := recover().(error); nodeErrorCause(err) != "not a number: two" {
+			t.Errorf("Expected reduce failure, got %v", err)
+		}
+	}()
+	sum.Run(state)
+}
+
+// TestBatchResultsKey tests separate results keys and the warning when one batch
+// node's results replace another's
+func TestBatchResultsKey(t *testing.T) {
+	var buf bytes.Buffer
+	SetRunLogHandler(slog.NewJSONHandler(&buf, nil))
+	defer SetRunLogHandler(nil)
+
+	double := func(item interface{}) (interface{}, error) { return item.(int) * 2, nil }
+	first := NewMapNode("items", double)
+	first.SetName("first")
+	first.SetParams(map[string]interface{}{"results_key": "doubled"})
+	second := NewMapNode("items", double)
+	second.SetName("second")
+	sum := NewReduceNode("total", 0, func(acc, item interface{}) (interface{}, error) {
+		return acc.(int) + item.(int), nil
+	})
+	sum.SetParams(map[string]interface{}{"results_key": "doubled"})
+
+	state := NewSharedState()
+	state.Set("items", []interface{}{1, 2, 3})
+	first.Run(state)
+	second.Run(state)
+	sum.Run(state)
+	if len(state.GetSlice("doubled")) != 3 || len(state.GetSlice(BatchResultsKey)) != 3 {
+		t.Fatalf("Expected both result sets, got %v and %v", state.Get("doubled"), state.Get(BatchResultsKey))
+	}
+	if state.Get("total") != 12 {
+		t.Errorf("Expected the reduce node to read its results_key, got %v", state.Get("total"))
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warnings for distinct keys, got %s", buf.String())
+	}
+
+	second.Run(state)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warning when a node replaces its own results, got %s", buf.String())
+	}
+	first.SetParams(map[string]interface{}{})
+	first.Run(state)
+	if !strings.Contains(buf.String(), "batch results overwritten") || !strings.Contains(buf.String(), `"previous_node":"second"`) {
+		t.Errorf("Expected an overwrite warning naming the previous node, got %s", buf.String())
+	}
+}
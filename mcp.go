@@ -0,0 +1,289 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MCPProtocolVersion is the Model Context Protocol revision MCPClient requests
+// when it initializes a session
+const MCPProtocolVersion = "2025-06-18"
+
+// MCPTransport carries JSON-RPC messages between an MCPClient and an MCP server,
+// such as over the stdin and stdout of a server subprocess or as POSTs to a
+// streamable HTTP endpoint. It sends message and returns the server's response
+// to it. Notifications have no response: the returned bytes are ignored and
+// may be nil.
+type MCPTransport func(ctx context.Context, message []byte) ([]byte, error)
+
+// MCPTool describes a tool exposed by an MCP server
+type MCPTool struct {
+	// Name identifies the tool in CallTool
+	Name string `json:"name"`
+	// Description tells a model what the tool does
+	Description string `json:"description,omitempty"`
+	// InputSchema is the JSON Schema of the tool's arguments
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+}
+
+// MCPContent is one content block of a tool result
+type MCPContent struct {
+	// Type is the kind of content: "text", "image", "audio", "resource", ...
+	Type string `json:"type"`
+	// Text is the content of a "text" block
+	Text string `json:"text,omitempty"`
+	// Data is the base64-encoded content of an "image" or "audio" block
+	Data string `json:"data,omitempty"`
+	// MimeType is the media type of Data
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// MCPToolResult is the result of calling an MCP tool
+type MCPToolResult struct {
+	// Content holds the result's content blocks
+	Content []MCPContent `json:"content"`
+	// StructuredContent is the result as a JSON value, if the tool returns one
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	// IsError reports that the tool itself failed; Content describes the failure
+	IsError bool `json:"isError,omitempty"`
+}
+
+// Text returns the text blocks of the result joined by newlines
+func (r *MCPToolResult) Text() string {
+	var texts []string
+	for _, content := range r.Content {
+		if content.Type == "text" {
+			texts = append(texts, content.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// MCPError is a JSON-RPC error response from an MCP server
+type MCPError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the server's code and message
+func (e *MCPError) Error() string {
+	return fmt.Sprintf("flow: mcp error %d: %s", e.Code, e.Message)
+}
+
+// MCPClient is a Model Context Protocol client that discovers and calls the tools
+// of one MCP server through a caller-supplied MCPTransport, so the package needs
+// no transport or SDK dependency. The session is initialized on the first
+// request. Tools adapts the server's tools for NewReActNodeContext.
+type MCPClient struct {
+	transport MCPTransport
+	nextID    int64
+
+	mu          sync.Mutex
+	initialized bool
+}
+
+// NewMCPClient creates a client for the MCP server reached through transport
+func NewMCPClient(transport MCPTransport) *MCPClient {
+	return &MCPClient{transport: transport}
+}
+
+// mcpRequest is a JSON-RPC request, or a notification when ID is zero
+type mcpRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int64       `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// mcpResponse is a JSON-RPC response
+type mcpResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *MCPError       `json:"error"`
+}
+
+// ListTools returns every tool the server exposes, following pagination
+func (c *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
+	if err := c.initialize(ctx); err != nil {
+		return nil, err
+	}
+	var tools []MCPTool
+	cursor := ""
+	for {
+		var params map[string]string
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		var page struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor"`
+		}
+		if err := c.request(ctx, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool calls the named tool with arguments. A tool that fails reports it in
+// the result's IsError; the error is for failures to reach the tool.
+func (c *MCPClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*MCPToolResult, error) {
+	if err := c.initialize(ctx); err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{"name": name}
+	if arguments != nil {
+		params["arguments"] = arguments
+	}
+	var result MCPToolResult
+	if err := c.request(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	result.StructuredContent = normalizeJSON(result.StructuredContent)
+	return &result, nil
+}
+
+// Tools discovers the server's tools and returns them as ReAct tools by name, so
+// the reasoner of NewReActNodeContext can call them alongside local tools. Each
+// call is made with the context of the act node's exec call, so cancelling the
+// run interrupts a call in flight. A tool's
+// input is sent as its arguments, and must be a map[string]interface{} or encode
+// to a JSON object. Its observation is the structured content of the result if
+// the tool returns some, and its text otherwise; a result flagged as an error is
+// recorded as the step's Error.
+//
+// Example:
+//
+//	client := flow.NewMCPClient(stdioTransport(cmd))
+//	tools, err := client.Tools(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	tools["calculator"] = flow.Tool(calc).WithContext()
+//	agent := flow.NewReActNodeContext(8, reason, tools)
+func (c *MCPClient) Tools(ctx context.Context) (map[string]ContextTool, error) {
+	listed, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tools := make(map[string]ContextTool, len(listed))
+	for _, tool := range listed {
+		tools[tool.Name] = c.tool(tool.Name)
+	}
+	return tools, nil
+}
+
+// tool adapts the named MCP tool to a ReAct ContextTool
+func (c *MCPClient) tool(name string) ContextTool {
+	return func(ctx context.Context, input interface{}) (interface{}, error) {
+		arguments, err := mcpArguments(input)
+		if err != nil {
+			return nil, fmt.Errorf("flow: mcp tool %q: %w", name, err)
+		}
+		result, err := c.CallTool(ctx, name, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsError {
+			return nil, fmt.Errorf("flow: mcp tool %q: %s", name, result.Text())
+		}
+		if result.StructuredContent != nil {
+			return result.StructuredContent, nil
+		}
+		return result.Text(), nil
+	}
+}
+
+// mcpArguments converts a tool input to the JSON object of a tool call's arguments
+func mcpArguments(input interface{}) (map[string]interface{}, error) {
+	switch v := input.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var arguments map[string]interface{}
+	if err := json.Unmarshal(data, &arguments); err != nil {
+		return nil, fmt.Errorf("input must be a JSON object, got %T", input)
+	}
+	return arguments, nil
+}
+
+// initialize opens the MCP session on the first request. A failed handshake is
+// attempted again by the next request.
+func (c *MCPClient) initialize(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.initialized {
+		return nil
+	}
+
+	params := map[string]interface{}{
+		"protocolVersion": MCPProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "flow"},
+	}
+	if err := c.request(ctx, "initialize", params, nil); err != nil {
+		return err
+	}
+	if err := c.notify(ctx, "notifications/initialized"); err != nil {
+		return err
+	}
+	c.initialized = true
+	return nil
+}
+
+// request sends a request and decodes its result into result, unless it is nil
+func (c *MCPClient) request(ctx context.Context, method string, params, result interface{}) error {
+	id := atomic.AddInt64(&c.nextID, 1)
+	message, err := json.Marshal(mcpRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("flow: mcp %s: %w", method, err)
+	}
+	data, err := c.transport(ctx, message)
+	if err != nil {
+		return fmt.Errorf("flow: mcp %s: %w", method, err)
+	}
+
+	var response mcpResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("flow: mcp %s: decoding response: %w", method, err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if response.ID != id {
+		return fmt.Errorf("flow: mcp %s: response to request %d, expected %d", method, response.ID, id)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("flow: mcp %s: decoding result: %w", method, err)
+	}
+	return nil
+}
+
+// notify sends a notification, which the server does not answer
+func (c *MCPClient) notify(ctx context.Context, method string) error {
+	message, err := json.Marshal(mcpRequest{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return fmt.Errorf("flow: mcp %s: %w", method, err)
+	}
+	if _, err := c.transport(ctx, message); err != nil {
+		return fmt.Errorf("flow: mcp %s: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMCPServer answers the MCP requests of a client in process
+type fakeMCPServer struct {
+	methods []string
+}
+
+// transport handles one JSON-RPC message the way an MCP server would
+func (s *fakeMCPServer) transport(ctx context.Context, message []byte) ([]byte, error) {
+	var req struct {
+		ID     int64                  `json:"id"`
+		Method string                 `json:"method"`
+		Params map[string]interface{} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &req); err != nil {
+		return nil, err
+	}
+	s.methods = append(s.methods, req.Method)
+
+	var result interface{}
+	switch req.Method {
+	case "notifications/initialized":
+		return nil, nil
+	case "initialize":
+		result = map[string]interface{}{"protocolVersion": MCPProtocolVersion, "capabilities": map[string]interface{}{"tools": map[string]interface{}{}}}
+	case "tools/list":
+		// Two pages of one tool each
+		if req.Params["cursor"] == nil {
+			result = map[string]interface{}{"tools": []MCPTool{{Name: "weather"}}, "nextCursor": "page-2"}
+		} else {
+			result = map[string]interface{}{"tools": []MCPTool{{Name: "lookup"}}}
+		}
+	case "tools/call":
+		args, _ := req.Params["arguments"].(map[string]interface{})
+		switch req.Params["name"] {
+		case "weather":
+			if args["city"] == "Atlantis" {
+				result = MCPToolResult{Content: []MCPContent{{Type: "text", Text: "unknown city"}}, IsError: true}
+			} else {
+				result = MCPToolResult{Content: []MCPContent{{Type: "text", Text: fmt.Sprintf("sunny in %v", args["city"])}}}
+			}
+		case "lookup":
+			result = MCPToolResult{StructuredContent: map[string]interface{}{"population": 2100000}}
+		default:
+			return json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": MCPError{Code: -32602, Message: "unknown tool"}})
+		}
+	}
+	return json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+// TestMCPToolsInReActLoop tests that discovered MCP tools are called by a ReAct loop
+func TestMCPToolsInReActLoop(t *testing.T) {
+	server := &fakeMCPServer{}
+	client := NewMCPClient(server.transport)
+	tools, err := client.Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tools) != 2 || tools["weather"] == nil || tools["lookup"] == nil {
+		t.Fatalf("Expected the tools of both pages, got %v", tools)
+	}
+
+	type city struct {
+		City string `json:"city"`
+	}
+	reason := func(shared *SharedState, steps []ReActStep) (ReActDecision, error) {
+		switch len(steps) {
+		case 0:
+			return ReActDecision{Action: "weather", Input: city{"Paris"}}, nil
+		case 1:
+			return ReActDecision{Action: "weather", Input: map[string]interface{}{"city": "Atlantis"}}, nil
+		case 2:
+			return ReActDecision{Action: "lookup", Input: city{"Paris"}}, nil
+		default:
+			return ReActDecision{Answer: steps[0].Observation}, nil
+		}
+	}
+	state := NewSharedState()
+	if action := NewFlow().Start(NewReActNodeContext(5, reason, tools)).Run(state); action != AnswerAction {
+		t.Fatalf("Expected %q, got %q", AnswerAction, action)
+	}
+
+	steps := state.Get(ScratchpadKey).([]ReActStep)
+	if steps[0].Observation != "sunny in Paris" {
+		t.Errorf("Expected the text result as the observation, got %v", steps[0].Observation)
+	}
+	if steps[1].Error != `flow: mcp tool "weather": unknown city` {
+		t.Errorf("Expected the tool error as the step's error, got %q", steps[1].Error)
+	}
+	if fmt.Sprint(steps[2].Observation) != "map[population:2100000]" {
+		t.Errorf("Expected the structured result as the observation, got %v", steps[2].Observation)
+	}
+	if server.methods[0] != "initialize" || server.methods[1] != "notifications/initialized" {
+		t.Errorf("Expected the session to be initialized first, got %v", server.methods)
+	}
+	initialized := 0
+	for _, method := range server.methods {
+		if method == "initialize" {
+			initialized++
+		}
+	}
+	if initialized != 1 {
+		t.Errorf("Expected one initialize request, got %d", initialized)
+	}
+}
+
+// TestMCPToolCancelled tests that cancelling the run interrupts an MCP call in flight
+func TestMCPToolCancelled(t *testing.T) {
+	server := &fakeMCPServer{}
+	calling := make(chan struct{})
+	interrupted := make(chan error, 1)
+	client := NewMCPClient(func(ctx context.Context, message []byte) ([]byte, error) {
+		if !strings.Contains(string(message), `"tools/call"`) {
+			return server.transport(ctx, message)
+		}
+		close(calling)
+		<-ctx.Done()
+		interrupted <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	tools, err := client.Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reason := func(shared *SharedState, steps []ReActStep) (ReActDecision, error) {
+		if len(steps) == 0 {
+			return ReActDecision{Action: "weather", Input: map[string]interface{}{"city": "Paris"}}, nil
+		}
+		return ReActDecision{Answer: steps[0].Error}, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_, _ = NewFlow().Start(NewReActNodeContext(5, reason, tools)).RunContext(ctx, NewSharedState())
+	}()
+	<-calling
+	cancel()
+
+	select {
+	case err := <-interrupted:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the call's ctx to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected cancelling the run to interrupt the call")
+	}
+}
+
+// TestMCPClientErrors tests that JSON-RPC errors and transport failures are returned
+func TestMCPClientErrors(t *testing.T) {
+	server := &fakeMCPServer{}
+	client := NewMCPClient(server.transport)
+	_, err := client.CallTool(context.Background(), "teleport", nil)
+	var mcpErr *MCPError
+	if !errors.As(err, &mcpErr) || mcpErr.Code != -32602 {
+		t.Errorf("Expected the server's error, got %v", err)
+	}
+
+	down := errors.New("connection refused")
+	failing := NewMCPClient(func(context.Context, []byte) ([]byte, error) { return nil, down })
+	if _, err := failing.ListTools(context.Background()); !errors.Is(err, down) {
+		t.Errorf("Expected the transport error, got %v", err)
+	}
+	if _, err := failing.Tools(context.Background()); err == nil {
+		t.Error("Expected the failed handshake to be attempted again")
+	}
+}
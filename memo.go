@@ -0,0 +1,139 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCachePatternUnsupported is returned by InvalidateCache when the node's cache
+// backend cannot delete keys by pattern
+var ErrCachePatternUnsupported = errors.New("flow: cache backend does not support pattern invalidation")
+
+// CachePatternDeleter is implemented by Cache backends that can delete every key
+// matching a glob pattern ("*" for any run of characters, "?" for one, "[...]" for
+// a class), which InvalidateCache requires
+type CachePatternDeleter interface {
+	DeletePattern(pattern string) error
+}
+
+// SetCacheKeyFunc sets the function deriving the cache key of an exec input for a
+// node with "cache": true. Inputs with the same key share one cached result; without
+// a key function, inputs are keyed by fmt.Sprint(input).
+//
+// Example:
+//
+//	geocode.SetParams(map[string]interface{}{"data": addresses, "batch": true, "cache": true})
+//	geocode.SetCacheKeyFunc(func(input interface{}) string {
+//		return strings.ToLower(input.(Address).String())
+//	})
+func (n *Node) SetCacheKeyFunc(fn func(input interface{}) string) {
+	n.cacheKeyFunc = fn
+}
+
+// InvalidateCache deletes the node's cached results whose keys match the glob
+// pattern, so stale steps can be refreshed without flushing the whole cache.
+// It returns ErrCachePatternUnsupported if the backend cannot match patterns.
+//
+// Example:
+//
+//	// Forget every geocoded address in Paris
+//	geocode.InvalidateCache("*, paris")
+func (n *Node) InvalidateCache(pattern string) error {
+	cache, prefix := n.memoCache(context.Background())
+	deleter, ok := cache.(CachePatternDeleter)
+	if !ok {
+		return ErrCachePatternUnsupported
+	}
+	return deleter.DeletePattern(prefix + pattern)
+}
+
+// cacheKey returns the cache key of input, or false if the node does not cache
+func (n *Node) cacheKey(ctx context.Context, input interface{}) (string, bool) {
+	if !n.getBoolParam(ctx, "cache") {
+		return "", false
+	}
+	if n.cacheKeyFunc != nil {
+		return n.cacheKeyFunc(input), true
+	}
+	return fmt.Sprint(input), true
+}
+
+// memoEntry wraps a cached result with the time it stops being fresh, for nodes
+// serving stale results while they revalidate
+type memoEntry struct {
+	Value      interface{} `json:"value"`
+	FreshUntil time.Time   `json:"fresh_until"`
+}
+
+// decodeMemoEntry recovers a memoEntry from a cached value, including one decoded
+// from JSON by a shared backend
+func decodeMemoEntry(value interface{}) (memoEntry, bool) {
+	switch v := value.(type) {
+	case memoEntry:
+		return v, true
+	case map[string]interface{}:
+		freshUntil, ok := v["fresh_until"].(string)
+		if !ok || len(v) != 2 {
+			return memoEntry{}, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, freshUntil)
+		if err != nil {
+			return memoEntry{}, false
+		}
+		return memoEntry{Value: v["value"], FreshUntil: t}, true
+	default:
+		return memoEntry{}, false
+	}
+}
+
+// cachedResult returns the memoized result for key. With "cache_stale" set, a
+// result past its "cache_ttl" is still returned while a background exec call
+// refreshes it.
+func (n *Node) cachedResult(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta, key string) (interface{}, bool) {
+	cache, prefix := n.memoCache(ctx)
+	value, ok := cache.Get(prefix + key)
+	if !ok {
+		return nil, false
+	}
+	entry, ok := decodeMemoEntry(value)
+	if !ok {
+		return value, true
+	}
+	if time.Now().After(entry.FreshUntil) {
+		n.revalidate(ctx, shared, input, meta, key)
+	}
+	return entry.Value, true
+}
+
+// storeResult memoizes the result for key, expiring it after "cache_ttl" plus the
+// "cache_stale" window during which it may be served while revalidating
+func (n *Node) storeResult(ctx context.Context, key string, result interface{}) {
+	cache, prefix := n.memoCache(ctx)
+	ttl := n.getDurationParam(ctx, "cache_ttl")
+	stale := n.getDurationParam(ctx, "cache_stale")
+	if ttl <= 0 || stale <= 0 {
+		cache.Set(prefix+key, result, ttl)
+		return
+	}
+	cache.Set(prefix+key, memoEntry{Value: result, FreshUntil: time.Now().Add(ttl)}, ttl+stale)
+}
+
+// revalidate refreshes a stale result in the background, once per key at a time.
+// A failed refresh keeps serving the stale result until it expires.
+func (n *Node) revalidate(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta, key string) {
+	if _, running := n.revalidating.LoadOrStore(key, true); running {
+		return
+	}
+	meta.Attempt, meta.MaxAttempts = 1, 1
+	// The refresh may outlive the run that found the result stale
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer n.revalidating.Delete(key)
+		defer func() { _ = recover() }() // A panicking refresh is a failed refresh
+		if result, err := n.callExec(ctx, shared, input, meta); err == nil {
+			n.storeResult(ctx, key, result)
+		}
+	}()
+}
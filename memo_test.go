@@ -0,0 +1,174 @@
+package Flow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCacheReusesResults tests that cached exec results are reused across runs by key
+func TestCacheReusesResults(t *testing.T) {
+	lookups := 0
+
+	geocode := NewNode()
+	geocode.SetParams(map[string]interface{}{
+		"data":  []string{"Paris", "paris", "Berlin"},
+		"batch": true,
+		"cache": true,
+	})
+	geocode.SetCacheKeyFunc(func(input interface{}) string {
+		return strings.ToLower(input.(string))
+	})
+	geocode.SetExecFunc(func(item interface{}) (interface{}, error) {
+		lookups++
+		return "coords of " + strings.ToLower(item.(string)), nil
+	})
+
+	geocode.Run(NewSharedState())
+	state := NewSharedState()
+	geocode.Run(state)
+
+	if lookups != 2 {
+		t.Errorf("Expected 2 lookups, got %d", lookups)
+	}
+	results := state.GetSlice(BatchResultsKey)
+	if len(results) != 3 || results[1] != "coords of paris" || results[2] != "coords of berlin" {
+		t.Errorf("Unexpected cached results: %v", results)
+	}
+}
+
+// TestCacheSkipsFailures tests that failed exec calls are not cached
+func TestCacheSkipsFailures(t *testing.T) {
+	calls := 0
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"cache": true})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("config service down")
+		}
+		return "config", nil
+	})
+
+	func() {
+		defer func() { recover() }()
+		node.Run(NewSharedState())
+	}()
+	node.Run(NewSharedState())
+	node.Run(NewSharedState())
+
+	if calls != 2 {
+		t.Errorf("Expected a failure not to be cached, got %d calls", calls)
+	}
+}
+
+// TestCacheTTL tests that cached results expire after cache_ttl
+func TestCacheTTL(t *testing.T) {
+	calls := 0
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"cache": true, "cache_ttl": 10 * time.Millisecond})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		calls++
+		return "config", nil
+	})
+
+	node.Run(NewSharedState())
+	node.Run(NewSharedState())
+	time.Sleep(20 * time.Millisecond)
+	node.Run(NewSharedState())
+
+	if calls != 2 {
+		t.Errorf("Expected a refetch after the TTL, got %d calls", calls)
+	}
+}
+
+// TestCacheStaleWhileRevalidate tests that stale results are served while a background refresh runs
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var version int64
+	refreshed := make(chan struct{}, 1)
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"cache":       true,
+		"cache_ttl":   10 * time.Millisecond,
+		"cache_stale": time.Minute,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		v := atomic.AddInt64(&version, 1)
+		if v > 1 {
+			refreshed <- struct{}{}
+		}
+		return fmt.Sprintf("v%d", v), nil
+	})
+
+	if action := node.Run(NewSharedState()); action != "v1" {
+		t.Fatalf("Expected v1, got %s", action)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if action := node.Run(NewSharedState()); action != "v1" {
+		t.Errorf("Expected the stale v1 to be served, got %s", action)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a background refresh")
+	}
+	// The refresh stores its result right after exec returns
+	deadline := time.Now().Add(time.Second)
+	for node.Run(NewSharedState()) != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the refreshed v2 to be served")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestInvalidateCache tests that results matching a pattern are deleted from LRU and Redis backends
+func TestInvalidateCache(t *testing.T) {
+	server := startFakeRedis(t)
+	RegisterCache("test-redis", NewRedisCache(server.addr, "memo:"))
+
+	for _, backend := range []string{"", "test-redis"} {
+		calls := 0
+		geocode := NewNode()
+		geocode.SetName("geocode")
+		geocode.SetParams(map[string]interface{}{
+			"data":          []string{"paris/fr", "lyon/fr", "berlin/de"},
+			"batch":         true,
+			"cache":         true,
+			"cache_backend": backend,
+		})
+		geocode.SetExecFunc(func(item interface{}) (interface{}, error) {
+			calls++
+			return item, nil
+		})
+
+		geocode.Run(NewSharedState())
+		if err := geocode.InvalidateCache("*/fr"); err != nil {
+			t.Fatalf("Unexpected error (backend %q): %v", backend, err)
+		}
+		geocode.Run(NewSharedState())
+
+		if calls != 5 {
+			t.Errorf("Expected only the French entries to be refetched (backend %q), got %d calls", backend, calls)
+		}
+	}
+
+	RegisterCache("test-plain", plainCache{})
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"cache": true, "cache_backend": "test-plain"})
+	if err := node.InvalidateCache("*"); !errors.Is(err, ErrCachePatternUnsupported) {
+		t.Errorf("Expected ErrCachePatternUnsupported, got %v", err)
+	}
+}
+
+// plainCache is a Cache without pattern support
+type plainCache struct{}
+
+func (plainCache) Get(string) (interface{}, bool)         { return nil, false }
+func (plainCache) Set(string, interface{}, time.Duration) {}
+func (plainCache) Delete(string)                          {}
@@ -0,0 +1,133 @@
+package Flow
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Gauge names reported to Metrics. Each carries one label identifying what it measures.
+const (
+	// GaugeQueuedRuns counts runs waiting to start, labelled "queue" with the
+	// scheduler name or "pool" for FlowPool runs
+	GaugeQueuedRuns = "flow_queued_runs"
+	// GaugeInFlightNodes counts node executions in progress, labelled "node"
+	GaugeInFlightNodes = "flow_inflight_nodes"
+	// GaugeInFlightItems counts batch items started but not finished (including
+	// items waiting out a retry backoff), labelled "node"
+	GaugeInFlightItems = "flow_inflight_batch_items"
+	// GaugeLimiterWait is the most recent time, in seconds, an exec call waited for
+	// a limiter, semaphore or scheduler slot, labelled "limiter"
+	GaugeLimiterWait = "flow_limiter_wait_seconds"
+	// GaugeBatchConcurrency is the concurrency currently allowed to a parallel
+	// batch with "adaptive_concurrency", labelled "node"
+	GaugeBatchConcurrency = "flow_batch_concurrency_limit"
+)
+
+// Metrics receives gauge updates, so capacity issues are visible in an existing
+// monitoring system before they become timeouts. Register an implementation with
+// SetMetrics; SetGauge is called on every change and must be safe for concurrent use.
+//
+// Example:
+//
+//	type promMetrics struct{ vecs map[string]*prometheus.GaugeVec }
+//
+//	func (m promMetrics) SetGauge(name, label, labelValue string, value float64) {
+//		m.vecs[name].WithLabelValues(labelValue).Set(value)
+//	}
+//
+//	flow.SetMetrics(promMetrics{vecs: gaugeVecs})
+type Metrics interface {
+	SetGauge(name, label, labelValue string, value float64)
+}
+
+// GaugeValue is the current value of one gauge series
+type GaugeValue struct {
+	Name       string
+	Label      string
+	LabelValue string
+	Value      float64
+}
+
+// gaugeKey identifies a gauge series
+type gaugeKey struct {
+	name       string
+	labelValue string
+}
+
+var gaugeRegistry = struct {
+	mu      sync.Mutex
+	values  map[gaugeKey]float64
+	metrics Metrics
+}{
+	values: make(map[gaugeKey]float64),
+}
+
+// gaugeLabels maps each gauge to the name of its label
+var gaugeLabels = map[string]string{
+	GaugeQueuedRuns:       "queue",
+	GaugeInFlightNodes:    "node",
+	GaugeInFlightItems:    "node",
+	GaugeLimiterWait:      "limiter",
+	GaugeBatchConcurrency: "node",
+}
+
+// SetMetrics registers the process-wide receiver of gauge updates (nil to stop
+// reporting). The current value of every gauge is reported to it immediately.
+func SetMetrics(m Metrics) {
+	gaugeRegistry.mu.Lock()
+	defer gaugeRegistry.mu.Unlock()
+	gaugeRegistry.metrics = m
+	if m == nil {
+		return
+	}
+	for key, value := range gaugeRegistry.values {
+		m.SetGauge(key.name, gaugeLabels[key.name], key.labelValue, value)
+	}
+}
+
+// Gauges returns the current value of every gauge series, sorted by name and label
+func Gauges() []GaugeValue {
+	gaugeRegistry.mu.Lock()
+	defer gaugeRegistry.mu.Unlock()
+	values := make([]GaugeValue, 0, len(gaugeRegistry.values))
+	for key, value := range gaugeRegistry.values {
+		values = append(values, GaugeValue{Name: key.name, Label: gaugeLabels[key.name], LabelValue: key.labelValue, Value: value})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Name != values[j].Name {
+			return values[i].Name < values[j].Name
+		}
+		return values[i].LabelValue < values[j].LabelValue
+	})
+	return values
+}
+
+// addGauge changes a gauge by delta
+func addGauge(name, labelValue string, delta float64) {
+	gaugeRegistry.mu.Lock()
+	defer gaugeRegistry.mu.Unlock()
+	key := gaugeKey{name: name, labelValue: labelValue}
+	setGaugeLocked(key, gaugeRegistry.values[key]+delta)
+}
+
+// setGauge sets a gauge to value
+func setGauge(name, labelValue string, value float64) {
+	gaugeRegistry.mu.Lock()
+	defer gaugeRegistry.mu.Unlock()
+	setGaugeLocked(gaugeKey{name: name, labelValue: labelValue}, value)
+}
+
+func setGaugeLocked(key gaugeKey, value float64) {
+	gaugeRegistry.values[key] = value
+	if gaugeRegistry.metrics != nil {
+		gaugeRegistry.metrics.SetGauge(key.name, gaugeLabels[key.name], key.labelValue, value)
+	}
+}
+
+// timeWait records how long fn blocked as the limiter wait of the named limiter
+func timeWait(limiter string, fn func()) {
+	start := time.Now()
+	fn()
+	setGauge(GaugeLimiterWait, limiter, time.Since(start).Seconds())
+}
@@ -0,0 +1,150 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics keeps the last value reported for each gauge series
+type recordingMetrics struct {
+	mu     sync.Mutex
+	values map[gaugeKey]float64
+	labels map[string]string
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{values: make(map[gaugeKey]float64), labels: make(map[string]string)}
+}
+
+func (m *recordingMetrics) SetGauge(name, label, labelValue string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[gaugeKey{name: name, labelValue: labelValue}] = value
+	m.labels[name] = label
+}
+
+func (m *recordingMetrics) get(name, labelValue string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.values[gaugeKey{name: name, labelValue: labelValue}]
+}
+
+// gaugeValue returns the current value of a gauge series from Gauges
+func gaugeValue(name, labelValue string) float64 {
+	for _, g := range Gauges() {
+		if g.Name == name && g.LabelValue == labelValue {
+			return g.Value
+		}
+	}
+	return 0
+}
+
+// TestInFlightGauges tests the in-flight node and batch item gauges during a parallel batch
+func TestInFlightGauges(t *testing.T) {
+	metrics := newRecordingMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	node := NewNode()
+	node.SetName("test-gauge-batch")
+	node.SetParams(map[string]interface{}{
+		"data":     []int{1, 2, 3},
+		"batch":    true,
+		"parallel": true,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return item, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		node.Run(NewSharedState())
+	}()
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	if got := metrics.get(GaugeInFlightNodes, "test-gauge-batch"); got != 1 {
+		t.Errorf("Expected 1 node in flight, got %v", got)
+	}
+	if got := metrics.get(GaugeInFlightItems, "test-gauge-batch"); got != 3 {
+		t.Errorf("Expected 3 items in flight, got %v", got)
+	}
+	if metrics.labels[GaugeInFlightItems] != "node" {
+		t.Errorf("Expected label node, got %q", metrics.labels[GaugeInFlightItems])
+	}
+
+	close(release)
+	<-done
+	if got := gaugeValue(GaugeInFlightNodes, "test-gauge-batch"); got != 0 {
+		t.Errorf("Expected no node in flight after the run, got %v", got)
+	}
+	if got := gaugeValue(GaugeInFlightItems, "test-gauge-batch"); got != 0 {
+		t.Errorf("Expected no items in flight after the run, got %v", got)
+	}
+}
+
+// TestQueuedRunsGauge tests that runs waiting for a scheduler slot are counted
+func TestQueuedRunsGauge(t *testing.T) {
+	s := Scheduler("test-gauge-sched", 1)
+	if err := s.Acquire(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return "ok", nil })
+	f := NewFlow().Start(node)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = s.Run(context.Background(), f, NewSharedState(), 0)
+		}()
+	}
+	for s.Waiting() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	metrics := newRecordingMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+	// Registering replays the current values
+	if got := metrics.get(GaugeQueuedRuns, "test-gauge-sched"); got != 2 {
+		t.Errorf("Expected 2 queued runs, got %v", got)
+	}
+
+	s.Release()
+	wg.Wait()
+	if got := metrics.get(GaugeQueuedRuns, "test-gauge-sched"); got != 0 {
+		t.Errorf("Expected no queued runs, got %v", got)
+	}
+}
+
+// TestLimiterWaitGauge tests that the time spent waiting for a limiter is reported
+func TestLimiterWaitGauge(t *testing.T) {
+	limiter := Limiter("test-gauge-limiter", 1)
+	limiter.Acquire()
+	go func() {
+		time.Sleep(time.Millisecond * 30)
+		limiter.Release()
+	}()
+
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return "ok", nil })
+	f := NewFlow().Start(node)
+	f.SetParams(map[string]interface{}{"limiter": "test-gauge-limiter"})
+	f.Run(NewSharedState())
+
+	if got := gaugeValue(GaugeLimiterWait, "test-gauge-limiter"); got < 0.02 {
+		t.Errorf("Expected a limiter wait of at least 20ms, got %vs", got)
+	}
+}
@@ -1,11 +1,13 @@
 package Flow
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
-	"math"
 	"math/big"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,13 +46,66 @@ func secureRandFloat64() float64 {
 // The Node maintains a map of parameters, successor nodes for workflow chaining,
 // and optional user-provided functions for custom prep, exec, and post processing.
 type Node struct {
-	params     map[string]interface{}
-	successors map[string]*Node
+	name          string
+	params        map[string]interface{}
+	paramsVersion uint64       // incremented whenever params are replaced
+	adoptedFrom   *Node        // the flow node whose params were last adopted, nil after SetParams
+	adoptedAt     uint64       // the paramsVersion of adoptedFrom that was adopted
+	checkedAt     uint64       // the paramsVersion last checked for ignored params
+	paramsMu      sync.RWMutex // guards the params fields, which flows replace on every node they run
+	successors    map[string]*Node
+	nextFunc      func(*SharedState, string) *Node
+	edgesMu       sync.RWMutex // guards successors and nextFunc against mutation mid-run
+	fanIn         *fanIn       // concurrent entry branches, set by Flow.StartAll
+	weighted      map[string][]weightedEdge
+	mapKey        string             // state key of the items mapped over, set by NewMapNode
+	gate          gate               // state for the "debounce" and "throttle" params
+	source        <-chan interface{} // values fed through the item subgraph, set by NewChannelSourceNode
+	sink          *sink              // buffered output, set by the sink node constructors
 
 	// User-provided functions (optional)
-	execFunc func(interface{}) (interface{}, error)
-	prepFunc func(*SharedState) interface{}
-	postFunc func(*SharedState, interface{}, interface{}) string
+	execFunc    func(context.Context, *SharedState, interface{}, ItemMeta) (interface{}, error)
+	prepFunc    func(context.Context, *SharedState) interface{}
+	postFunc    func(context.Context, *SharedState, interface{}, interface{}) string
+	cleanupFunc func(*SharedState, error)
+
+	checkpointFunc func(*SharedState, BatchCheckpoint)
+	idempotencyKey func(interface{}) string
+	cacheKeyFunc   func(interface{}) string
+	priorityFunc   func(interface{}) int
+	weightFunc     func(interface{}) int
+	fallbacks      []*Node // tried in order when exec fails, set by SetFallbacks
+
+	memo     Cache     // default backend of "cache": true, reused across runs
+	memoOnce sync.Once // creates memo on first use
+
+	isolation    *bulkhead // slots of the "bulkhead" param, reused across runs
+	bulkheadOnce sync.Once // creates isolation on first use
+
+	revalidating sync.Map // cache keys being refreshed in the background
+	stats        nodeStats
+}
+
+// ItemMeta describes the item an exec call is processing.
+// It is passed to functions registered with SetBatchExecFunc, which need it for
+// logging progress, producing positional outputs, and adapting to the attempt.
+type ItemMeta struct {
+	// Index is the zero-based position of the item in the batch data
+	Index int
+	// Total is the number of items in the batch (1 outside of batch mode)
+	Total int
+	// Attempt is the one-based attempt number for the current exec call
+	Attempt int
+	// MaxAttempts is the number of attempts allowed by the "retries" param (at least 1).
+	// A spent "retry_budget" or "retry_max_elapsed" can end retries before
+	// MaxAttempts is reached.
+	MaxAttempts int
+}
+
+// FinalAttempt reports whether the current exec call is the last one allowed,
+// e.g. to fall back to a cheaper or more reliable dependency
+func (m ItemMeta) FinalAttempt() bool {
+	return m.Attempt >= m.MaxAttempts
 }
 
 // NewNode creates a new adaptive Node with empty parameters and successors.
@@ -70,6 +125,7 @@ func NewNode() *Node {
 	return &Node{
 		params:     make(map[string]interface{}),
 		successors: make(map[string]*Node),
+		weighted:   make(map[string][]weightedEdge),
 	}
 }
 
@@ -77,10 +133,53 @@ func NewNode() *Node {
 // Parameters determine which execution patterns the node will use:
 //   - "batch": true - enables batch processing of "data" parameter
 //   - "parallel": true - enables parallel execution (requires "batch": true)
-//   - "parallel_limit": int - limits concurrent goroutines (default: 10)
+//   - "parallel_limit": int - limits concurrent goroutines (default: 10), or their total weight (see SetItemWeightFunc)
+//   - "adaptive_concurrency": bool - shrink the parallel batch's concurrency below "parallel_limit" while attempts fail, and grow it back as they succeed
+//   - "adaptive_latency": time.Duration - with "adaptive_concurrency", attempts slower than this count as failures
+//   - "coordinated_backoff": bool - pause the whole parallel batch while an item backs off or the node's breaker is open, then probe with one attempt at a time
+//   - "deterministic": bool - run parallel batch items one attempt at a time in a seeded, reproducible order
+//   - "seed": int - seed of the "deterministic" interleaving
 //   - "retries": int - enables retry logic with exponential backoff
 //   - "retry_delay": time.Duration - base delay for retry backoff
+//   - "retry_multiplier": float64 - backoff growth factor per attempt (default: 2)
+//   - "retry_max_delay": time.Duration - upper bound on a single backoff wait
+//   - "retry_jitter": float64 - jitter as a fraction of the backoff (default: 0.1)
+//   - "retry_jitter_strategy": string - JitterFull, JitterEqual, or JitterDecorrelated
+//   - "retry_budget": int - total retries shared by all items of a batch execution
+//   - "retry_max_elapsed": time.Duration - stop retrying an item once the next attempt would start this long after its first
+//   - "limiter": string - name of a shared limiter registered with Limiter() or RateLimiter()
+//   - "semaphores": []string - names of shared limiters to hold a slot of each during exec
+//   - "scheduler": string - name of a priority scheduler registered with Scheduler()
+//   - "priority": int - scheduling priority of exec calls, higher first (see SetPriorityFunc)
+//   - "bulkhead": int - exec slots reserved for this node alone, fixed on first use
+//   - "bulkhead_queue": int - calls allowed to wait for a bulkhead slot before ErrBulkheadFull
+//   - "bulkhead_shed": string - ShedReject, ShedDropOldest, or ShedDegrade when the bulkhead queue is full
+//   - "models": []string - model preference list that a SetModelExecFunc function fails over along
+//   - "model": string - Model name whose context window and tokenizer bound string and []Message exec inputs
+//   - "context_window": int - Context window in tokens, overriding the model's
+//   - "reserve_tokens": int - Tokens of the window reserved for the completion
+//   - "context_policy": string - ContextReject (default) or ContextTruncate for prompts over budget
+//   - "breaker": string - name of a shared circuit breaker registered with CircuitBreaker()
+//   - "stream_key": string - append the chunks of a SetStreamExecFunc function to this key as they arrive
+//   - "hedge_after": time.Duration - launch a second exec call if the first is still running
+//   - "post_error_action": string - route errors of a SetPostFuncWithError function (and recovered post panics) to this action
+//   - "recover_panics": bool - convert panics in prep/exec/post into *PanicError errors of their phase
+//   - "resume_key": string - record completed items under this key so a re-run skips them
+//   - "checkpoint_every": int - call the SetBatchCheckpointFunc func every N completed items
+//   - "dedup": string - skip items already marked in the RegisterDedup store of this name
+//   - "cache": bool - reuse exec results by input across runs (see SetCacheKeyFunc)
+//   - "cache_backend": string - memoize into the RegisterCache backend of this name
+//   - "cache_ttl": time.Duration - expire cached results after this long
+//   - "cache_stale": time.Duration - serve expired results this much longer while refreshing them
+//   - "batch_metadata": bool - store per-item []BatchItemMetadata under BatchMetadataKey
+//   - "continue_on_error": bool - finish the batch past failed items, storing every []Result under BatchItemResultsKey
+//   - "debounce": time.Duration - suppress calls within this interval of the previous call
+//   - "throttle": time.Duration - wait so starts are at least this interval apart
 //   - "data": []interface{} - data to process in batch mode
+//   - "results_key": string - SharedState key to store batch results under (default: BatchResultsKey)
+//   - "data_key": string - SharedState key to read the batch data from at run time when "data" is unset
+//   - "map": func(interface{}) interface{} - replace each batch item before it is executed
+//   - "filter": func(interface{}) bool - skip batch items (after "map") for which it returns false; results cover the kept items
 //
 // Example:
 //
@@ -91,11 +190,58 @@ func NewNode() *Node {
 //		"retries": 3,
 //	})
 func (n *Node) SetParams(params map[string]interface{}) {
+	n.paramsMu.Lock()
+	defer n.paramsMu.Unlock()
 	n.params = params
+	n.paramsVersion++
+	n.adoptedFrom = nil
+}
+
+// adoptParams sets the params of a flow's node like SetParams unless the node
+// already holds the same version of them, as when the flow runs it again,
+// sparing the write lock
+func (n *Node) adoptParams(from *Node) {
+	from.paramsMu.RLock()
+	params, version := from.params, from.paramsVersion
+	from.paramsMu.RUnlock()
+	if params == nil {
+		return
+	}
+
+	n.paramsMu.RLock()
+	same := n.adoptedFrom == from && n.adoptedAt == version
+	n.paramsMu.RUnlock()
+	if same {
+		return
+	}
+	n.paramsMu.Lock()
+	defer n.paramsMu.Unlock()
+	n.params = params
+	n.paramsVersion++
+	n.adoptedFrom, n.adoptedAt = from, version
+}
+
+// paramMap returns the node's parameters as set, without evaluating ParamFuncs
+func (n *Node) paramMap() Params {
+	n.paramsMu.RLock()
+	defer n.paramsMu.RUnlock()
+	return n.params
 }
 
-// GetParam retrieves a parameter value by key.
-// Returns nil if the parameter doesn't exist.
+// SetName assigns a human-readable name to the node.
+// Names identify the node in profiler labels and diagnostics.
+func (n *Node) SetName(name string) {
+	n.name = name
+}
+
+// Name returns the node's name, or "" if none was set
+func (n *Node) Name() string {
+	return n.name
+}
+
+// GetParam retrieves a parameter value by key as set, returning ParamFunc values
+// unevaluated (functions registered with SetExecFuncWithParams receive the values
+// computed for their run). Returns nil if the parameter doesn't exist.
 //
 // Example:
 //
@@ -104,7 +250,7 @@ func (n *Node) SetParams(params map[string]interface{}) {
 //		retriesInt := retries.(int)
 //	}
 func (n *Node) GetParam(key string) interface{} {
-	return n.params[key]
+	return n.paramMap()[key]
 }
 
 // Next establishes a connection to another node for workflow chaining.
@@ -127,62 +273,492 @@ func (n *Node) Next(node *Node, action string) *Node {
 	if action == "" {
 		action = DefaultAction
 	}
+	n.edgesMu.Lock()
+	previous, replaced := n.successors[action]
 	n.successors[action] = node
+	delete(n.weighted, action)
+	n.edgesMu.Unlock()
+
+	if replaced && previous != node {
+		n.warn(nil, WarnSuccessorOverwritten, "flow: successor overwritten",
+			map[string]interface{}{"action": action, "previous": debugNodeName(previous.Name()), "next": debugNodeName(node.Name())})
+	}
+	return node
+}
+
+// weightedEdge is one candidate successor registered with NextWeighted
+type weightedEdge struct {
+	node   *Node
+	weight float64
+}
+
+// NextWeighted adds node as a candidate successor for action with the given weight.
+// Unlike Next, which replaces the edge, every candidate registered under the same
+// action is kept, and each routing decision picks one at random in proportion to
+// its weight, e.g. for canarying a new branch on a small share of runs. Weights
+// <= 0 are never chosen. The first weighted candidate replaces an edge set with
+// Next, and calling Next for the action replaces all candidates.
+//
+// Example:
+//
+//	ingest.NextWeighted(stableParser, "parse", 95)
+//	ingest.NextWeighted(canaryParser, "parse", 5)
+func (n *Node) NextWeighted(node *Node, action string, weight float64) *Node {
+	if action == "" {
+		action = DefaultAction
+	}
+	n.edgesMu.Lock()
+	defer n.edgesMu.Unlock()
+	if _, ok := n.successors[action]; !ok || len(n.weighted[action]) == 0 {
+		// The first candidate doubles as the static edge reported by GetSuccessors
+		n.successors[action] = node
+		delete(n.weighted, action)
+	}
+	n.weighted[action] = append(n.weighted[action], weightedEdge{node: node, weight: weight})
 	return node
 }
 
-// GetSuccessors returns a map of all successor nodes keyed by their action strings.
+// RemoveNext removes the successor registered for action, if any.
+// Like Next, it is safe to call while a flow containing the node is running,
+// for example from a post function that revises the remaining plan; the change
+// applies to the next routing decision made from this node.
+func (n *Node) RemoveNext(action string) {
+	if action == "" {
+		action = DefaultAction
+	}
+	n.edgesMu.Lock()
+	defer n.edgesMu.Unlock()
+	delete(n.successors, action)
+	delete(n.weighted, action)
+}
+
+// NextFunc registers a function that computes the next node at runtime from the
+// shared state and the action returned by this node, for routing chosen by a
+// planner or lookup table rather than a static edge. The function is consulted
+// before the successors registered with Next(); returning nil falls back to
+// them. The action is "default" when the node returned an empty action.
+//
+// Example:
+//
+//	planner.NextFunc(func(shared *flow.SharedState, action string) *flow.Node {
+//		return tools[shared.Get("next_tool").(string)]
+//	})
+func (n *Node) NextFunc(fn func(shared *SharedState, action string) *Node) {
+	n.edgesMu.Lock()
+	defer n.edgesMu.Unlock()
+	n.nextFunc = fn
+}
+
+// GetSuccessors returns a snapshot of all successor nodes keyed by their action strings.
 // This is primarily used internally by Flow for traversal.
 func (n *Node) GetSuccessors() map[string]*Node {
-	return n.successors
+	n.edgesMu.RLock()
+	defer n.edgesMu.RUnlock()
+	successors := make(map[string]*Node, len(n.successors))
+	for action, next := range n.successors {
+		successors[action] = next
+	}
+	return successors
+}
+
+// successor returns the node registered for action, choosing among weighted
+// candidates when there are several
+func (n *Node) successor(action string) (*Node, bool) {
+	n.edgesMu.RLock()
+	defer n.edgesMu.RUnlock()
+	if candidates := n.weighted[action]; len(candidates) > 0 {
+		next := pickWeighted(candidates)
+		return next, next != nil
+	}
+	next, ok := n.successors[action]
+	return next, ok
+}
+
+// pickWeighted chooses a candidate at random in proportion to its weight,
+// returning nil if no candidate has a positive weight
+func pickWeighted(candidates []weightedEdge) *Node {
+	total := 0.0
+	for _, c := range candidates {
+		if c.weight > 0 {
+			total += c.weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	target := secureRandFloat64() * total
+	var last *Node
+	for _, c := range candidates {
+		if c.weight <= 0 {
+			continue
+		}
+		if target < c.weight {
+			return c.node
+		}
+		target -= c.weight
+		last = c.node
+	}
+	return last
+}
+
+// resolver returns the function registered with NextFunc, if any
+func (n *Node) resolver() func(*SharedState, string) *Node {
+	n.edgesMu.RLock()
+	defer n.edgesMu.RUnlock()
+	return n.nextFunc
+}
+
+// sortedSuccessors returns the node's successors, including every weighted
+// candidate, in lexical order of their actions
+func (n *Node) sortedSuccessors() []*Node {
+	n.edgesMu.RLock()
+	defer n.edgesMu.RUnlock()
+	actions := make([]string, 0, len(n.successors))
+	for action := range n.successors {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	successors := make([]*Node, 0, len(actions))
+	for _, action := range actions {
+		if candidates := n.weighted[action]; len(candidates) > 0 {
+			for _, c := range candidates {
+				successors = append(successors, c.node)
+			}
+			continue
+		}
+		successors = append(successors, n.successors[action])
+	}
+	return successors
+}
+
+// children returns the nodes reachable in one step: fan-in branches first, then successors
+func (n *Node) children() []*Node {
+	successors := n.sortedSuccessors()
+	if n.fanIn == nil {
+		return successors
+	}
+	return append(append([]*Node(nil), n.fanIn.branches...), successors...)
 }
 
 // SetExecFunc sets the user's business logic function
 func (n *Node) SetExecFunc(fn func(interface{}) (interface{}, error)) {
-	n.execFunc = fn
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(_ context.Context, _ *SharedState, input interface{}, _ ItemMeta) (interface{}, error) {
+		return fn(input)
+	}
+}
+
+// SetBatchExecFunc sets a business logic function that also receives metadata
+// about the item being processed (index, total count, and attempt numbers).
+// It replaces any previously set exec function. Outside of batch mode the prep
+// result is passed as the item with index 0 and total 1.
+//
+// Example:
+//
+//	node.SetBatchExecFunc(func(item interface{}, meta flow.ItemMeta) (interface{}, error) {
+//		log.Printf("processing %d/%d (attempt %d)", meta.Index+1, meta.Total, meta.Attempt)
+//		return process(item)
+//	})
+func (n *Node) SetBatchExecFunc(fn func(interface{}, ItemMeta) (interface{}, error)) {
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(_ context.Context, _ *SharedState, input interface{}, meta ItemMeta) (interface{}, error) {
+		return fn(input, meta)
+	}
+}
+
+// SetExecFuncWithState sets a business logic function that receives the
+// SharedState of the current run alongside the prep result (or batch item).
+// It replaces any previously set exec function. Unlike capturing the state in a
+// closure, this keeps the node reusable across runs with different states.
+//
+// Example:
+//
+//	node.SetExecFuncWithState(func(state *flow.SharedState, prep interface{}) (interface{}, error) {
+//		history := state.GetSlice("history")
+//		return reply(history, prep.(string))
+//	})
+func (n *Node) SetExecFuncWithState(fn func(*SharedState, interface{}) (interface{}, error)) {
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(_ context.Context, shared *SharedState, input interface{}, _ ItemMeta) (interface{}, error) {
+		return fn(shared, input)
+	}
+}
+
+// SetExecFuncWithContext sets a business logic function that receives the
+// context of the call, so it can stop early when the call is abandoned: the ctx
+// is canceled when the run started with RunContext is canceled, and when a
+// hedged call ("hedge_after") loses to the other attempt. It replaces any
+// previously set exec function.
+//
+// Example:
+//
+//	node.SetExecFuncWithContext(func(ctx context.Context, prep interface{}) (interface{}, error) {
+//		req, _ := http.NewRequestWithContext(ctx, "GET", prep.(string), nil)
+//		return http.DefaultClient.Do(req)
+//	})
+func (n *Node) SetExecFuncWithContext(fn func(context.Context, interface{}) (interface{}, error)) {
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(ctx context.Context, _ *SharedState, input interface{}, _ ItemMeta) (interface{}, error) {
+		return fn(ctx, input)
+	}
+}
+
+// SetExecFuncWithParams sets a business logic function that receives the node's
+// parameters alongside the prep result (or batch item), so it can be defined as
+// a plain package-level function. It replaces any previously set exec function.
+// ParamFunc values are computed for the current run, SecretRef param values are
+// replaced by their secrets from the shared state, and "${state.key}"
+// placeholders in string params are interpolated (see Interpolate).
+//
+// Example:
+//
+//	func fetch(params flow.Params, prep interface{}) (interface{}, error) {
+//		return http.Get(params.String("url"))
+//	}
+//
+//	node.SetExecFuncWithParams(fetch)
+func (n *Node) SetExecFuncWithParams(fn func(Params, interface{}) (interface{}, error)) {
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(ctx context.Context, shared *SharedState, input interface{}, _ ItemMeta) (interface{}, error) {
+		return fn(n.runParams(ctx).withSecrets(shared).withState(shared), input)
+	}
 }
 
 // SetPrepFunc sets optional preparation function
 func (n *Node) SetPrepFunc(fn func(*SharedState) interface{}) {
-	n.prepFunc = fn
+	if fn == nil {
+		n.prepFunc = nil
+		return
+	}
+	n.prepFunc = func(_ context.Context, shared *SharedState) interface{} {
+		return fn(shared)
+	}
 }
 
 // SetPostFunc sets optional post-processing function
 func (n *Node) SetPostFunc(fn func(*SharedState, interface{}, interface{}) string) {
-	n.postFunc = fn
+	if fn == nil {
+		n.postFunc = nil
+		return
+	}
+	n.postFunc = func(_ context.Context, shared *SharedState, prep, exec interface{}) string {
+		return fn(shared, prep, exec)
+	}
+}
+
+// PostErrorKey is the SharedState key under which a node routed by
+// "post_error_action" stores its post error. Named nodes use PostErrorKey + ":" +
+// name, as with RetryTelemetryKey.
+const PostErrorKey = "post_error"
+
+// SetPostFuncWithError sets a post-processing function that can fail, e.g. while
+// persisting results, instead of having to panic or swallow the problem. A
+// returned error fails the node like an exec error, raised as a *NodeError, or,
+// when the "post_error_action" param is set, is stored under PostErrorKey and
+// routes to that action. The action is ignored when the error is non-nil. It
+// replaces any previously set post function.
+//
+// Example:
+//
+//	node.SetPostFuncWithError(func(shared *flow.SharedState, prep, exec interface{}) (string, error) {
+//		if err := db.Save(exec); err != nil {
+//			return "", err
+//		}
+//		return "saved", nil
+//	})
+//	node.SetParams(map[string]interface{}{"post_error_action": "save_failed"})
+//	node.Next(alert, "save_failed")
+func (n *Node) SetPostFuncWithError(fn func(*SharedState, interface{}, interface{}) (string, error)) {
+	if fn == nil {
+		n.postFunc = nil
+		return
+	}
+	n.postFunc = func(ctx context.Context, shared *SharedState, prep, exec interface{}) string {
+		action, err := fn(shared, prep, exec)
+		if err == nil {
+			return action
+		}
+		if errorAction := n.getStringParam(ctx, "post_error_action"); errorAction != "" {
+			shared.Set(postErrorKey(n.name), err)
+			return errorAction
+		}
+		panic(err)
+	}
+}
+
+// postErrorKey returns the state key holding the post error of the named node
+func postErrorKey(name string) string {
+	if name == "" {
+		return PostErrorKey
+	}
+	return PostErrorKey + ":" + name
+}
+
+// SetCleanupFunc sets an optional teardown function that is guaranteed to run
+// after exec/post, whether the node succeeded, exhausted its retries, or
+// panicked. It receives the failure as an error (nil on success), which makes it
+// the place to release connections, temp files, and locks acquired in prep.
+// Failures still propagate after cleanup runs.
+//
+// Example:
+//
+//	node.SetPrepFunc(func(shared *flow.SharedState) interface{} {
+//		conn := pool.Get()
+//		shared.Set("conn", conn)
+//		return conn
+//	})
+//	node.SetCleanupFunc(func(shared *flow.SharedState, err error) {
+//		shared.Get("conn").(*Conn).Close()
+//	})
+func (n *Node) SetCleanupFunc(fn func(*SharedState, error)) {
+	n.cleanupFunc = fn
 }
 
 // Run executes the node with adaptive behavior based on parameters
 func (n *Node) Run(shared *SharedState) string {
+	return n.run(context.Background(), shared)
+}
+
+// run executes the node under profiling labels derived from ctx and records its statistics
+func (n *Node) run(ctx context.Context, shared *SharedState) string {
+	start := time.Now()
+	completed := false
+	addGauge(GaugeInFlightNodes, n.name, 1)
+	defer func() {
+		addGauge(GaugeInFlightNodes, n.name, -1)
+		n.stats.record(time.Since(start), !completed)
+	}()
+	if n.cleanupFunc != nil {
+		defer n.cleanup(shared)
+	}
+	defer n.wrapPanic(ctx)
+	ctx = n.bindParams(ctx, shared)
+
+	n.emit(ctx, Event{Type: EventNodeStarted})
+
+	var action string
+	n.profile(ctx, func() {
+		action = n.dispatch(ctx, shared)
+	})
+	completed = true
+
+	n.emit(ctx, Event{Type: EventActionChosen, Action: action})
+	return action
+}
+
+// cleanup runs the user's cleanup function with the in-flight failure (if any),
+// then lets the failure continue propagating
+func (n *Node) cleanup(shared *SharedState) {
+	r := recover()
+	n.cleanupFunc(shared, panicToError(r))
+	if r != nil {
+		panic(r)
+	}
+}
+
+// panicToError converts a recovered panic value into an error (nil for no panic)
+func panicToError(r interface{}) error {
+	if r == nil {
+		return nil
+	}
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", r)
+}
+
+// dispatch selects the execution pattern from the node's parameters
+func (n *Node) dispatch(ctx context.Context, shared *SharedState) string {
+	// Debounced calls are suppressed and throttled calls wait their turn
+	if !n.admit(ctx) {
+		return SuppressedAction
+	}
+
+	// RunBatch supplies its items directly
+	if items, ctx, ok := batchItemsFrom(ctx); ok {
+		return n.runBatch(ctx, shared, items)
+	}
+
+	// Run fan-in entry branches to completion before this node's own lifecycle
+	if n.fanIn != nil {
+		n.fanIn.run(ctx, shared)
+	}
+
+	// Channel sources feed each received value through their item subgraph
+	if n.source != nil {
+		return n.runSource(ctx, shared)
+	}
+
+	// Sinks write a state value to their output
+	if n.sink != nil {
+		return n.runSink(ctx, shared)
+	}
+
+	// Map nodes always batch over the items stored in shared state
+	if n.mapKey != "" {
+		items := shared.Get(n.mapKey)
+		if items == nil {
+			items = []interface{}{}
+		}
+		return n.runBatch(ctx, shared, items)
+	}
+
 	// Check for batch processing first
-	if n.getBoolParam("batch") {
-		if data := n.GetParam("data"); data != nil {
-			return n.runBatch(shared, data)
+	if n.getBoolParam(ctx, "batch") {
+		if data := n.param(ctx, "data"); data != nil {
+			return n.runBatch(ctx, shared, data)
+		}
+		// Items produced by an upstream node are read from state at run time
+		if key := n.getStringParam(ctx, "data_key"); key != "" {
+			data := shared.Get(key)
+			if data == nil {
+				data = []interface{}{}
+			}
+			return n.runBatch(ctx, shared, data)
 		}
 		// If batch: true but no data, fall through to single execution
 	}
 
 	// Check for retry behavior
-	if retries := n.getIntParam("retries"); retries > 0 {
-		return n.runWithRetry(shared, retries)
+	if retries := n.getIntParam(ctx, "retries"); retries > 0 {
+		return n.runWithRetry(ctx, shared, retries)
 	}
 
 	// Default single execution
-	return n.runSingle(shared)
+	return n.runSingle(ctx, shared)
 }
 
 // runSingle executes the basic prep -> exec -> post lifecycle
-func (n *Node) runSingle(shared *SharedState) string {
+func (n *Node) runSingle(ctx context.Context, shared *SharedState) string {
 	// Prep phase
 	var prepResult interface{}
 	if n.prepFunc != nil {
-		prepResult = n.prepFunc(shared)
+		prepResult = n.callPrep(ctx, shared)
 	}
 
 	// Exec phase
 	var execResult interface{} = DefaultAction
 	if n.execFunc != nil {
-		result, err := n.execFunc(prepResult)
+		result, err := n.execWithRetry(ctx, shared, prepResult, ItemMeta{Total: 1}, retryPolicy{}, nil)
+		if n.degraded(ctx, err) {
+			return ShedAction
+		}
 		if err != nil {
 			panic(err) // Match Python behavior
 		}
@@ -191,7 +767,7 @@ func (n *Node) runSingle(shared *SharedState) string {
 
 	// Post phase
 	if n.postFunc != nil {
-		return n.postFunc(shared, prepResult, execResult)
+		return n.callPost(ctx, shared, prepResult, execResult)
 	}
 
 	// Convert result to string
@@ -201,49 +777,104 @@ func (n *Node) runSingle(shared *SharedState) string {
 	return fmt.Sprintf("%v", execResult)
 }
 
+// callExec invokes the user's exec function for one attempt, hedging the call
+// when "hedge_after" is set
+func (n *Node) callExec(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta) (interface{}, error) {
+	if hedgeAfter := n.getDurationParam(ctx, "hedge_after"); hedgeAfter > 0 {
+		return n.callHedged(ctx, shared, input, meta, hedgeAfter)
+	}
+	return n.callGuarded(ctx, shared, input, meta)
+}
+
+// callGuarded invokes the user's exec function, consulting the node's shared circuit
+// breaker and holding a slot of its shared limiter (if any) for the duration of the call
+func (n *Node) callGuarded(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta) (interface{}, error) {
+	if name := n.getStringParam(ctx, "breaker"); name != "" {
+		breaker := lookupBreaker(name)
+		if breaker == nil {
+			panic(fmt.Errorf("flow: circuit breaker %q is not registered", name))
+		}
+		if err := breaker.allow(); err != nil {
+			return nil, err
+		}
+		defer func() {
+			// A panic counts as a failure, so a panicking probe can't leave the
+			// breaker half-open with its probe outstanding
+			if r := recover(); r != nil {
+				breaker.record(fmt.Errorf("flow: panic in exec: %v", r))
+				panic(r)
+			}
+		}()
+		result, err := n.callLimited(ctx, shared, input, meta)
+		breaker.record(err)
+		return result, err
+	}
+	return n.callLimited(ctx, shared, input, meta)
+}
+
+// callLimited invokes the user's exec function while holding a slot of the node's
+// bulkhead, of its priority scheduler, of each of its semaphores and of its shared concurrency
+// limiter, or after waiting for its shared rate limiter
+func (n *Node) callLimited(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta) (result interface{}, err error) {
+	if b := n.bulkhead(ctx); b != nil {
+		release, err := b.enter(ctx, n.getStringParam(ctx, "bulkhead_shed"))
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	release, err := n.schedule(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if release != nil {
+		defer release()
+	}
+	release, err = acquireAll(ctx, n.semaphores(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if rate := lookupRateLimiter(n.getStringParam(ctx, "limiter")); rate != nil {
+		timeWait(rate.Name(), func() { err = rate.WaitContext(ctx) })
+		if err != nil {
+			return nil, err
+		}
+	}
+	if n.getBoolParam(ctx, "recover_panics") {
+		defer n.recoverInto("exec", &err)
+	}
+	return n.execFunc(ctx, shared, input, meta)
+}
+
 // runWithRetry wraps execution with retry logic when retries > 0
-func (n *Node) runWithRetry(shared *SharedState, maxRetries int) string {
-	retryDelay := n.getDurationParam("retry_delay")
+func (n *Node) runWithRetry(ctx context.Context, shared *SharedState, maxRetries int) string {
+	policy := n.retryPolicy(ctx)
+	rec := newRetryRecorder(maxRetries)
 
 	// Prep phase (once)
 	var prepResult interface{}
 	if n.prepFunc != nil {
-		prepResult = n.prepFunc(shared)
+		prepResult = n.callPrep(ctx, shared)
 	}
 
 	// Retry loop around exec phase
 	var execResult interface{} = DefaultAction
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if n.execFunc != nil {
-			result, err := n.execFunc(prepResult)
-			if err == nil {
-				execResult = result
-				break
-			}
-
-			// Calculate exponential backoff with jitter for next attempt
-			if attempt < maxRetries-1 && retryDelay > 0 {
-				// Exponential backoff: retry_delay * (2^attempt) + jitter
-				backoffDelay := time.Duration(float64(retryDelay) * math.Pow(2, float64(attempt)))
-				// Add jitter (up to 10% of the backoff delay)
-				jitter := time.Duration(secureRandFloat64() * float64(backoffDelay) * 0.1)
-				totalDelay := backoffDelay + jitter
-				time.Sleep(totalDelay)
-			}
-
-			// Last attempt failed
-			if attempt == maxRetries-1 {
-				panic(err)
-			}
-		} else {
-			execResult = DefaultAction
-			break
+	if n.execFunc != nil {
+		result, err := n.execWithRetry(ctx, shared, prepResult, ItemMeta{Total: 1}, policy, rec)
+		rec.store(shared, n.name)
+		if n.degraded(ctx, err) {
+			return ShedAction
+		}
+		if err != nil {
+			panic(err)
 		}
+		execResult = result
 	}
 
 	// Post phase
 	if n.postFunc != nil {
-		return n.postFunc(shared, prepResult, execResult)
+		return n.callPost(ctx, shared, prepResult, execResult)
 	}
 
 	// Convert result to string
@@ -253,149 +884,257 @@ func (n *Node) runWithRetry(shared *SharedState, maxRetries int) string {
 	return fmt.Sprintf("%v", execResult)
 }
 
-// runBatch processes data by calling exec once per item
-func (n *Node) runBatch(shared *SharedState, data interface{}) string {
+// runBatch processes data by calling exec once per item.
+// Once ctx is done no further items are started and the node panics with an
+// error wrapping ctx.Err().
+func (n *Node) runBatch(ctx context.Context, shared *SharedState, data interface{}) string {
+	data = n.preprocessItems(ctx, data)
+
 	// Check for parallel processing
-	if n.getBoolParam("parallel") {
-		return n.runBatchParallel(shared, data)
+	if n.getBoolParam(ctx, "parallel") {
+		return n.runBatchParallel(ctx, shared, data)
 	}
 
 	// Sequential batch processing
-	return n.runBatchSequential(shared, data)
+	return n.runBatchSequential(ctx, shared, data)
+}
+
+// batchInterrupted describes a batch stopped because ctx is done
+func batchInterrupted(ctx context.Context, completed, total int) error {
+	return fmt.Errorf("flow: batch interrupted after %d of %d items: %w", completed, total, ctx.Err())
+}
+
+// execItem runs one sequential batch item, counting it as in flight meanwhile
+func (n *Node) execItem(ctx context.Context, shared *SharedState, item interface{}, meta ItemMeta, policy retryPolicy, rec *retryRecorder) (interface{}, error) {
+	addGauge(GaugeInFlightItems, n.name, 1)
+	defer addGauge(GaugeInFlightItems, n.name, -1)
+	return n.execWithRetry(ctx, shared, item, meta, policy, rec)
 }
 
 // runBatchSequential processes items one by one
-func (n *Node) runBatchSequential(shared *SharedState, data interface{}) string {
+func (n *Node) runBatchSequential(ctx context.Context, shared *SharedState, data interface{}) string {
 	items := n.convertToSlice(data)
 	results := make([]interface{}, 0, len(items))
-	retries := n.getIntParam("retries")
-	retryDelay := n.getDurationParam("retry_delay")
+	policy := n.retryPolicy(ctx)
+	rec := newRetryRecorder(policy.retries)
+	meta := n.newBatchMetaRecorder(ctx, len(items))
+	progress := n.batchProgress(ctx, shared)
+	checkpoints := n.newBatchCheckpointer(ctx, len(items))
+	outcomes := n.newBatchOutcomes(ctx, len(items))
+	failed := false
 
-	for _, item := range items {
+	for i, item := range items {
 		if n.execFunc == nil {
 			continue
 		}
-
-		var result interface{}
-		var err error
+		if result, done := progress.result(i); done {
+			outcomes.record(i, nil, result, nil)
+			checkpoints.done(shared, i, result)
+			results = append(results, result)
+			continue
+		}
+		if ctx.Err() != nil {
+			rec.store(shared, n.name)
+			meta.store(shared)
+			panic(batchInterrupted(ctx, i, len(items)))
+		}
 
 		// Apply retry logic if configured
-		if retries > 0 {
-			for attempt := 0; attempt < retries; attempt++ {
-				result, err = n.execFunc(item)
-				if err == nil {
-					break
-				}
-				if attempt < retries-1 && retryDelay > 0 {
-					// Exponential backoff: retry_delay * (2^attempt) + jitter
-					backoffDelay := time.Duration(float64(retryDelay) * math.Pow(2, float64(attempt)))
-					// Add jitter (up to 10% of the backoff delay)
-					jitter := time.Duration(secureRandFloat64() * float64(backoffDelay) * 0.1)
-					totalDelay := backoffDelay + jitter
-					time.Sleep(totalDelay)
-				}
-			}
-		} else {
-			result, err = n.execFunc(item)
+		started := time.Now()
+		itemRec := outcomes.itemRecorder(meta.itemRecorder(rec))
+		result, err := n.execItem(ctx, shared, item, ItemMeta{Index: i, Total: len(items)}, policy, itemRec)
+		meta.record(i, 0, started, itemRec, err)
+		if aborted(ctx, err) {
+			rec.store(shared, n.name)
+			meta.store(shared)
+			panic(batchInterrupted(ctx, i, len(items)))
+		}
+		outcomes.record(i, itemRec, result, err)
+		n.emit(ctx, Event{Type: EventBatchItemDone, Index: i, Elapsed: time.Since(started), Result: result, Err: err})
+		if err != nil && outcomes != nil {
+			// Failed items keep a nil result and are retried when the batch resumes
+			failed = true
+			results = append(results, nil)
+			continue
 		}
-
 		if err != nil {
-			panic(err)
+			rec.store(shared, n.name)
+			meta.store(shared)
+			panic(batchFailure(err))
 		}
+		progress.complete(i, result)
+		checkpoints.done(shared, i, result)
 		results = append(results, result)
 	}
+	rec.store(shared, n.name)
+	meta.store(shared)
+	outcomes.store(shared)
+	if !failed {
+		n.clearProgress(ctx, shared)
+	}
 
 	// Store results in shared state
-	shared.Set("batch_results", results)
+	n.storeResults(ctx, shared, results)
 	return BatchCompleteAction
 }
 
-// runBatchParallel processes items concurrently
-func (n *Node) runBatchParallel(shared *SharedState, data interface{}) string {
+// runBatchParallel processes items concurrently on up to "parallel_limit" workers,
+// parking items between retries instead of sleeping
+func (n *Node) runBatchParallel(ctx context.Context, shared *SharedState, data interface{}) string {
 	items := n.convertToSlice(data)
-	parallelLimit := n.getIntParam("parallel_limit")
+	parallelLimit := n.getIntParam(ctx, "parallel_limit")
 	if parallelLimit <= 0 {
 		parallelLimit = len(items) // No limit
 	}
-	retries := n.getIntParam("retries")
-	retryDelay := n.getDurationParam("retry_delay")
+	policy := n.retryPolicy(ctx)
+	rec := newRetryRecorder(policy.retries)
+
+	meta := n.newBatchMetaRecorder(ctx, len(items))
+	progress := n.batchProgress(ctx, shared)
+	checkpoints := n.newBatchCheckpointer(ctx, len(items))
+	outcomes := n.newBatchOutcomes(ctx, len(items))
 
 	results := make([]interface{}, len(items))
-	sem := make(chan struct{}, parallelLimit)
-	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	var completed, failed int64
 
-	for i, item := range items {
-		wg.Add(1)
-		go func(index int, data interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}        // Acquire semaphore
-			defer func() { <-sem }() // Release semaphore
-
-			if n.execFunc != nil {
-				var result interface{}
-				var err error
-
-				// Apply retry logic if configured
-				if retries > 0 {
-					for attempt := 0; attempt < retries; attempt++ {
-						result, err = n.execFunc(data)
-						if err == nil {
-							break
-						}
-						if attempt < retries-1 && retryDelay > 0 {
-							// Exponential backoff: retry_delay * (2^attempt) + jitter
-							backoffDelay := time.Duration(float64(retryDelay) * math.Pow(2, float64(attempt)))
-							// Add jitter (up to 10% of the backoff delay)
-							jitter := time.Duration(secureRandFloat64() * float64(backoffDelay) * 0.1)
-							totalDelay := backoffDelay + jitter
-							time.Sleep(totalDelay)
-						}
-					}
-				} else {
-					result, err = n.execFunc(data)
-				}
-
-				if err != nil {
-					panic(err)
-				}
-				results[index] = result
+	// Items completed by an earlier run are not started again
+	for i := range items {
+		if result, done := progress.result(i); done {
+			outcomes.record(i, nil, result, nil)
+			checkpoints.done(shared, i, result)
+			results[i] = result
+			completed++
+		}
+	}
+	resumed := func(index int) bool {
+		_, done := progress.result(index)
+		return done
+	}
+
+	finish := func(worker int, item *batchItem) {
+		index, result, err := item.index, item.call.result, item.call.err
+		addGauge(GaugeInFlightItems, n.name, -1)
+		meta.record(index, worker, item.started, item.rec, err)
+		if aborted(ctx, err) {
+			// Leave the item uncompleted so the batch reports the interruption
+			return
+		}
+		outcomes.record(index, item.rec, result, err)
+		n.emit(ctx, Event{Type: EventBatchItemDone, Index: index, Elapsed: time.Since(item.started), Result: result, Err: err})
+		if err != nil && outcomes != nil {
+			// Failed items keep a nil result and are retried when the batch resumes
+			atomic.AddInt64(&failed, 1)
+			atomic.AddInt64(&completed, 1)
+			return
+		}
+		if err != nil {
+			// Surface the failure on the calling goroutine after all items finish
+			errOnce.Do(func() { firstErr = err })
+			return
+		}
+		progress.complete(index, result)
+		checkpoints.done(shared, index, result)
+		results[index] = result
+		atomic.AddInt64(&completed, 1)
+	}
+
+	// Workers step items one attempt at a time, parking them between retries
+	work := func(q *batchQueue, worker int, item *batchItem, index int) {
+		if item == nil {
+			if n.execFunc == nil {
+				atomic.AddInt64(&completed, 1)
+				return
 			}
-		}(i, item)
+			// Apply retry logic if configured
+			itemRec := outcomes.itemRecorder(meta.itemRecorder(rec))
+			item = &batchItem{index: index, started: time.Now(), rec: itemRec}
+			addGauge(GaugeInFlightItems, n.name, 1)
+			item.call = n.newExecCall(ctx, shared, items[index], ItemMeta{Index: index, Total: len(items)}, policy, itemRec)
+		}
+		if ctx.Err() != nil && !item.call.done {
+			// A parked item woken because ctx is done abandons its backoff
+			item.call.abort()
+		}
+		for !item.call.done {
+			attempted := time.Now()
+			wait := item.call.step()
+			q.observe(attempted, item.call.err, max(wait, n.breakerCooldown(ctx, item.call.err)))
+			if wait > 0 {
+				q.park(item, wait)
+				return
+			}
+		}
+		finish(worker, item)
+	}
+
+	// Items still waiting for a worker are not started once ctx is done
+	queue := newBatchQueue(ctx, len(items), parallelLimit, resumed, work)
+	if n.weightFunc != nil {
+		weights := make([]int, len(items))
+		for i, item := range items {
+			weights[i] = n.weightFunc(item)
+		}
+		queue.weighted(weights)
 	}
+	if n.getBoolParam(ctx, "adaptive_concurrency") {
+		queue.adapt(newAdaptiveLimit(n.name, parallelLimit, n.getDurationParam(ctx, "adaptive_latency")))
+	}
+	if n.getBoolParam(ctx, "coordinated_backoff") {
+		queue.coordinate()
+	}
+	if n.getBoolParam(ctx, "deterministic") {
+		queue.deterministic(int64(n.getIntParam(ctx, "seed")))
+	}
+	queue.run()
+	rec.store(shared, n.name)
+	meta.store(shared)
+	outcomes.store(shared)
 
-	wg.Wait()
+	if firstErr != nil {
+		panic(batchFailure(firstErr))
+	}
+	if int(completed) < len(items) && ctx.Err() != nil {
+		panic(batchInterrupted(ctx, int(completed), len(items)))
+	}
+	if failed == 0 {
+		n.clearProgress(ctx, shared)
+	}
 
 	// Store results in shared state
-	shared.Set("batch_results", results)
+	n.storeResults(ctx, shared, results)
 	return BatchCompleteAction
 }
 
-// Helper methods for parameter extraction
-func (n *Node) getIntParam(key string) int {
-	if val := n.GetParam(key); val != nil {
-		if i, ok := val.(int); ok {
-			return i
-		}
-	}
-	return 0
+// Helper methods for parameter extraction in the run of ctx
+func (n *Node) getIntParam(ctx context.Context, key string) int {
+	return n.runParams(ctx).Int(key)
 }
 
-func (n *Node) getBoolParam(key string) bool {
-	if val := n.GetParam(key); val != nil {
-		if b, ok := val.(bool); ok {
-			return b
-		}
-	}
-	return false
+func (n *Node) getBoolParam(ctx context.Context, key string) bool {
+	return n.runParams(ctx).Bool(key)
 }
 
-func (n *Node) getDurationParam(key string) time.Duration {
-	if val := n.GetParam(key); val != nil {
-		if d, ok := val.(time.Duration); ok {
-			return d
-		}
+func (n *Node) getStringParam(ctx context.Context, key string) string {
+	return n.runParams(ctx).String(key)
+}
+
+// getFloatParam returns a numeric parameter as float64 and whether it was set
+func (n *Node) getFloatParam(ctx context.Context, key string) (float64, bool) {
+	switch v := n.param(ctx, key).(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
 	}
-	return 0
+	return 0, false
+}
+
+func (n *Node) getDurationParam(ctx context.Context, key string) time.Duration {
+	return n.runParams(ctx).Duration(key)
 }
 
 // convertToSlice handles different slice types
@@ -1,6 +1,7 @@
 package Flow
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -479,6 +480,405 @@ func TestEdgeCases(t *testing.T) {
 	})
 }
 
+// TestBatchExecFuncMetadata tests that batch exec receives index, total, and attempt
+func TestBatchExecFuncMetadata(t *testing.T) {
+	state := NewSharedState()
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":     []string{"a", "b", "c"},
+		"batch":    true,
+		"parallel": true,
+		"retries":  2,
+	})
+
+	var mu sync.Mutex
+	attempts := make(map[int]int)
+	node.SetBatchExecFunc(func(item interface{}, meta ItemMeta) (interface{}, error) {
+		mu.Lock()
+		attempts[meta.Index] = meta.Attempt
+		mu.Unlock()
+
+		if meta.Total != 3 {
+			return nil, fmt.Errorf("unexpected total %d", meta.Total)
+		}
+		if meta.Index == 1 && meta.Attempt == 1 {
+			return nil, fmt.Errorf("first attempt fails for %s", item)
+		}
+		return fmt.Sprintf("%d:%s", meta.Index, item), nil
+	})
+
+	node.Run(state)
+
+	results := state.Get("batch_results").([]interface{})
+	expected := []string{"0:a", "1:b", "2:c"}
+	for i, result := range results {
+		if result.(string) != expected[i] {
+			t.Errorf("Expected '%s', got '%v'", expected[i], result)
+		}
+	}
+	if attempts[1] != 2 {
+		t.Errorf("Expected item 1 to succeed on attempt 2, got %d", attempts[1])
+	}
+	if attempts[0] != 1 || attempts[2] != 1 {
+		t.Errorf("Expected items 0 and 2 to succeed on attempt 1, got %d and %d", attempts[0], attempts[2])
+	}
+}
+
+// TestBatchDataKey tests that batch data is read from state when the node runs
+func TestBatchDataKey(t *testing.T) {
+	producer := NewNode()
+	producer.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("urls", []string{"a", "b"})
+		return "default"
+	})
+	fetch := NewNode()
+	fetch.SetParams(map[string]interface{}{"batch": true, "data_key": "urls"})
+	fetch.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return "got " + item.(string), nil
+	})
+
+	state := NewSharedState()
+	producer.Run(state)
+	fetch.Run(state)
+	results := state.GetSlice(BatchResultsKey)
+	if len(results) != 2 || results[0] != "got a" || results[1] != "got b" {
+		t.Errorf("Expected the produced items to be processed, got %v", results)
+	}
+
+	state = NewSharedState()
+	if action := fetch.Run(state); action != BatchCompleteAction || len(state.GetSlice(BatchResultsKey)) != 0 {
+		t.Errorf("Expected a missing key to be an empty batch, got %q", action)
+	}
+}
+
+// TestExecFuncWithState tests that exec receives the state of the current run
+func TestExecFuncWithState(t *testing.T) {
+	node := NewNode()
+	node.SetPrepFunc(func(shared *SharedState) interface{} {
+		return shared.GetInt("input")
+	})
+	node.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		shared.Set("output", prep.(int)*2)
+		return "done", nil
+	})
+
+	// The same node is reusable across independent states
+	for _, input := range []int{3, 5} {
+		state := NewSharedState()
+		state.Set("input", input)
+
+		if result := node.Run(state); result != "done" {
+			t.Errorf("Expected 'done', got '%s'", result)
+		}
+		if state.GetInt("output") != input*2 {
+			t.Errorf("Expected output %d, got %d", input*2, state.GetInt("output"))
+		}
+	}
+}
+
+// greetWithParams is a package-level exec function used by TestExecFuncWithParams
+func greetWithParams(params Params, prep interface{}) (interface{}, error) {
+	return fmt.Sprintf("%s, %s!", params.String("greeting"), params.String("name")), nil
+}
+
+// TestExecFuncWithParams tests that exec receives the node's params
+func TestExecFuncWithParams(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"greeting": "Hello",
+		"name":     "World",
+	})
+	node.SetExecFuncWithParams(greetWithParams)
+
+	if result := node.Run(NewSharedState()); result != "Hello, World!" {
+		t.Errorf("Expected 'Hello, World!', got '%s'", result)
+	}
+}
+
+// TestCleanupFunc tests that cleanup runs on success, retry exhaustion, and panics
+func TestCleanupFunc(t *testing.T) {
+	cases := []struct {
+		name    string
+		exec    func(interface{}) (interface{}, error)
+		wantErr string
+	}{
+		{"Success", func(interface{}) (interface{}, error) { return "ok", nil }, ""},
+		{"RetriesExhausted", func(interface{}) (interface{}, error) { return nil, fmt.Errorf("exhausted") }, "exhausted"},
+		{"Panic", func(interface{}) (interface{}, error) { panic("boom") }, "panic: boom"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := NewNode()
+			node.SetParams(map[string]interface{}{"retries": 2})
+			node.SetExecFunc(tc.exec)
+
+			cleaned := false
+			var cleanupErr error
+			node.SetCleanupFunc(func(shared *SharedState, err error) {
+				cleaned = true
+				cleanupErr = err
+			})
+
+			func() {
+				defer func() {
+					r := recover()
+					if (r != nil) != (tc.wantErr != "") {
+						t.Errorf("Unexpected panic state: %v", r)
+					}
+				}()
+				node.Run(NewSharedState())
+			}()
+
+			if !cleaned {
+				t.Fatal("Expected cleanup to run")
+			}
+			if tc.wantErr == "" && cleanupErr != nil {
+				t.Errorf("Expected nil error, got %v", cleanupErr)
+			}
+			if tc.wantErr != "" && (cleanupErr == nil || (cleanupErr.Error() != tc.wantErr && nodeErrorCause(cleanupErr) != tc.wantErr)) {
+				t.Errorf("Expected error '%s', got %v", tc.wantErr, cleanupErr)
+			}
+		})
+	}
+}
+
+// TestNextFunc tests runtime successor resolution with fallback to static edges
+func TestNextFunc(t *testing.T) {
+	var visited []string
+	visit := func(name, action string) *Node {
+		node := NewNode()
+		node.SetName(name)
+		node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+			visited = append(visited, name)
+			return action, nil
+		})
+		return node
+	}
+
+	planner := visit("planner", "plan")
+	search := visit("search", "done")
+	fallback := visit("fallback", "done")
+	tools := map[string]*Node{"search": search}
+
+	planner.Next(fallback, "plan")
+	planner.NextFunc(func(shared *SharedState, action string) *Node {
+		if action != "plan" {
+			t.Errorf("Expected action 'plan', got '%s'", action)
+		}
+		return tools[shared.Get("tool").(string)]
+	})
+
+	pipeline := NewFlow().Start(planner)
+
+	state := NewSharedState()
+	state.Set("tool", "search")
+	pipeline.Run(state)
+
+	state.Set("tool", "unknown")
+	pipeline.Run(state)
+
+	expected := []string{"planner", "search", "planner", "fallback"}
+	if fmt.Sprint(visited) != fmt.Sprint(expected) {
+		t.Errorf("Expected visits %v, got %v", expected, visited)
+	}
+}
+
+// TestGraphMutationDuringRun tests that nodes can grow and prune the graph mid-run
+func TestGraphMutationDuringRun(t *testing.T) {
+	planner := NewNode()
+	planner.SetName("planner")
+	planner.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		if shared.GetInt("steps") >= 3 {
+			return "finish"
+		}
+
+		// Grow the plan by one step that loops back to the planner
+		step := NewNode()
+		step.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+			shared.Set("steps", shared.GetInt("steps")+1)
+			return "replan"
+		})
+		step.Next(planner, "replan")
+		planner.Next(step, "step")
+		return "step"
+	})
+
+	pipeline := NewFlow().Start(planner)
+
+	// Mutations from another goroutine must not race with traversal
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		extra := NewNode()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				planner.Next(extra, "noise")
+				planner.RemoveNext("noise")
+			}
+		}
+	}()
+
+	state := NewSharedState()
+	action := pipeline.Run(state)
+	close(stop)
+	wg.Wait()
+
+	if action != "finish" {
+		t.Errorf("Expected action 'finish', got '%s'", action)
+	}
+	if state.GetInt("steps") != 3 {
+		t.Errorf("Expected 3 steps, got %d", state.GetInt("steps"))
+	}
+
+	planner.RemoveNext("step")
+	if _, exists := planner.GetSuccessors()["step"]; exists {
+		t.Error("Expected step edge to be removed")
+	}
+}
+
+// TestStartAll tests concurrent entry branches joined before the flow continues
+func TestStartAll(t *testing.T) {
+	branch := func(key string) *Node {
+		node := NewNode()
+		node.SetName(key)
+		node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+			time.Sleep(time.Millisecond * 30)
+			return "loaded", nil
+		})
+		node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+			shared.Set(key, exec)
+			return "done"
+		})
+		return node
+	}
+
+	handle := NewNode()
+	handle.SetPrepFunc(func(shared *SharedState) interface{} {
+		return []interface{}{shared.Get("config"), shared.Get("cache"), shared.Get("user")}
+	})
+	handle.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		for _, v := range prep.([]interface{}) {
+			if v != "loaded" {
+				t.Errorf("Expected every branch to finish before the join, got %v", prep)
+			}
+		}
+		return "handled"
+	})
+
+	pipeline := NewFlow()
+	pipeline.StartAll(branch("config"), branch("cache"), branch("user")).Next(handle, DefaultAction)
+
+	start := time.Now()
+	action := pipeline.Run(NewSharedState())
+	if action != "handled" {
+		t.Errorf("Expected action 'handled', got '%s'", action)
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*80 {
+		t.Errorf("Expected branches to run concurrently, took %v", elapsed)
+	}
+
+	// A failing branch surfaces after the others finish
+	failing := NewNode()
+	failing.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("user service down")
+	})
+	pipeline.StartAll(branch("config"), failing)
+	defer func() {
+		if err, _ := recover().(error); nodeErrorCause(err) != "user service down" {
+			t.Errorf("Expected branch failure to propagate, got %v", err)
+		}
+	}()
+	pipeline.Run(NewSharedState())
+}
+
+// TestNextWeighted tests weighted selection among successors sharing an action
+func TestNextWeighted(t *testing.T) {
+	counts := map[string]int{}
+	branch := func(name string) *Node {
+		node := NewNode()
+		node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+			counts[name]++
+			return "done"
+		})
+		return node
+	}
+
+	ingest := NewNode()
+	ingest.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		return "parse"
+	})
+	ingest.NextWeighted(branch("stable"), "parse", 80)
+	ingest.NextWeighted(branch("canary"), "parse", 20)
+	ingest.NextWeighted(branch("disabled"), "parse", 0)
+
+	pipeline := NewFlow().Start(ingest)
+	for i := 0; i < 1000; i++ {
+		pipeline.Run(NewSharedState())
+	}
+
+	if counts["disabled"] != 0 {
+		t.Errorf("Expected zero-weight branch never to run, got %d", counts["disabled"])
+	}
+	if counts["canary"] < 120 || counts["canary"] > 280 {
+		t.Errorf("Expected roughly 20%% canary runs, got %d of 1000", counts["canary"])
+	}
+	if counts["stable"]+counts["canary"] != 1000 {
+		t.Errorf("Expected every run to route, got %v", counts)
+	}
+
+	// Next replaces every weighted candidate
+	only := branch("only")
+	ingest.Next(only, "parse")
+	pipeline.Run(NewSharedState())
+	if counts["only"] != 1 {
+		t.Errorf("Expected Next to replace weighted candidates, got %v", counts)
+	}
+}
+
+// TestPostFuncWithError tests that post errors fail the node or route to "post_error_action"
+func TestPostFuncWithError(t *testing.T) {
+	saveErr := errors.New("disk full")
+	node := NewNode()
+	node.SetName("save")
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return "result", nil })
+	node.SetPostFuncWithError(func(shared *SharedState, prep, exec interface{}) (string, error) {
+		return "saved", saveErr
+	})
+	alerted := false
+	alert := NewNode()
+	alert.SetExecFunc(func(interface{}) (interface{}, error) {
+		alerted = true
+		return "default", nil
+	})
+	node.Next(alert, "save_failed")
+
+	func() {
+		defer func() {
+			var nodeErr *NodeError
+			err, _ := recover().(error)
+			if !errors.As(err, &nodeErr) || nodeErr.Node != "save" || !errors.Is(err, saveErr) {
+				t.Errorf("Expected a NodeError wrapping the post error, got %v", err)
+			}
+		}()
+		NewFlow().Start(node).Run(NewSharedState())
+	}()
+
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"post_error_action": "save_failed"})
+	state := NewSharedState()
+	pipeline.Run(state)
+	if !alerted || state.Get(PostErrorKey+":save") != saveErr {
+		t.Errorf("Expected the error to route to save_failed and be stored, got %v", state.Get(PostErrorKey+":save"))
+	}
+}
+
 // Benchmark tests to validate performance characteristics
 func BenchmarkAdaptiveNodeBasic(b *testing.B) {
 	state := NewSharedState()
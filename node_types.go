@@ -0,0 +1,72 @@
+package Flow
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownNodeType is returned by NewNodeOfType for a type that isn't registered
+var ErrUnknownNodeType = errors.New("flow: unknown node type")
+
+// NodeFactory builds a node of a registered type from the params it is declared
+// with, returning an error for params it can't work with
+type NodeFactory func(params map[string]interface{}) (*Node, error)
+
+var nodeTypeRegistry = struct {
+	mu        sync.RWMutex
+	factories map[string]NodeFactory
+}{
+	factories: make(map[string]NodeFactory),
+}
+
+// RegisterNodeType registers the factory building nodes of the given type name,
+// replacing any factory previously registered with that name, so packages
+// outside this module can provide integration nodes ("http", "sql", company
+// specific nodes) that declarative flow definitions and tools create by name.
+// Packages typically register their types in an init function.
+//
+// Example:
+//
+//	func init() {
+//		flow.RegisterNodeType("http", func(params map[string]interface{}) (*flow.Node, error) {
+//			var cfg httpConfig
+//			if err := flow.Params(params).Bind(&cfg); err != nil {
+//				return nil, err
+//			}
+//			node := flow.NewNode()
+//			node.SetParams(params)
+//			node.SetExecFunc(cfg.fetch)
+//			return node, nil
+//		})
+//	}
+func RegisterNodeType(typeName string, factory NodeFactory) {
+	nodeTypeRegistry.mu.Lock()
+	defer nodeTypeRegistry.mu.Unlock()
+	nodeTypeRegistry.factories[typeName] = factory
+}
+
+// NewNodeOfType builds a node with the factory registered for typeName. It
+// returns an error wrapping ErrUnknownNodeType if none is registered, or the
+// factory's error annotated with the type name.
+func NewNodeOfType(typeName string, params map[string]interface{}) (*Node, error) {
+	nodeTypeRegistry.mu.RLock()
+	factory, ok := nodeTypeRegistry.factories[typeName]
+	nodeTypeRegistry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownNodeType, typeName)
+	}
+
+	node, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("flow: node type %q: %w", typeName, err)
+	}
+	return node, nil
+}
+
+// NodeTypes returns the registered node type names in lexical order
+func NodeTypes() []string {
+	nodeTypeRegistry.mu.RLock()
+	defer nodeTypeRegistry.mu.RUnlock()
+	return sortedKeys(nodeTypeRegistry.factories)
+}
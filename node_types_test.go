@@ -0,0 +1,77 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNodeTypeRegistry tests creating nodes of registered types by name
+func TestNodeTypeRegistry(t *testing.T) {
+	RegisterNodeType("test-greeter", func(params map[string]interface{}) (*Node, error) {
+		var cfg struct {
+			Greeting string `param:"greeting,required"`
+		}
+		if err := Params(params).Bind(&cfg); err != nil {
+			return nil, err
+		}
+		node := NewNode()
+		node.SetParams(params)
+		node.SetExecFunc(func(interface{}) (interface{}, error) { return cfg.Greeting + "!", nil })
+		node.SetPostFunc(func(shared *SharedState, _, result interface{}) string {
+			shared.Set("greeting", result)
+			return DefaultAction
+		})
+		return node, nil
+	})
+
+	node, err := NewNodeOfType("test-greeter", map[string]interface{}{"greeting": "hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	state := NewSharedState()
+	node.Run(state)
+	if got := state.Get("greeting"); got != "hello!" {
+		t.Errorf("Expected the factory's node to run, got %v", got)
+	}
+
+	if _, err := NewNodeOfType("test-greeter", nil); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected the factory's error, got %v", err)
+	}
+	if _, err := NewNodeOfType("test-missing", nil); !errors.Is(err, ErrUnknownNodeType) {
+		t.Errorf("Expected ErrUnknownNodeType, got %v", err)
+	}
+
+	found := false
+	for _, name := range NodeTypes() {
+		found = found || name == "test-greeter"
+	}
+	if !found {
+		t.Errorf("Expected test-greeter among %v", NodeTypes())
+	}
+
+	runner := NewRunner()
+	runner.SetAuthorizer(AuthorizerFunc(func(_ context.Context, req AuthRequest) error {
+		if req.Action != AuthNodeTypes {
+			return errors.New("unexpected action")
+		}
+		return nil
+	}))
+	server := httptest.NewServer(runner.AdminHandler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/node-types")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var types []string
+	if err := json.NewDecoder(resp.Body).Decode(&types); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(types) != len(NodeTypes()) {
+		t.Errorf("Expected 200 with the registered types, got %d %v", resp.StatusCode, types)
+	}
+}
@@ -0,0 +1,102 @@
+package Flow
+
+import "context"
+
+// ParamFunc is a param value computed from the shared state each time the node
+// runs, instead of being frozen at SetParams time. Every param reads the computed
+// value during the run, including those that configure the node's behavior, such
+// as a "parallel_limit" derived from the input size. Plain
+// func(*SharedState) interface{} values are treated the same way.
+//
+// Example:
+//
+//	node.SetParams(map[string]interface{}{
+//		"batch":    true,
+//		"parallel": true,
+//		"parallel_limit": flow.ParamFunc(func(shared *flow.SharedState) interface{} {
+//			return len(shared.GetSlice("urls"))/100 + 1
+//		}),
+//	})
+type ParamFunc func(shared *SharedState) interface{}
+
+// boundParamsKey is the context key of the params computed for a node's run
+type boundParamsKey struct{}
+
+// boundParams are the params of node with ParamFunc values computed for one run
+type boundParams struct {
+	node   *Node
+	params Params
+}
+
+// bindParams evaluates the node's param funcs against shared for the run about to
+// start and returns ctx carrying the computed params. They are never stored on
+// the node, so concurrent runs of one node each see the values of their own run.
+func (n *Node) bindParams(ctx context.Context, shared *SharedState) context.Context {
+	n.paramsMu.RLock()
+	params, version := Params(n.params), n.paramsVersion
+	n.paramsMu.RUnlock()
+
+	var evaluated Params
+	for key, value := range params {
+		fn := paramFunc(value)
+		if fn == nil {
+			continue
+		}
+		if evaluated == nil {
+			evaluated = make(Params, len(params))
+			for k, v := range params {
+				evaluated[k] = v
+			}
+		}
+		evaluated[key] = fn(shared)
+	}
+
+	// Params are checked by the first run of each version, not on every run
+	n.paramsMu.Lock()
+	unchecked := n.checkedAt < version
+	if unchecked {
+		n.checkedAt = version
+	}
+	n.paramsMu.Unlock()
+
+	if unchecked {
+		if evaluated != nil {
+			params = evaluated
+		}
+		n.warnIgnoredParams(shared, params)
+	}
+	if evaluated == nil {
+		if _, ok := ctx.Value(boundParamsKey{}).(boundParams); !ok {
+			return ctx
+		}
+		// A nested run must not see the values bound for an enclosing one
+		evaluated = params
+	}
+	return context.WithValue(ctx, boundParamsKey{}, boundParams{node: n, params: evaluated})
+}
+
+// runParams returns the node's params for the run of ctx, with ParamFunc values
+// computed by bindParams
+func (n *Node) runParams(ctx context.Context) Params {
+	if bound, ok := ctx.Value(boundParamsKey{}).(boundParams); ok && bound.node == n {
+		return bound.params
+	}
+	return n.paramMap()
+}
+
+// param returns a single param for the run of ctx, or nil if it doesn't exist
+func (n *Node) param(ctx context.Context, key string) interface{} {
+	return n.runParams(ctx)[key]
+}
+
+// paramFunc returns value as a ParamFunc, or nil if it is not one
+func paramFunc(value interface{}) ParamFunc {
+	switch fn := value.(type) {
+	case ParamFunc:
+		return fn
+	case func(*SharedState) interface{}:
+		return fn
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParamFuncEvaluatedPerRun tests that param funcs are computed from state when the node runs
+func TestParamFuncEvaluatedPerRun(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"batch":    true,
+		"parallel": true,
+		"data": ParamFunc(func(shared *SharedState) interface{} {
+			return shared.GetSlice("urls")
+		}),
+		"parallel_limit": func(shared *SharedState) interface{} {
+			return len(shared.GetSlice("urls")) / 2
+		},
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		mu.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return item, nil
+	})
+
+	for _, size := range []int{4, 8} {
+		state := NewSharedState()
+		urls := make([]interface{}, size)
+		for i := range urls {
+			urls[i] = i
+		}
+		state.Set("urls", urls)
+		peak = 0
+
+		node.Run(state)
+
+		if got := len(state.GetSlice(BatchResultsKey)); got != size {
+			t.Errorf("Expected %d results, got %d", size, got)
+		}
+		if peak > size/2 {
+			t.Errorf("Expected at most %d concurrent items, got %d", size/2, peak)
+		}
+	}
+}
+
+// TestParamFuncConcurrentRuns tests that concurrent runs of one graph each see
+// the param values computed for their own state
+func TestParamFuncConcurrentRuns(t *testing.T) {
+	const runs = 4
+	var started sync.WaitGroup
+	started.Add(runs)
+
+	// Flows set their params on every node they run
+	pipeline := NewFlow()
+	pipeline.SetParams(map[string]interface{}{
+		"label": ParamFunc(func(shared *SharedState) interface{} {
+			return shared.Get(PoolInputKey)
+		}),
+	})
+	node := NewNode()
+	node.SetPrepFunc(func(*SharedState) interface{} {
+		// Every run has computed its params before any of them execs
+		started.Done()
+		started.Wait()
+		return nil
+	})
+	node.SetExecFuncWithParams(func(params Params, _ interface{}) (interface{}, error) {
+		return params.String("label"), nil
+	})
+	node.SetPostFunc(func(shared *SharedState, _, exec interface{}) string {
+		shared.Set("seen", exec)
+		return DefaultAction
+	})
+
+	results, err := NewFlowPool(pipeline.Start(node), runs).Run(context.Background(), []interface{}{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatalf("Expected the runs to succeed, got %v", err)
+	}
+	for _, r := range results {
+		if seen := r.State.Get("seen"); seen != r.Input {
+			t.Errorf("Expected run %d to see its own label %v, got %v", r.Index, r.Input, seen)
+		}
+	}
+	if _, ok := node.GetParam("label").(ParamFunc); !ok {
+		t.Errorf("Expected GetParam to return the param func as set, got %v", node.GetParam("label"))
+	}
+}
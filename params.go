@@ -0,0 +1,81 @@
+package Flow
+
+import "time"
+
+// Params is a typed, read-only view over a node's parameters.
+// It is passed to functions registered with SetExecFuncWithParams so business
+// logic can be written as plain package-level functions instead of closures
+// over the node variable. Typed getters return the zero value when a key is
+// missing or holds a different type.
+//
+// Example:
+//
+//	func greet(params flow.Params, prep interface{}) (interface{}, error) {
+//		return fmt.Sprintf("Hello, %s!", params.String("name")), nil
+//	}
+//
+//	node.SetExecFuncWithParams(greet)
+type Params map[string]interface{}
+
+// Get returns the raw parameter value, or nil if it doesn't exist
+func (p Params) Get(key string) interface{} {
+	return p[key]
+}
+
+// String returns a string parameter, or "" if missing or not a string
+func (p Params) String(key string) string {
+	if s, ok := p[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// Int returns an int parameter, or 0 if missing or not an int
+func (p Params) Int(key string) int {
+	if i, ok := p[key].(int); ok {
+		return i
+	}
+	return 0
+}
+
+// Bool returns a bool parameter, or false if missing or not a bool
+func (p Params) Bool(key string) bool {
+	if b, ok := p[key].(bool); ok {
+		return b
+	}
+	return false
+}
+
+// Duration returns a time.Duration parameter, or 0 if missing or not a duration
+func (p Params) Duration(key string) time.Duration {
+	if d, ok := p[key].(time.Duration); ok {
+		return d
+	}
+	return 0
+}
+
+// Strings returns a list parameter given as []string, []interface{} of strings,
+// or a single string, or nil if missing or of another type
+func (p Params) Strings(key string) []string {
+	return toStrings(p[key])
+}
+
+// toStrings converts a []string, []interface{} of strings or single string to a
+// []string, or returns nil for other values
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return append([]string(nil), v...)
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	case string:
+		return []string{v}
+	}
+	return nil
+}
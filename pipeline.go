@@ -0,0 +1,134 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultPipelineBuffer is the capacity of the channels between pipeline stages
+const defaultPipelineBuffer = 16
+
+// Pipeline is a streaming dataflow over a chain of nodes. Unlike a Flow, which
+// traverses the whole graph once per run, each stage runs persistently in its own
+// goroutine and items stream between stages over bounded channels, so a slow
+// stage applies backpressure to the ones before it.
+//
+// Each stage calls its node's exec function once per item, with the node's retry,
+// limiter, breaker and hedging parameters applied; prep and post functions are
+// not used. A stage without an exec function passes items through unchanged.
+//
+// Example:
+//
+//	p := flow.NewPipeline(parse, enrich, store).SetBuffer(64)
+//	out, errc := p.Run(ctx, state, lines)
+//	for result := range out {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		log.Fatal(err)
+//	}
+type Pipeline struct {
+	stages []*Node
+	buffer int
+}
+
+// NewPipeline creates a pipeline whose stages run in the given order
+func NewPipeline(stages ...*Node) *Pipeline {
+	return &Pipeline{
+		stages: stages,
+		buffer: defaultPipelineBuffer,
+	}
+}
+
+// SetBuffer sets the capacity of the channels between stages and returns the
+// Pipeline for method chaining. A size of 0 makes every hand-off synchronous.
+func (p *Pipeline) SetBuffer(size int) *Pipeline {
+	if size < 0 {
+		size = 0
+	}
+	p.buffer = size
+	return p
+}
+
+// Run starts every stage and streams the items received from in through them.
+// It returns the output channel of the last stage, which is closed once in is
+// closed and drained or the pipeline stops, and an error channel that receives
+// the first stage failure (if any) and is closed when every stage has exited.
+// A failure or ctx being done stops all stages; items in flight are dropped.
+func (p *Pipeline) Run(ctx context.Context, shared *SharedState, in <-chan interface{}) (<-chan interface{}, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	errc := make(chan error, 1)
+
+	var once sync.Once
+	fail := func(err error) {
+		once.Do(func() {
+			errc <- err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	src := in
+	for _, stage := range p.stages {
+		out := make(chan interface{}, p.buffer)
+		wg.Add(1)
+		go func(stage *Node, in <-chan interface{}, out chan<- interface{}) {
+			defer wg.Done()
+			stage.runStage(ctx, shared, in, out, fail)
+		}(stage, src, out)
+		src = out
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(errc)
+	}()
+	return src, errc
+}
+
+// runStage executes the node once per item received from in, sending results to out
+// until in is closed, ctx is done, or an item fails
+func (n *Node) runStage(ctx context.Context, shared *SharedState, in <-chan interface{}, out chan<- interface{}, fail func(error)) {
+	defer close(out)
+	var index int
+	defer func() {
+		if r := recover(); r != nil {
+			fail(&NodeError{Node: n.name, Flow: flowNameFrom(ctx), Index: index, Err: panicToError(r)})
+		}
+	}()
+
+	ctx = n.bindParams(ctx, shared)
+	policy := n.retryPolicy(ctx)
+	for ; ; index++ {
+		var item interface{}
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return
+		case item, ok = <-in:
+			if !ok {
+				return
+			}
+		}
+
+		result := item
+		if n.execFunc != nil {
+			var err error
+			result, err = n.execWithRetry(ctx, shared, item, ItemMeta{Index: index}, policy, nil)
+			if aborted(ctx, err) {
+				return
+			}
+			if err != nil {
+				fail(err)
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case out <- result:
+		}
+	}
+}
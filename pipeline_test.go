@@ -0,0 +1,90 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// TestPipelineStreamsItems tests that items stream through every stage in order
+func TestPipelineStreamsItems(t *testing.T) {
+	double := NewNode()
+	double.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return item.(int) * 2, nil
+	})
+	label := NewNode()
+	label.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return fmt.Sprintf("item-%d", item.(int)), nil
+	})
+
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		for i := 1; i <= 5; i++ {
+			in <- i
+		}
+	}()
+
+	out, errc := NewPipeline(double, NewNode(), label).SetBuffer(1).Run(context.Background(), NewSharedState(), in)
+
+	var results []interface{}
+	for result := range out {
+		results = append(results, result)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []interface{}{"item-2", "item-4", "item-6", "item-8", "item-10"}
+	if fmt.Sprint(results) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, results)
+	}
+}
+
+// TestPipelineStopsOnFailure tests that a failing stage stops the pipeline and reports the error
+func TestPipelineStopsOnFailure(t *testing.T) {
+	validate := NewNode()
+	validate.SetName("validate")
+	validate.SetParams(map[string]interface{}{"retries": 2})
+	validate.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) == 3 {
+			return nil, fmt.Errorf("invalid item")
+		}
+		return item, nil
+	})
+
+	in := make(chan interface{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for i := 1; ; i++ {
+			select {
+			case in <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	out, errc := NewPipeline(validate).Run(ctx, NewSharedState(), in)
+	for range out {
+	}
+
+	err := <-errc
+	if err == nil || err.Error() != `flow: node "validate" item 2 attempt 2: invalid item` {
+		t.Errorf("Expected stage failure, got %v", err)
+	}
+	if _, open := <-errc; open {
+		t.Error("Expected error channel to be closed")
+	}
+
+	// Cancellation stops the pipeline without an error
+	cancelled, stop := context.WithCancel(context.Background())
+	stop()
+	out, errc = NewPipeline(NewNode()).Run(cancelled, NewSharedState(), make(chan interface{}))
+	for range out {
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Expected no error on cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PoolInputKey is the SharedState key under which FlowPool stores each run's input
+// when no SetStateFunc func is registered
+const PoolInputKey = "input"
+
+// poolQueue labels the GaugeQueuedRuns series of FlowPool runs
+const poolQueue = "pool"
+
+// FlowPool runs many independent runs of the same flow concurrently, bounded by a
+// global worker limit, with a fresh SharedState per run. It replaces the errgroup
+// boilerplate otherwise written around Flow.Run and aggregates every run's outcome.
+//
+// Example:
+//
+//	pool := flow.NewFlowPool(pipeline, 8).SetStateFunc(func(input interface{}) *flow.SharedState {
+//		state := flow.NewSharedState()
+//		state.Set("document", input)
+//		return state
+//	})
+//	results, err := pool.Run(ctx, documents)
+//	for _, r := range results {
+//		if r.Err == nil {
+//			summaries = append(summaries, r.State.Get("summary"))
+//		}
+//	}
+type FlowPool struct {
+	flow     *Flow
+	workers  int
+	newState func(input interface{}) *SharedState
+
+	// tenantOf and the quotas configure multi-tenant scheduling
+	tenantOf     func(input interface{}) string
+	quotas       map[string]TenantQuota
+	defaultQuota TenantQuota
+}
+
+// PoolResult is the outcome of one run of a FlowPool
+type PoolResult struct {
+	// Index is the position of the run's input
+	Index int
+	// Input is the value the run's state was created from
+	Input interface{}
+	// Tenant is the tenant the run was tagged with by SetTenantFunc
+	Tenant string
+	// Action is the last action returned by the run
+	Action string
+	// State is the run's shared state
+	State *SharedState
+	// Err is the run's failure, if any; panics are reported as errors
+	Err error
+}
+
+// NewFlowPool creates a pool running f with at most workers runs at a time.
+// A workers value <= 0 is treated as 1.
+func NewFlowPool(f *Flow, workers int) *FlowPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &FlowPool{
+		flow:    f,
+		workers: workers,
+		newState: func(input interface{}) *SharedState {
+			state := NewSharedState()
+			state.Set(PoolInputKey, input)
+			return state
+		},
+	}
+}
+
+// SetStateFunc sets the function creating each run's state from its input and
+// returns the FlowPool for method chaining. By default the input is stored under
+// PoolInputKey in an otherwise empty state.
+func (p *FlowPool) SetStateFunc(fn func(input interface{}) *SharedState) *FlowPool {
+	p.newState = fn
+	return p
+}
+
+// Run executes one run per input, at most the pool's worker limit at a time, and
+// waits for all of them. Results are returned in input order. The error joins
+// every run's failure (nil if all succeeded), so errors.Is and errors.As match any
+// of them. Once ctx is done no further runs are started, and runs in progress
+// stop as with RunContext; runs never started fail with ErrRunInterrupted.
+func (p *FlowPool) Run(ctx context.Context, inputs []interface{}) ([]PoolResult, error) {
+	results := make([]PoolResult, len(inputs))
+	tenants := make([]string, len(inputs))
+	for i, input := range inputs {
+		results[i] = PoolResult{Index: i, Input: input}
+		if p.tenantOf != nil {
+			tenants[i] = p.tenantOf(input)
+			results[i].Tenant = tenants[i]
+		}
+	}
+	addGauge(GaugeQueuedRuns, poolQueue, float64(len(inputs)))
+
+	sched := p.newTenantScheduler(tenants)
+	finished := make(chan *tenantQueue, len(inputs))
+	running := 0
+	var wg sync.WaitGroup
+	for sched.queued > 0 && ctx.Err() == nil {
+		var wait time.Duration
+		if running < p.workers {
+			var index int
+			var q *tenantQueue
+			if index, q, wait = sched.pick(time.Now()); index >= 0 {
+				addGauge(GaugeQueuedRuns, poolQueue, -1)
+				running++
+				wg.Add(1)
+				go func(r *PoolResult) {
+					defer wg.Done()
+					defer func() { finished <- q }()
+					p.run(ctx, r)
+				}(&results[index])
+				continue
+			}
+		}
+
+		// Wait for a run to finish, a rate quota to refill, or ctx
+		var timer *time.Timer
+		var refill <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			refill = timer.C
+		}
+		select {
+		case q := <-finished:
+			running--
+			q.running--
+		case <-refill:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+
+	for _, index := range sched.drain() {
+		addGauge(GaugeQueuedRuns, poolQueue, -1)
+		results[index].Err = fmt.Errorf("%w: %w", ErrRunInterrupted, ctx.Err())
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// run executes a single run, recovering a panic as its error
+func (p *FlowPool) run(ctx context.Context, r *PoolResult) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.Err = panicToError(rec)
+		}
+	}()
+	r.State = p.newState(r.Input)
+	if p.tenantOf != nil {
+		r.State.Set(TenantKey, r.Tenant)
+	}
+	r.Action, r.Err = p.flow.RunContext(ctx, r.State)
+}
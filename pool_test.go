@@ -0,0 +1,72 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFlowPool tests per-run state, the worker limit and aggregated failures
+func TestFlowPool(t *testing.T) {
+	var inFlight, peak int64
+	double := NewNode()
+	double.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		if current := atomic.AddInt64(&inFlight, 1); current > atomic.LoadInt64(&peak) {
+			atomic.StoreInt64(&peak, current)
+		}
+		defer atomic.AddInt64(&inFlight, -1)
+		time.Sleep(5 * time.Millisecond)
+
+		n := shared.GetInt(PoolInputKey)
+		if n < 0 {
+			return nil, errors.New("negative input")
+		}
+		shared.Set("doubled", n*2)
+		return "done", nil
+	})
+
+	pool := NewFlowPool(NewFlow().Start(double), 2)
+	results, err := pool.Run(context.Background(), []interface{}{1, -2, 3, -4, 5})
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.Index != i || r.Input == nil {
+			t.Errorf("Expected result %d to describe its input, got %+v", i, r)
+		}
+	}
+	if results[2].Action != "done" || results[2].State.GetInt("doubled") != 6 {
+		t.Errorf("Expected run 2 to double its own input, got %+v", results[2])
+	}
+	if results[1].Err == nil || results[3].Err == nil || results[0].Err != nil {
+		t.Errorf("Expected only negative inputs to fail, got %+v", results)
+	}
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErrorCause(nodeErr) != "negative input" {
+		t.Errorf("Expected the aggregated error to contain the node failure, got %v", err)
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent runs, got %d", peak)
+	}
+}
+
+// TestFlowPoolCancel tests that no runs start once ctx is done
+func TestFlowPoolCancel(t *testing.T) {
+	node := NewNode()
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "done", nil
+	})
+	pool := NewFlowPool(NewFlow().Start(node), 1).SetStateFunc(func(input interface{}) *SharedState {
+		t.Error("Expected no state to be created for cancelled runs")
+		return NewSharedState()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := pool.Run(ctx, []interface{}{1, 2})
+	if !errors.Is(err, ErrRunInterrupted) || !errors.Is(results[1].Err, context.Canceled) {
+		t.Errorf("Expected interrupted runs, got %v", err)
+	}
+}
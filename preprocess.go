@@ -0,0 +1,37 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+)
+
+// preprocessItems applies the "map" and "filter" params to batch data before any
+// item is executed: each item is replaced by map's result, and then items for
+// which filter returns false are dropped. Without either param data is returned
+// unchanged.
+func (n *Node) preprocessItems(ctx context.Context, data interface{}) interface{} {
+	mapParam, filterParam := n.param(ctx, "map"), n.param(ctx, "filter")
+	if mapParam == nil && filterParam == nil {
+		return data
+	}
+	mapFn, ok := mapParam.(func(interface{}) interface{})
+	if mapParam != nil && !ok {
+		panic(fmt.Errorf("flow: \"map\" param must be a func(interface{}) interface{}, got %T", mapParam))
+	}
+	filterFn, ok := filterParam.(func(interface{}) bool)
+	if filterParam != nil && !ok {
+		panic(fmt.Errorf("flow: \"filter\" param must be a func(interface{}) bool, got %T", filterParam))
+	}
+
+	items := n.convertToSlice(data)
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if mapFn != nil {
+			item = mapFn(item)
+		}
+		if filterFn == nil || filterFn(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,50 @@
+package Flow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBatchMapAndFilter tests preprocessing batch items with the "map" and
+// "filter" params
+func TestBatchMapAndFilter(t *testing.T) {
+	processed := map[string]bool{"https://a.example": true}
+	for _, parallel := range []bool{false, true} {
+		var seen []interface{}
+		node := NewNode()
+		node.SetParams(map[string]interface{}{
+			"data":     []string{" https://A.example", "https://b.example ", "HTTPS://C.EXAMPLE"},
+			"batch":    true,
+			"parallel": parallel,
+			"map": func(item interface{}) interface{} {
+				return strings.ToLower(strings.TrimSpace(item.(string)))
+			},
+			"filter": func(item interface{}) bool { return !processed[item.(string)] },
+		})
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			if !parallel {
+				seen = append(seen, item)
+			}
+			return len(item.(string)), nil
+		})
+
+		state := NewSharedState()
+		node.Run(state)
+		if got := state.GetSlice(BatchResultsKey); !reflect.DeepEqual(got, []interface{}{17, 17}) {
+			t.Errorf("parallel=%v: expected results for the kept items, got %v", parallel, got)
+		}
+		if want := []interface{}{"https://b.example", "https://c.example"}; !parallel && !reflect.DeepEqual(seen, want) {
+			t.Errorf("Expected exec to see %v, got %v", want, seen)
+		}
+	}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"data": []int{1}, "batch": true, "filter": func(int) bool { return true }})
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(panicToError(r).Error(), `"filter" param`) {
+			t.Errorf("Expected a panic for a filter of the wrong type, got %v", r)
+		}
+	}()
+	node.Run(NewSharedState())
+}
@@ -0,0 +1,65 @@
+package Flow
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestProfileHookSamples tests that the profile hook receives node and flow identity
+func TestProfileHookSamples(t *testing.T) {
+	var mu sync.Mutex
+	samples := make([]NodeSample, 0)
+	SetProfileHook(func(s NodeSample) {
+		mu.Lock()
+		samples = append(samples, s)
+		mu.Unlock()
+	})
+	defer SetProfileHook(nil)
+
+	fetch := NewNode()
+	fetch.SetName("fetch")
+	fetch.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "fetched", nil
+	})
+
+	store := NewNode()
+	store.SetName("store")
+	store.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "stored", nil
+	})
+	fetch.Next(store, "fetched")
+
+	pipeline := NewFlow().Start(fetch)
+	pipeline.SetName("ingest")
+	pipeline.Run(NewSharedState())
+
+	if len(samples) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(samples))
+	}
+	expected := []string{"fetch", "store"}
+	for i, s := range samples {
+		if s.Flow != "ingest" {
+			t.Errorf("Expected flow 'ingest', got '%s'", s.Flow)
+		}
+		if s.Node != expected[i] {
+			t.Errorf("Expected node '%s', got '%s'", expected[i], s.Node)
+		}
+		if s.Duration <= 0 {
+			t.Errorf("Expected positive duration for %s", s.Node)
+		}
+	}
+}
+
+// TestProfileHookDisabled tests that unnamed nodes run without a hook
+func TestProfileHookDisabled(t *testing.T) {
+	SetProfileHook(nil)
+
+	node := NewNode()
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+
+	if result := node.Run(NewSharedState()); result != "ok" {
+		t.Errorf("Expected 'ok', got '%s'", result)
+	}
+}
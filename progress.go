@@ -0,0 +1,121 @@
+package Flow
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of how far a flow run has advanced.
+// It is available from RunHandle.Progress and Runner.Progress while a run is in
+// flight, for UIs and health checks watching long orchestrations.
+type Progress struct {
+	// Completed is the number of nodes that have finished executing
+	Completed int
+	// EstimatedRemaining is the number of nodes on the longest path from the
+	// current node (inclusive) to a terminal node; 0 once the run has finished
+	EstimatedRemaining int
+	// CurrentNode is the name of the node currently executing ("" if unnamed or finished)
+	CurrentNode string
+	// Started is when the run began
+	Started time.Time
+	// Elapsed is the time since the run began, frozen once it finishes
+	Elapsed time.Duration
+	// Finished reports whether the run has ended
+	Finished bool
+}
+
+// progressTracker records the progress of a single run as orchestration advances
+type progressTracker struct {
+	mu        sync.Mutex
+	depths    map[*Node]int
+	started   time.Time
+	ended     time.Time
+	completed int
+	current   *Node
+	finished  bool
+}
+
+// newProgressTracker prepares a tracker for a run starting at start
+func newProgressTracker(start *Node) *progressTracker {
+	return &progressTracker{
+		depths:  graphDepths(start),
+		started: time.Now(),
+	}
+}
+
+// enter records that curr is about to run, completing the previous node
+func (p *progressTracker) enter(curr *Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil {
+		p.completed++
+	}
+	p.current = curr
+}
+
+// finish records the end of the run; completed reports whether the current node finished
+func (p *progressTracker) finish(completed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil && completed {
+		p.completed++
+	}
+	p.current = nil
+	p.finished = true
+	p.ended = time.Now()
+}
+
+// snapshot returns the current progress
+func (p *progressTracker) snapshot() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	progress := Progress{
+		Completed: p.completed,
+		Started:   p.started,
+		Finished:  p.finished,
+	}
+	if p.finished {
+		progress.Elapsed = p.ended.Sub(p.started)
+	} else {
+		progress.Elapsed = time.Since(p.started)
+	}
+	if p.current != nil {
+		progress.CurrentNode = p.current.name
+		progress.EstimatedRemaining = p.depths[p.current]
+	}
+	return progress
+}
+
+// graphDepths computes, for every node reachable from start, the number of nodes on
+// the longest path from it to a terminal node. Edges back to a node already on the
+// current path are ignored, so cyclic graphs yield an estimate rather than infinity.
+func graphDepths(start *Node) map[*Node]int {
+	depths := make(map[*Node]int)
+	onPath := make(map[*Node]bool)
+
+	var visit func(n *Node) int
+	visit = func(n *Node) int {
+		if d, ok := depths[n]; ok {
+			return d
+		}
+		onPath[n] = true
+		longest := 0
+		for _, next := range n.children() {
+			if next == nil || onPath[next] {
+				continue
+			}
+			if d := visit(next); d > longest {
+				longest = d
+			}
+		}
+		onPath[n] = false
+		depths[n] = longest + 1
+		return longest + 1
+	}
+
+	if start != nil {
+		visit(start)
+	}
+	return depths
+}
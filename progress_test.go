@@ -0,0 +1,109 @@
+package Flow
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRunProgress tests progress snapshots during and after a run
+func TestRunProgress(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	first := NewNode()
+	first.SetName("first")
+	first.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "next", nil
+	})
+
+	blocking := NewNode()
+	blocking.SetName("blocking")
+	blocking.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		close(entered)
+		<-release
+		return "next", nil
+	})
+
+	last := NewNode()
+	last.SetName("last")
+	last.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "done", nil
+	})
+	first.Next(blocking, "next")
+	blocking.Next(last, "next")
+
+	handle := NewFlow().Start(first).RunAsync(context.Background(), NewSharedState())
+	<-entered
+
+	progress := handle.Progress()
+	if progress.Completed != 1 || progress.CurrentNode != "blocking" {
+		t.Errorf("Expected 1 completed at 'blocking', got %d at '%s'", progress.Completed, progress.CurrentNode)
+	}
+	if progress.EstimatedRemaining != 2 {
+		t.Errorf("Expected 2 nodes remaining, got %d", progress.EstimatedRemaining)
+	}
+	if progress.Finished {
+		t.Error("Expected run to be in flight")
+	}
+
+	close(release)
+	if _, err := handle.Result(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	progress = handle.Progress()
+	if !progress.Finished || progress.Completed != 3 || progress.EstimatedRemaining != 0 {
+		t.Errorf("Unexpected final progress: %+v", progress)
+	}
+}
+
+// TestRunnerProgress tests progress lookup for runs managed by a Runner
+func TestRunnerProgress(t *testing.T) {
+	runner := NewRunner()
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	node := NewNode()
+	node.SetName("waiting")
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		close(entered)
+		<-release
+		return "done", nil
+	})
+
+	go func() {
+		_, _ = runner.Run(NewFlow().Start(node), NewSharedState())
+	}()
+	<-entered
+
+	active := runner.Active()
+	if len(active) != 1 {
+		t.Fatalf("Expected 1 active run, got %d", len(active))
+	}
+	progress, ok := runner.Progress(active[0].ID)
+	if !ok || progress.CurrentNode != "waiting" {
+		t.Errorf("Expected progress at 'waiting', got %+v", progress)
+	}
+	if progress.Elapsed <= 0 || progress.Elapsed > time.Second {
+		t.Errorf("Unexpected elapsed time: %v", progress.Elapsed)
+	}
+
+	close(release)
+	if _, ok := runner.Progress("run-does-not-exist"); ok {
+		t.Error("Expected unknown run to report false")
+	}
+}
+
+// TestGraphDepthsWithCycle tests depth estimation on cyclic graphs
+func TestGraphDepthsWithCycle(t *testing.T) {
+	a, b, c := NewNode(), NewNode(), NewNode()
+	a.Next(b, "next")
+	b.Next(a, "retry")
+	b.Next(c, "next")
+
+	depths := graphDepths(a)
+	if depths[a] != 3 || depths[b] != 2 || depths[c] != 1 {
+		t.Errorf("Unexpected depths: a=%d b=%d c=%d", depths[a], depths[b], depths[c])
+	}
+}
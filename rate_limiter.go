@@ -0,0 +1,180 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TokenBucket bounds the rate of executions across every node that references it,
+// so all flows in a service collectively respect a shared quota such as an API's
+// requests per minute. It is a token bucket: up to limit calls may start at once,
+// after which calls are spaced out to limit per period. Callers past the quota
+// wait their turn in the order they arrived.
+//
+// Rate limiters are created and registered with RateLimiter() and referenced from
+// nodes through the "limiter" parameter, sharing names with concurrency limiters.
+//
+// Example:
+//
+//	flow.RateLimiter("openrouter", 60, time.Minute)
+//
+//	node.SetParams(map[string]interface{}{
+//		"data":     prompts,
+//		"batch":    true,
+//		"parallel": true,
+//		"limiter":  "openrouter", // At most 60 calls a minute across all flows
+//	})
+type TokenBucket struct {
+	name   string
+	limit  int
+	period time.Duration
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+var rateLimiterRegistry = struct {
+	mu       sync.RWMutex
+	limiters map[string]*TokenBucket
+}{
+	limiters: make(map[string]*TokenBucket),
+}
+
+// RateLimiter registers a named rate limiter allowing limit executions per period
+// and returns it. If a rate limiter with the same name is already registered, the
+// existing limiter is returned unchanged so that every node referencing the name
+// shares one quota. A limit <= 0 is treated as 1 and a period <= 0 as one second.
+// It panics if the name is already registered with Limiter().
+//
+// Parameters:
+//   - name: The name nodes use to reference the limiter via the "limiter" param
+//   - limit: The maximum number of executions per period
+//   - period: The window the limit applies to
+//
+// Returns:
+//   - *TokenBucket: The registered rate limiter
+func RateLimiter(name string, limit int, period time.Duration) *TokenBucket {
+	if lookupLimiter(name) != nil {
+		panic(fmt.Errorf("flow: limiter %q is already registered as a concurrency limiter", name))
+	}
+
+	rateLimiterRegistry.mu.Lock()
+	defer rateLimiterRegistry.mu.Unlock()
+
+	if existing, ok := rateLimiterRegistry.limiters[name]; ok {
+		return existing
+	}
+
+	l := newTokenBucket(name, limit, period)
+	rateLimiterRegistry.limiters[name] = l
+	return l
+}
+
+// newTokenBucket creates a full, unregistered bucket, applying RateLimiter's defaults
+func newTokenBucket(name string, limit int, period time.Duration) *TokenBucket {
+	if limit <= 0 {
+		limit = 1
+	}
+	if period <= 0 {
+		period = time.Second
+	}
+	return &TokenBucket{
+		name:   name,
+		limit:  limit,
+		period: period,
+		tokens: float64(limit),
+		last:   time.Now(),
+	}
+}
+
+// lookupRateLimiter returns the registered rate limiter for name, or nil if none exists
+func lookupRateLimiter(name string) *TokenBucket {
+	rateLimiterRegistry.mu.RLock()
+	defer rateLimiterRegistry.mu.RUnlock()
+	return rateLimiterRegistry.limiters[name]
+}
+
+// Name returns the name the rate limiter was registered under
+func (l *TokenBucket) Name() string {
+	return l.name
+}
+
+// Limit returns the maximum number of executions per period
+func (l *TokenBucket) Limit() int {
+	return l.limit
+}
+
+// Period returns the window the limit applies to
+func (l *TokenBucket) Period() time.Duration {
+	return l.period
+}
+
+// Wait blocks until the quota allows another execution, and takes it
+func (l *TokenBucket) Wait() {
+	if wait := l.reserve(time.Now()); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// WaitContext blocks until the quota allows another execution and takes it, or
+// until ctx is done, returning ctx.Err() and giving the token back in that case
+func (l *TokenBucket) WaitContext(ctx context.Context) error {
+	wait := l.reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token at now and returns how long the caller must wait for it.
+// Tokens go negative while callers queue, so later callers wait longer.
+func (l *TokenBucket) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	interval := l.refillLocked(now)
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * interval)
+}
+
+// take takes a token at now if one is available, and otherwise leaves the bucket
+// unchanged and returns how long until one is
+func (l *TokenBucket) take(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	interval := l.refillLocked(now)
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) * interval)
+}
+
+// refillLocked adds the tokens accrued since the last update and returns the
+// interval between tokens
+func (l *TokenBucket) refillLocked(now time.Time) float64 {
+	interval := float64(l.period) / float64(l.limit)
+	l.tokens += float64(now.Sub(l.last)) / interval
+	if l.tokens > float64(l.limit) {
+		l.tokens = float64(l.limit)
+	}
+	l.last = now
+	return interval
+}
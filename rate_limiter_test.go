@@ -0,0 +1,79 @@
+package Flow
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSharedRateLimiterAcrossNodes tests that a named rate limiter paces calls across nodes
+func TestSharedRateLimiterAcrossNodes(t *testing.T) {
+	RateLimiter("test-shared-api", 4, 100*time.Millisecond)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		node := NewNode()
+		node.SetParams(map[string]interface{}{
+			"data":     []int{1, 2, 3, 4},
+			"batch":    true,
+			"parallel": true,
+			"limiter":  "test-shared-api",
+		})
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			return item, nil
+		})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.Run(NewSharedState())
+		}()
+	}
+	wg.Wait()
+
+	// A burst of 4 runs immediately; the other 4 are spaced 25ms apart
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected 8 calls at 4 per 100ms to take at least 100ms, took %v", elapsed)
+	}
+}
+
+// TestRateLimiterReserve tests token bucket refills and queued waits
+func TestRateLimiterReserve(t *testing.T) {
+	l := RateLimiter("test-rate-reserve", 2, time.Second)
+	now := time.Now()
+	l.tokens, l.last = 2, now
+
+	if wait := l.reserve(now); wait != 0 {
+		t.Errorf("Expected the first call to start immediately, waited %v", wait)
+	}
+	if wait := l.reserve(now); wait != 0 {
+		t.Errorf("Expected the burst to start immediately, waited %v", wait)
+	}
+	if wait := l.reserve(now); wait != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms, got %v", wait)
+	}
+	if wait := l.reserve(now); wait != time.Second {
+		t.Errorf("Expected the next queued call to wait 1s, got %v", wait)
+	}
+	if wait := l.reserve(now.Add(3 * time.Second)); wait != 0 {
+		t.Errorf("Expected the bucket to refill, waited %v", wait)
+	}
+}
+
+// TestRateLimiterRegistry tests registration semantics and name collisions with Limiter
+func TestRateLimiterRegistry(t *testing.T) {
+	first := RateLimiter("test-rate-registry", 60, time.Minute)
+	second := RateLimiter("test-rate-registry", 10, time.Second)
+	if first != second || second.Limit() != 60 || second.Period() != time.Minute {
+		t.Error("Expected re-registration to return the existing rate limiter")
+	}
+
+	Limiter("test-rate-collision", 1)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic for a name already used by a concurrency limiter")
+		}
+	}()
+	RateLimiter("test-rate-collision", 1, time.Second)
+}
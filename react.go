@@ -0,0 +1,173 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// ScratchpadKey is the SharedState key under which ReAct loops keep their []ReActStep
+	ScratchpadKey = "scratchpad"
+	// AnswerKey is the SharedState key under which ReAct loops store their final answer
+	AnswerKey = "answer"
+	// AnswerAction is returned by a ReAct loop once the reasoner gives an answer
+	AnswerAction = "answer"
+	// MaxStepsAction is returned by a ReAct loop that used up its steps without an answer
+	MaxStepsAction = "max_steps"
+
+	// reactActAction and reactReasonAction connect the two nodes of a ReAct loop
+	reactActAction    = "act"
+	reactReasonAction = "reason"
+)
+
+// ReActStep is one reason→act→observe step of a ReAct loop
+type ReActStep struct {
+	// Thought is the reasoner's explanation of the step
+	Thought string `json:"thought,omitempty"`
+	// Action is the name of the tool called ("" for the final answer)
+	Action string `json:"action,omitempty"`
+	// Input is the tool's input
+	Input interface{} `json:"input,omitempty"`
+	// Observation is the tool's result
+	Observation interface{} `json:"observation,omitempty"`
+	// Error is the tool's error message, if it failed or is unknown
+	Error string `json:"error,omitempty"`
+}
+
+// ReActDecision is what the reasoner of a ReAct loop decides to do next: call the
+// tool named Action with Input, or, with an empty Action, finish with Answer
+type ReActDecision struct {
+	Thought string
+	Action  string
+	Input   interface{}
+	Answer  interface{}
+}
+
+// Tool is an action available to a ReAct loop
+type Tool func(input interface{}) (interface{}, error)
+
+// ContextTool is an action available to a ReAct loop created with
+// NewReActNodeContext. It receives the context of the act node's exec call, so a
+// remote call can stop when the run is cancelled or the node times out.
+type ContextTool func(ctx context.Context, input interface{}) (interface{}, error)
+
+// WithContext adapts t to a ContextTool that ignores its context
+func (t Tool) WithContext() ContextTool {
+	return func(_ context.Context, input interface{}) (interface{}, error) {
+		return t(input)
+	}
+}
+
+// NewReActNode creates a ReAct (reason→act→observe) loop and returns its entry node.
+// The loop alternates between two nodes: "reason" calls reason with the scratchpad
+// of steps so far, typically prompting a model with it, and "act" calls the chosen
+// tool and records its observation. Both are ordinary nodes, so every step shows up
+// in events, traces and journals, and retry params apply to reasoning calls.
+//
+// The scratchpad is stored as a []ReActStep under ScratchpadKey; a loop entered with
+// an existing scratchpad continues it. Tool failures and unknown tools are recorded
+// as the step's Error for the reasoner to react to. When the reasoner answers, the
+// answer is stored under AnswerKey and the loop returns AnswerAction; after
+// maxSteps tool calls without an answer it returns MaxStepsAction.
+//
+// Parameters:
+//   - maxSteps: The most tool calls before giving up (<= 0 means 10)
+//   - reason: Decides the next step from the state and scratchpad
+//   - tools: The tools the reasoner may call, by name
+//
+// Returns:
+//   - *Node: The loop's reasoning node, to connect AnswerAction and MaxStepsAction from
+//
+// Example:
+//
+//	agent := flow.NewReActNode(8, func(shared *flow.SharedState, steps []flow.ReActStep) (flow.ReActDecision, error) {
+//		return askModel(shared.Get("question"), steps)
+//	}, map[string]flow.Tool{"search": search, "calculator": calc})
+//	agent.Next(respond, flow.AnswerAction)
+//	agent.Next(escalate, flow.MaxStepsAction)
+func NewReActNode(maxSteps int, reason func(shared *SharedState, scratchpad []ReActStep) (ReActDecision, error), tools map[string]Tool) *Node {
+	contextTools := make(map[string]ContextTool, len(tools))
+	for name, tool := range tools {
+		contextTools[name] = tool.WithContext()
+	}
+	return NewReActNodeContext(maxSteps, reason, contextTools)
+}
+
+// NewReActNodeContext creates a ReAct loop like NewReActNode whose tools are
+// called with the context of the act node's exec call, such as the tools of an
+// MCPClient.
+//
+// Example:
+//
+//	tools, err := client.Tools(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	tools["calculator"] = flow.Tool(calc).WithContext()
+//	agent := flow.NewReActNodeContext(8, reason, tools)
+func NewReActNodeContext(maxSteps int, reason func(shared *SharedState, scratchpad []ReActStep) (ReActDecision, error), tools map[string]ContextTool) *Node {
+	if maxSteps <= 0 {
+		maxSteps = 10
+	}
+
+	reasonNode := NewNode()
+	reasonNode.SetName("reason")
+	reasonNode.SetPrepFunc(func(shared *SharedState) interface{} {
+		return scratchpad(shared)
+	})
+	reasonNode.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		return reason(shared, prep.([]ReActStep))
+	})
+	reasonNode.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		decision := exec.(ReActDecision)
+		steps := prep.([]ReActStep)
+		if decision.Action == "" {
+			shared.Set(ScratchpadKey, append(steps, ReActStep{Thought: decision.Thought}))
+			shared.Set(AnswerKey, decision.Answer)
+			return AnswerAction
+		}
+		if len(steps) >= maxSteps {
+			return MaxStepsAction
+		}
+		shared.Set(ScratchpadKey, append(steps, ReActStep{Thought: decision.Thought, Action: decision.Action, Input: decision.Input}))
+		return reactActAction
+	})
+
+	actNode := NewNode()
+	actNode.SetName("act")
+	actNode.SetPrepFunc(func(shared *SharedState) interface{} {
+		steps := scratchpad(shared)
+		return steps[len(steps)-1]
+	})
+	actNode.SetExecFuncWithContext(func(ctx context.Context, prep interface{}) (interface{}, error) {
+		step := prep.(ReActStep)
+		tool, ok := tools[step.Action]
+		if !ok {
+			step.Error = fmt.Sprintf("unknown tool %q", step.Action)
+			return step, nil
+		}
+		observation, err := tool(ctx, step.Input)
+		if err != nil {
+			step.Error = err.Error()
+			return step, nil
+		}
+		step.Observation = observation
+		return step, nil
+	})
+	actNode.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		steps := scratchpad(shared)
+		steps[len(steps)-1] = exec.(ReActStep)
+		shared.Set(ScratchpadKey, steps)
+		return reactReasonAction
+	})
+
+	reasonNode.Next(actNode, reactActAction)
+	actNode.Next(reasonNode, reactReasonAction)
+	return reasonNode
+}
+
+// scratchpad returns a copy of the ReAct steps in shared
+func scratchpad(shared *SharedState) []ReActStep {
+	steps, _ := shared.Get(ScratchpadKey).([]ReActStep)
+	return append([]ReActStep(nil), steps...)
+}
@@ -0,0 +1,78 @@
+package Flow
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestReActLoop tests the reason, act and observe cycle up to the final answer
+func TestReActLoop(t *testing.T) {
+	reason := func(shared *SharedState, steps []ReActStep) (ReActDecision, error) {
+		switch len(steps) {
+		case 0:
+			return ReActDecision{Thought: "look it up", Action: "search", Input: "capital of France"}, nil
+		case 1:
+			return ReActDecision{Thought: "try the broken tool", Action: "flaky"}, nil
+		case 2:
+			return ReActDecision{Thought: "try a tool that isn't there", Action: "teleport"}, nil
+		default:
+			return ReActDecision{Thought: "done", Answer: steps[0].Observation}, nil
+		}
+	}
+	tools := map[string]Tool{
+		"search": func(input interface{}) (interface{}, error) { return "Paris", nil },
+		"flaky":  func(input interface{}) (interface{}, error) { return nil, errors.New("timeout") },
+	}
+
+	var events []Event
+	agent := NewReActNode(5, reason, tools)
+	pipeline := NewFlow().Start(agent)
+	pipeline.Subscribe(func(e Event) { events = append(events, e) })
+	state := NewSharedState()
+	if action := pipeline.Run(state); action != AnswerAction {
+		t.Fatalf("Expected %q, got %q", AnswerAction, action)
+	}
+
+	if state.Get(AnswerKey) != "Paris" {
+		t.Errorf("Expected answer Paris, got %v", state.Get(AnswerKey))
+	}
+	steps := state.Get(ScratchpadKey).([]ReActStep)
+	if len(steps) != 4 {
+		t.Fatalf("Expected 4 scratchpad steps, got %+v", steps)
+	}
+	if steps[0].Observation != "Paris" || steps[1].Error != "timeout" || steps[2].Error != `unknown tool "teleport"` {
+		t.Errorf("Unexpected observations: %+v", steps)
+	}
+	if steps[3].Thought != "done" || steps[3].Action != "" {
+		t.Errorf("Expected the final step to record the answer thought, got %+v", steps[3])
+	}
+
+	started := 0
+	for _, e := range events {
+		if e.Type == EventNodeStarted {
+			started++
+		}
+	}
+	if started != 7 {
+		t.Errorf("Expected 4 reason and 3 act node runs in the event stream, got %d", started)
+	}
+}
+
+// TestReActMaxSteps tests that the loop gives up after its step budget
+func TestReActMaxSteps(t *testing.T) {
+	calls := 0
+	agent := NewReActNode(2, func(shared *SharedState, steps []ReActStep) (ReActDecision, error) {
+		return ReActDecision{Action: "noop"}, nil
+	}, map[string]Tool{"noop": func(interface{}) (interface{}, error) {
+		calls++
+		return nil, nil
+	}})
+
+	state := NewSharedState()
+	if action := NewFlow().Start(agent).Run(state); action != MaxStepsAction {
+		t.Fatalf("Expected %q, got %q", MaxStepsAction, action)
+	}
+	if calls != 2 || len(state.Get(ScratchpadKey).([]ReActStep)) != 2 {
+		t.Errorf("Expected 2 tool calls, got %d", calls)
+	}
+}
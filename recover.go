@@ -0,0 +1,89 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is produced when a user function panics while the node's
+// "recover_panics" param is true. The panic is recovered and treated as an
+// ordinary error of its phase: exec panics flow through retries and fallbacks
+// like any returned exec error, and post panics are routed by
+// "post_error_action" like the errors of a SetPostFuncWithError function. Prep
+// panics, and post panics without "post_error_action", fail the node with the
+// PanicError.
+type PanicError struct {
+	// Node is the name of the node whose function panicked
+	Node string
+	// Phase is the lifecycle phase that panicked: "prep", "exec", or "post"
+	Phase string
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the goroutine stack captured when the panic was recovered
+	Stack []byte
+}
+
+// Error describes the panic with its node identity and phase
+func (e *PanicError) Error() string {
+	if e.Node == "" {
+		return fmt.Sprintf("flow: panic in %s: %v", e.Phase, e.Value)
+	}
+	return fmt.Sprintf("flow: panic in node %q %s: %v", e.Node, e.Phase, e.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// recoverInto converts a panic into a *PanicError stored in *errp.
+// It must be deferred directly.
+func (n *Node) recoverInto(phase string, errp *error) {
+	if r := recover(); r != nil {
+		*errp = &PanicError{Node: n.name, Phase: phase, Value: r, Stack: debug.Stack()}
+	}
+}
+
+// callPrep runs the user's prep function, converting panics into a *PanicError
+// when "recover_panics" is set
+func (n *Node) callPrep(ctx context.Context, shared *SharedState) (result interface{}) {
+	if !n.getBoolParam(ctx, "recover_panics") {
+		return n.prepFunc(ctx, shared)
+	}
+
+	var err error
+	func() {
+		defer n.recoverInto("prep", &err)
+		result = n.prepFunc(ctx, shared)
+	}()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// callPost runs the user's post function, converting panics into a *PanicError
+// routed by "post_error_action" when "recover_panics" is set
+func (n *Node) callPost(ctx context.Context, shared *SharedState, prepResult, execResult interface{}) (action string) {
+	if !n.getBoolParam(ctx, "recover_panics") {
+		return n.postFunc(ctx, shared, prepResult, execResult)
+	}
+
+	var err error
+	func() {
+		defer n.recoverInto("post", &err)
+		action = n.postFunc(ctx, shared, prepResult, execResult)
+	}()
+	if err == nil {
+		return action
+	}
+	if errorAction := n.getStringParam(ctx, "post_error_action"); errorAction != "" {
+		shared.Set(postErrorKey(n.name), err)
+		return errorAction
+	}
+	panic(err)
+}
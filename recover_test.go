@@ -0,0 +1,109 @@
+package Flow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRecoverPanicsRetriesExec tests that exec panics are retried like errors
+func TestRecoverPanicsRetriesExec(t *testing.T) {
+	counter := &mockCounter{}
+
+	node := NewNode()
+	node.SetName("parser")
+	node.SetParams(map[string]interface{}{
+		"retries":        3,
+		"recover_panics": true,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if counter.increment() < 3 {
+			var m map[string]int
+			m["boom"] = 1 // nil map write panics
+		}
+		return "parsed", nil
+	})
+
+	state := NewSharedState()
+	if result := node.Run(state); result != "parsed" {
+		t.Errorf("Expected 'parsed', got '%s'", result)
+	}
+
+	telemetry := state.Get(RetryTelemetryKey + ":parser").(RetryTelemetry)
+	var panicErr *PanicError
+	if !errors.As(telemetry.LastError, &panicErr) {
+		t.Fatalf("Expected *PanicError as last error, got %v", telemetry.LastError)
+	}
+	if panicErr.Node != "parser" || panicErr.Phase != "exec" {
+		t.Errorf("Unexpected panic identity: %s/%s", panicErr.Node, panicErr.Phase)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Error("Expected stack to be captured")
+	}
+}
+
+// TestRecoverPanicsInPost tests that post panics surface as *PanicError
+func TestRecoverPanicsInPost(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"recover_panics": true,
+	})
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		panic("finalize failed")
+	})
+
+	defer func() {
+		r := recover()
+		var panicErr *PanicError
+		if err, ok := r.(error); !ok || !errors.As(err, &panicErr) {
+			t.Fatalf("Expected *PanicError, got %T", r)
+		}
+		if panicErr.Phase != "post" || !strings.Contains(panicErr.Error(), "finalize failed") {
+			t.Errorf("Unexpected error: %v", panicErr)
+		}
+	}()
+
+	node.Run(NewSharedState())
+}
+
+// TestRecoverPanicsErrorHandling tests that recovered exec panics reach the
+// fallbacks, recovered post panics reach "post_error_action", and recovered prep
+// panics fail the node
+func TestRecoverPanicsErrorHandling(t *testing.T) {
+	stale := NewNode()
+	stale.SetExecFunc(func(prep interface{}) (interface{}, error) { return "cached", nil })
+	primary := NewNode()
+	primary.SetParams(map[string]interface{}{"recover_panics": true})
+	primary.SetExecFunc(func(prep interface{}) (interface{}, error) { panic("vendor client crashed") })
+	primary.SetFallbacks(stale)
+	if result := primary.Run(NewSharedState()); result != "cached" {
+		t.Errorf("Expected the fallback's result, got %q", result)
+	}
+
+	saver := NewNode()
+	saver.SetName("saver")
+	saver.SetParams(map[string]interface{}{"recover_panics": true, "post_error_action": "save_failed"})
+	saver.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		panic("finalize failed")
+	})
+	state := NewSharedState()
+	if action := saver.Run(state); action != "save_failed" {
+		t.Errorf("Expected the post error action, got %q", action)
+	}
+	var panicErr *PanicError
+	if err, _ := state.Get(PostErrorKey + ":saver").(error); !errors.As(err, &panicErr) || panicErr.Phase != "post" {
+		t.Errorf("Expected the post *PanicError under the post error key, got %v", state.Get(PostErrorKey+":saver"))
+	}
+
+	loader := NewNode()
+	loader.SetParams(map[string]interface{}{"recover_panics": true, "post_error_action": "load_failed"})
+	loader.SetPrepFunc(func(shared *SharedState) interface{} { panic("no input") })
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.As(err, &panicErr) || panicErr.Phase != "prep" {
+			t.Errorf("Expected the prep *PanicError to fail the node, got %v", err)
+		}
+	}()
+	loader.Run(NewSharedState())
+	t.Error("Expected the prep panic to fail the node")
+}
@@ -0,0 +1,149 @@
+package Flow
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisDialTimeout bounds connecting to a Redis server
+const redisDialTimeout = 5 * time.Second
+
+// redisCommandTimeout bounds writing a command and reading its reply
+const redisCommandTimeout = 5 * time.Second
+
+// redisClient is a minimal Redis client speaking RESP over a single connection,
+// used by the Redis-backed stores so the package needs no client dependency.
+// Commands are serialized and each must complete within timeout, so a hung
+// server fails commands instead of blocking them; a failed connection is
+// re-dialed on the next command.
+type redisClient struct {
+	addr    string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// newRedisClient returns a client for the server at addr ("host:port").
+// It connects lazily on the first command.
+func newRedisClient(addr string) *redisClient {
+	return &redisClient{addr: addr, timeout: redisCommandTimeout}
+}
+
+// do sends a command and returns its reply: a string, an int64, nil, or a
+// []interface{} of replies. Redis error replies are returned as errors.
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, redisDialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn, c.r = conn, bufio.NewReader(conn)
+	}
+
+	var reply interface{}
+	err := c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err == nil {
+		reply, err = c.roundTrip(args)
+	}
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state; start over on the next command
+		c.conn.Close()
+		c.conn, c.r = nil, nil
+	}
+	return reply, err
+}
+
+// ping checks that the server is reachable and answering commands
+func (c *redisClient) ping() error {
+	_, err := c.do("PING")
+	return err
+}
+
+// roundTrip writes args as a RESP array and reads one reply
+func (c *redisClient) roundTrip(args []string) (interface{}, error) {
+	w := bufio.NewWriter(c.conn)
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return readRedisReply(c.r)
+}
+
+// redisError is an error reply sent by the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readRedisReply reads a single RESP reply
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
+
+// redisTTLArgs returns the SET arguments expiring a key after ttl, if positive
+func redisTTLArgs(ttl time.Duration) []string {
+	if ttl <= 0 {
+		return nil
+	}
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return []string{"PX", strconv.FormatInt(ms, 10)}
+}
@@ -0,0 +1,171 @@
+package Flow
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is an in-process server implementing the few commands the Redis stores use
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]string // PX argument of each key's last SET
+	addr string
+}
+
+// startFakeRedis starts a fake Redis server that stops when the test ends
+func startFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{data: make(map[string]string), ttls: make(map[string]string), addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readRedisReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+		fmt.Fprint(conn, f.exec(args))
+	}
+}
+
+// exec runs one command and returns its RESP-encoded reply
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "EXISTS":
+		if _, ok := f.data[args[1]]; ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "GET":
+		value, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		var nx bool
+		var ttl string
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				i++
+				ttl = args[i]
+			}
+		}
+		if _, exists := f.data[args[1]]; exists && nx {
+			return "$-1\r\n"
+		}
+		f.data[args[1]] = args[2]
+		if ttl != "" {
+			f.ttls[args[1]] = ttl
+		}
+		return "+OK\r\n"
+	case "SCAN":
+		// Return every match in one page
+		var keys []string
+		match, err := globRegexp(args[3])
+		if err != nil {
+			return "-ERR " + err.Error() + "\r\n"
+		}
+		for key := range f.data {
+			if match.MatchString(key) {
+				keys = append(keys, fmt.Sprintf("$%d\r\n%s\r\n", len(key), key))
+			}
+		}
+		return fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n%s", len(keys), strings.Join(keys, ""))
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// TestRedisClientReplies tests that the client decodes replies and error replies
+func TestRedisClientReplies(t *testing.T) {
+	server := startFakeRedis(t)
+	client := newRedisClient(server.addr)
+
+	if reply, err := client.do("SET", "greeting", "hello world"); err != nil || reply != "OK" {
+		t.Fatalf("Unexpected SET reply: %v, %v", reply, err)
+	}
+	if reply, err := client.do("GET", "greeting"); err != nil || reply != "hello world" {
+		t.Errorf("Unexpected GET reply: %v, %v", reply, err)
+	}
+	if reply, err := client.do("GET", "missing"); err != nil || reply != nil {
+		t.Errorf("Expected nil for a missing key, got %v, %v", reply, err)
+	}
+	if _, err := client.do("FLUSHALL"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("Expected an error reply, got %v", err)
+	}
+	if reply, err := client.do("EXISTS", "greeting"); err != nil || reply != int64(1) {
+		t.Errorf("Expected the connection to survive an error reply, got %v, %v", reply, err)
+	}
+}
+
+// TestRedisClientCommandTimeout tests that a server that stops answering fails the command
+func TestRedisClientCommandTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		// Accept the connection but never reply
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	client := newRedisClient(ln.Addr().String())
+	client.timeout = 50 * time.Millisecond
+	if _, err := client.do("PING"); err == nil {
+		t.Fatal("Expected a command to a hung server to time out")
+	}
+	if client.conn != nil {
+		t.Error("Expected the timed-out connection to be dropped")
+	}
+	(<-accepted).Close()
+}
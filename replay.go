@@ -0,0 +1,173 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Journal records the events of a run so it can be replayed later.
+// Register it with Flow.Subscribe(journal.Record) while running in production,
+// persist it with WriteTo, and reproduce the run locally with Flow.Replay.
+//
+// Example:
+//
+//	journal := flow.NewJournal()
+//	pipeline.Subscribe(journal.Record)
+//	pipeline.Run(state)
+//
+//	// Later, with the same flow definition:
+//	action := pipeline.Replay(journal, flow.NewSharedState())
+type Journal struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewJournal creates an empty journal
+func NewJournal() *Journal {
+	return &Journal{}
+}
+
+// Record appends an event; it is safe to use as a flow subscriber
+func (j *Journal) Record(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, e)
+}
+
+// Events returns a copy of the recorded events in order
+func (j *Journal) Events() []Event {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Event(nil), j.events...)
+}
+
+// journalEvent is the JSON form of an Event; errors are stored as messages
+type journalEvent struct {
+	Type    EventType     `json:"type"`
+	Time    time.Time     `json:"time"`
+	Flow    string        `json:"flow,omitempty"`
+	Node    string        `json:"node,omitempty"`
+	Index   int           `json:"index,omitempty"`
+	Attempt int           `json:"attempt,omitempty"`
+	Delay   time.Duration `json:"delay,omitempty"`
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+	Result  interface{}   `json:"result,omitempty"`
+	Err     string        `json:"error,omitempty"`
+	Action  string        `json:"action,omitempty"`
+}
+
+// WriteTo writes the journal to w as JSON lines. Errors are persisted as their
+// messages, and results as JSON, so numeric results read back as float64.
+// Results and errors pass through the redactors registered with AddRedactor.
+func (j *Journal) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	enc := json.NewEncoder(cw)
+	for _, e := range j.Events() {
+		je := journalEvent{
+			Type: e.Type, Time: e.Time, Flow: e.Flow, Node: e.Node, Index: e.Index,
+			Attempt: e.Attempt, Delay: e.Delay, Elapsed: e.Elapsed, Result: redact("", e.Result), Action: e.Action,
+		}
+		if e.Err != nil {
+			je.Err = redactError(e.Err)
+		}
+		if err := enc.Encode(je); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// ReadJournal reads a journal written by WriteTo
+func ReadJournal(r io.Reader) (*Journal, error) {
+	j := NewJournal()
+	dec := json.NewDecoder(r)
+	for {
+		var je journalEvent
+		if err := dec.Decode(&je); err == io.EOF {
+			return j, nil
+		} else if err != nil {
+			return nil, err
+		}
+		e := Event{
+			Type: je.Type, Time: je.Time, Flow: je.Flow, Node: je.Node, Index: je.Index,
+			Attempt: je.Attempt, Delay: je.Delay, Elapsed: je.Elapsed, Result: je.Result, Action: je.Action,
+		}
+		if je.Err != "" {
+			e.Err = errors.New(je.Err)
+		}
+		j.events = append(j.events, e)
+	}
+}
+
+// countingWriter tracks bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// replayKey identifies one exec attempt within a run
+type replayKey struct {
+	node    string
+	index   int
+	attempt int
+}
+
+// replayer serves recorded exec outcomes in the order they were recorded
+type replayer struct {
+	mu       sync.Mutex
+	outcomes map[replayKey][]Event
+}
+
+type replayerKey struct{}
+
+// Replay re-runs the flow with every exec call stubbed by the outcome recorded in
+// the journal, so a production failure can be reproduced step by step. Prep and
+// post functions run normally against the given state, retry backoff is skipped,
+// and subscribers observe the replayed run as usual. Nodes are matched by name,
+// so flows should name their nodes; a node visited several times consumes its
+// recorded attempts in order. Replay panics if the journal has no outcome for an
+// exec attempt, which indicates the flow definition has diverged.
+func (f *Flow) Replay(journal *Journal, shared *SharedState) string {
+	r := &replayer{outcomes: make(map[replayKey][]Event)}
+	for _, e := range journal.Events() {
+		if e.Type == EventExecAttempt {
+			key := replayKey{node: e.Node, index: e.Index, attempt: e.Attempt}
+			r.outcomes[key] = append(r.outcomes[key], e)
+		}
+	}
+
+	ctx := context.WithValue(context.Background(), replayerKey{}, r)
+	action, _, _ := f.orchestrate(ctx, shared, f.startNode, nil)
+	return action
+}
+
+// replayerFrom returns the replayer carried by ctx, or nil outside of Replay
+func replayerFrom(ctx context.Context) *replayer {
+	r, _ := ctx.Value(replayerKey{}).(*replayer)
+	return r
+}
+
+// next returns the recorded outcome for an exec attempt
+func (r *replayer) next(node string, meta ItemMeta) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := replayKey{node: node, index: meta.Index, attempt: meta.Attempt}
+	queue := r.outcomes[key]
+	if len(queue) == 0 {
+		panic(fmt.Errorf("flow: replay journal has no exec attempt for node %q item %d attempt %d", node, meta.Index, meta.Attempt))
+	}
+	r.outcomes[key] = queue[1:]
+	return queue[0].Result, queue[0].Err
+}
@@ -0,0 +1,117 @@
+package Flow
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestReplayFromJournal tests that a replayed run reproduces recorded exec outcomes
+func TestReplayFromJournal(t *testing.T) {
+	live := true
+	counter := &mockCounter{}
+
+	fetch := NewNode()
+	fetch.SetName("fetch")
+	fetch.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if !live {
+			t.Error("Expected exec not to be called during replay")
+		}
+		if counter.increment() == 1 {
+			return nil, fmt.Errorf("upstream timeout")
+		}
+		return "payload", nil
+	})
+	fetch.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("fetched", exec)
+		return "done"
+	})
+
+	pipeline := NewFlow().Start(fetch)
+	pipeline.SetParams(map[string]interface{}{
+		"retries":     2,
+		"retry_delay": time.Millisecond * 50,
+	})
+
+	journal := NewJournal()
+	pipeline.Subscribe(journal.Record)
+	pipeline.Run(NewSharedState())
+
+	live = false
+	state := NewSharedState()
+	start := time.Now()
+	action := pipeline.Replay(journal, state)
+
+	if action != "done" {
+		t.Errorf("Expected action 'done', got '%s'", action)
+	}
+	if state.Get("fetched") != "payload" {
+		t.Errorf("Expected replayed result 'payload', got %v", state.Get("fetched"))
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*40 {
+		t.Errorf("Expected replay to skip backoff, took %v", elapsed)
+	}
+}
+
+// TestReplayPersistedJournal tests replaying a journal read back from JSON lines
+func TestReplayPersistedJournal(t *testing.T) {
+	node := NewNode()
+	node.SetName("score")
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) == 2 {
+			return nil, fmt.Errorf("bad item")
+		}
+		return item.(int) * 10, nil
+	})
+
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{
+		"data":  []int{1, 2, 3},
+		"batch": true,
+	})
+
+	journal := NewJournal()
+	pipeline.Subscribe(journal.Record)
+	func() {
+		defer func() { _ = recover() }()
+		pipeline.Run(NewSharedState())
+	}()
+
+	var buf bytes.Buffer
+	if _, err := journal.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	restored, err := ReadJournal(&buf)
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		t.Error("Expected exec not to be called during replay")
+		return nil, nil
+	})
+	defer func() {
+		r := recover()
+		if err, _ := r.(error); nodeErrorCause(err) != "bad item" {
+			t.Errorf("Expected replayed failure 'bad item', got %v", r)
+		}
+	}()
+	pipeline.Replay(restored, NewSharedState())
+}
+
+// TestReplayDivergedFlow tests that replay panics when the journal has no matching attempt
+func TestReplayDivergedFlow(t *testing.T) {
+	node := NewNode()
+	node.SetName("renamed")
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic for a node missing from the journal")
+		}
+	}()
+	NewFlow().Start(node).Replay(NewJournal(), NewSharedState())
+}
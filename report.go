@@ -0,0 +1,157 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RunReport describes one run of a flow, as returned by Flow.RunReport
+type RunReport struct {
+	// Action is the last action of the run, or "" if a node failed
+	Action string
+	// Path lists every node run in the order they started, including loop
+	// revisits and fan-in branches
+	Path []NodeReport
+	// Duration is the wall time of the run
+	Duration time.Duration
+	// Retries is the total number of retries across the run
+	Retries int
+	// Warnings describe trouble the run survived, such as retried calls, failed
+	// batch items under "continue_on_error", and shed or suppressed nodes
+	Warnings []string
+	// Err is the failure that ended the run, or nil if it completed
+	Err error
+}
+
+// NodeReport describes one run of a node within a RunReport
+type NodeReport struct {
+	Node     string
+	Started  time.Time
+	Duration time.Duration
+	// Attempts is the number of exec calls made, across batch items
+	Attempts int
+	// Retries is the number of retries scheduled, across batch items
+	Retries int
+	// FailedItems is the number of batch items that failed
+	FailedItems int
+	// Action is the action the node returned ("" if it failed)
+	Action string
+	// Err is the exec error of the last failed attempt, if any
+	Err error
+}
+
+// RunReport runs the flow like Run and reports what happened: the terminal
+// action, the ordered path of nodes with their durations and retries, warnings,
+// and the error that ended the run, if any. Panics from node execution are
+// recovered into Err rather than propagated.
+//
+// Example:
+//
+//	report := pipeline.RunReport(state)
+//	if report.Err != nil {
+//		log.Printf("failed after %v at %s: %v", report.Duration, report.Path[len(report.Path)-1].Node, report.Err)
+//	}
+//	for _, warning := range report.Warnings {
+//		log.Println("warning:", warning)
+//	}
+func (f *Flow) RunReport(shared *SharedState) (report *RunReport) {
+	rec := &reportRecorder{open: make(map[string][]int)}
+	start := time.Now()
+	defer func() {
+		err := panicToError(recover())
+		report = rec.finish(time.Since(start), err)
+	}()
+
+	ctx := withSubscribers(context.Background(), []func(Event){rec.event})
+	rec.report.Action, rec.report.Err = f.runContext(ctx, shared, nil)
+	return nil
+}
+
+// reportRecorder builds a RunReport from the events of a run
+type reportRecorder struct {
+	mu     sync.Mutex
+	report RunReport
+	// open holds the Path indexes of the running visits of each node, innermost last
+	open map[string][]int
+}
+
+func (r *reportRecorder) event(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.Type == EventNodeStarted {
+		r.open[e.Node] = append(r.open[e.Node], len(r.report.Path))
+		r.report.Path = append(r.report.Path, NodeReport{Node: e.Node, Started: e.Time})
+		return
+	}
+	visits := r.open[e.Node]
+	if len(visits) == 0 {
+		return
+	}
+	node := &r.report.Path[visits[len(visits)-1]]
+
+	switch e.Type {
+	case EventExecAttempt:
+		node.Attempts++
+		if e.Err != nil {
+			node.Err = e.Err
+		}
+	case EventRetryScheduled:
+		node.Retries++
+		r.report.Retries++
+	case EventBatchItemDone:
+		if e.Err != nil {
+			node.FailedItems++
+		}
+	case EventActionChosen:
+		node.Duration = e.Time.Sub(node.Started)
+		node.Action = e.Action
+		r.open[e.Node] = visits[:len(visits)-1]
+	}
+}
+
+// finish completes the report once the run has ended
+func (r *reportRecorder) finish(elapsed time.Duration, panicked error) *RunReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := r.report
+	report.Duration = elapsed
+	if panicked != nil {
+		report.Err = panicked
+	}
+	failed := make(map[int]bool)
+	for _, visits := range r.open {
+		// Visits still open when the run ended failed or were interrupted
+		for _, i := range visits {
+			report.Path[i].Duration = time.Since(report.Path[i].Started)
+			failed[i] = true
+		}
+	}
+	for i := range report.Path {
+		if !failed[i] {
+			report.Warnings = append(report.Warnings, nodeWarnings(&report.Path[i])...)
+		}
+	}
+	return &report
+}
+
+// nodeWarnings describes the trouble a node survived
+func nodeWarnings(node *NodeReport) []string {
+	name := debugNodeName(node.Node)
+	var warnings []string
+	switch node.Action {
+	case ShedAction:
+		warnings = append(warnings, fmt.Sprintf("%s: shed by its bulkhead", name))
+	case SuppressedAction:
+		warnings = append(warnings, fmt.Sprintf("%s: suppressed by debounce", name))
+	}
+	if node.FailedItems > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: %d batch items failed, last error: %s", name, node.FailedItems, redactError(node.Err)))
+	} else if node.Retries > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: needed %d retries, last error: %s", name, node.Retries, redactError(node.Err)))
+	}
+	return warnings
+}
@@ -0,0 +1,73 @@
+package Flow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunReport tests the path, retries and warnings of a completed run
+func TestRunReport(t *testing.T) {
+	attempts := 0
+	fetch := NewNode()
+	fetch.SetName("fetch")
+	fetch.SetExecFunc(func(interface{}) (interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("timeout")
+		}
+		return "parse", nil
+	})
+	parse := NewNode()
+	parse.SetName("parse")
+	parse.SetExecFunc(func(interface{}) (interface{}, error) {
+		time.Sleep(5 * time.Millisecond)
+		return "done", nil
+	})
+	fetch.Next(parse, "parse")
+
+	pipeline := NewFlow().Start(fetch)
+	pipeline.SetParams(map[string]interface{}{"retries": 2, "retry_delay": time.Millisecond})
+	report := pipeline.RunReport(NewSharedState())
+
+	if report.Err != nil || report.Action != "done" {
+		t.Fatalf("Expected a completed run ending in done, got %q, %v", report.Action, report.Err)
+	}
+	if len(report.Path) != 2 || report.Path[0].Node != "fetch" || report.Path[1].Node != "parse" {
+		t.Fatalf("Expected the path fetch, parse, got %+v", report.Path)
+	}
+	if report.Path[0].Attempts != 2 || report.Path[0].Retries != 1 || report.Retries != 1 {
+		t.Errorf("Expected one retry of fetch, got %+v", report.Path[0])
+	}
+	if report.Path[1].Duration < 5*time.Millisecond || report.Duration < report.Path[1].Duration {
+		t.Errorf("Expected node and run durations, got %v of %v", report.Path[1].Duration, report.Duration)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "fetch: needed 1 retries") {
+		t.Errorf("Expected a retry warning, got %v", report.Warnings)
+	}
+}
+
+// TestRunReportFailure tests that a failing run is reported instead of panicking
+func TestRunReportFailure(t *testing.T) {
+	first := NewNode()
+	first.SetName("first")
+	broken := NewNode()
+	broken.SetName("broken")
+	broken.SetExecFunc(func(interface{}) (interface{}, error) {
+		return nil, errors.New("disk full")
+	})
+	first.Next(broken, DefaultAction)
+
+	report := NewFlow().Start(first).RunReport(NewSharedState())
+	var nodeErr *NodeError
+	if !errors.As(report.Err, &nodeErr) || nodeErr.Node != "broken" {
+		t.Fatalf("Expected a NodeError from broken, got %v", report.Err)
+	}
+	if len(report.Path) != 2 || report.Path[1].Action != "" || report.Path[1].Err == nil {
+		t.Errorf("Expected the failed visit to be recorded, got %+v", report.Path)
+	}
+	if report.Action != "" {
+		t.Errorf("Expected no terminal action, got %q", report.Action)
+	}
+}
@@ -0,0 +1,142 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// BatchProgress records the completed items of a batch node with a "resume_key"
+// param. It is stored in SharedState under the resume key while the batch runs, so
+// when a large batch fails or is interrupted, running the node again on the same
+// state (for example after restoring a persisted checkpoint) skips the items that
+// already completed and reuses their results. The progress is cleared once the
+// whole batch completes. The batch data must be unchanged between runs.
+//
+// Progress encodes as a JSON object of completed index to result, so it survives
+// WriteSnapshot and ReadSnapshot; the results then read back as JSON types.
+//
+// Example:
+//
+//	node.SetParams(map[string]interface{}{
+//		"data":       urls,
+//		"batch":      true,
+//		"parallel":   true,
+//		"resume_key": "crawl_progress",
+//	})
+//	// After a failure, re-running the node only fetches the remaining URLs
+//	done := state.Get("crawl_progress").(*flow.BatchProgress).Completed()
+type BatchProgress struct {
+	mu      sync.Mutex
+	results map[int]interface{}
+}
+
+// Done reports whether the item at index has completed
+func (p *BatchProgress) Done(index int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, done := p.results[index]
+	return done
+}
+
+// Completed returns the number of completed items
+func (p *BatchProgress) Completed() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.results)
+}
+
+// MarshalJSON encodes the progress as an object of completed index to result
+func (p *BatchProgress) MarshalJSON() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	results := make(map[string]interface{}, len(p.results))
+	for index, result := range p.results {
+		results[strconv.Itoa(index)] = result
+	}
+	return json.Marshal(results)
+}
+
+// UnmarshalJSON decodes progress encoded by MarshalJSON
+func (p *BatchProgress) UnmarshalJSON(data []byte) error {
+	var results map[string]interface{}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return err
+	}
+	decoded, err := progressFromMap(results)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = decoded.results
+	return nil
+}
+
+// progressFromMap rebuilds progress from its decoded JSON object
+func progressFromMap(results map[string]interface{}) (*BatchProgress, error) {
+	progress := &BatchProgress{results: make(map[int]interface{}, len(results))}
+	for key, result := range results {
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("flow: invalid batch progress index %q", key)
+		}
+		progress.results[index] = normalizeJSON(result)
+	}
+	return progress, nil
+}
+
+// result returns the stored result of a completed item.
+// A nil progress has no completed items.
+func (p *BatchProgress) result(index int) (interface{}, bool) {
+	if p == nil {
+		return nil, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, done := p.results[index]
+	return result, done
+}
+
+// complete records the result of an item
+func (p *BatchProgress) complete(index int, result interface{}) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results[index] = result
+}
+
+// batchProgress returns the progress stored under the node's "resume_key",
+// creating it on the first run, or nil if the node has no resume key. Progress
+// read back from a snapshot as a JSON object is restored in place.
+func (n *Node) batchProgress(ctx context.Context, shared *SharedState) *BatchProgress {
+	key := n.getStringParam(ctx, "resume_key")
+	if key == "" {
+		return nil
+	}
+	switch stored := shared.Get(key).(type) {
+	case *BatchProgress:
+		return stored
+	case map[string]interface{}:
+		progress, err := progressFromMap(stored)
+		if err != nil {
+			panic(err)
+		}
+		shared.Set(key, progress)
+		return progress
+	}
+	progress := &BatchProgress{results: make(map[int]interface{})}
+	shared.Set(key, progress)
+	return progress
+}
+
+// clearProgress removes the progress of a completed batch so the next run starts over
+func (n *Node) clearProgress(ctx context.Context, shared *SharedState) {
+	if key := n.getStringParam(ctx, "resume_key"); key != "" {
+		shared.Set(key, nil)
+	}
+}
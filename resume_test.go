@@ -0,0 +1,116 @@
+package Flow
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestBatchResume tests that a re-run batch skips the items completed by a failed run
+func TestBatchResume(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			state := NewSharedState()
+			var mu sync.Mutex
+			calls := map[int]int{}
+			outage := true
+
+			node := NewNode()
+			node.SetParams(map[string]interface{}{
+				"data":       []int{0, 1, 2, 3, 4},
+				"batch":      true,
+				"parallel":   parallel,
+				"resume_key": "progress",
+			})
+			node.SetExecFunc(func(item interface{}) (interface{}, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				i := item.(int)
+				calls[i]++
+				if i == 3 && outage {
+					return nil, fmt.Errorf("dependency down")
+				}
+				return i * 10, nil
+			})
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatal("Expected the first run to fail")
+					}
+				}()
+				node.Run(state)
+			}()
+
+			progress := state.Get("progress").(*BatchProgress)
+			if progress.Done(3) || !progress.Done(0) {
+				t.Errorf("Expected item 0 done and item 3 pending, got %d completed", progress.Completed())
+			}
+
+			outage = false
+			node.Run(state)
+
+			for i, count := range calls {
+				if i != 3 && count != 1 {
+					t.Errorf("Expected item %d to run once, ran %d times", i, count)
+				}
+			}
+			if calls[3] != 2 {
+				t.Errorf("Expected the failed item to be retried on resume, ran %d times", calls[3])
+			}
+			results := state.Get(BatchResultsKey).([]interface{})
+			if fmt.Sprint(results) != "[0 10 20 30 40]" {
+				t.Errorf("Expected results of both runs, got %v", results)
+			}
+			if state.Get("progress") != nil {
+				t.Error("Expected progress to be cleared once the batch completed")
+			}
+		})
+	}
+}
+
+// TestBatchResumeFromSnapshot tests that batch progress survives a persisted snapshot
+func TestBatchResumeFromSnapshot(t *testing.T) {
+	calls := map[int]int{}
+	outage := true
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":       []int{0, 1, 2, 3},
+		"batch":      true,
+		"resume_key": "progress",
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		i := item.(int)
+		calls[i]++
+		if i == 2 && outage {
+			return nil, fmt.Errorf("dependency down")
+		}
+		return map[string]interface{}{"id": i}, nil
+	})
+
+	state := NewSharedState()
+	func() {
+		defer func() { recover() }()
+		node.Run(state)
+	}()
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, state, nil); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadSnapshot(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outage = false
+	node.Run(restored)
+	if calls[0] != 1 || calls[1] != 1 || calls[2] != 2 || calls[3] != 1 {
+		t.Errorf("Expected only the failed and pending items to run again, got %v", calls)
+	}
+	results := restored.Get(BatchResultsKey).([]interface{})
+	if fmt.Sprint(results) != "[map[id:0] map[id:1] map[id:2] map[id:3]]" {
+		t.Errorf("Expected the restored results in order, got %v", results)
+	}
+}
@@ -0,0 +1,492 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryTelemetryKey is the SharedState key under which retrying nodes store their
+// RetryTelemetry. Named nodes use RetryTelemetryKey + ":" + name so that several
+// retrying nodes in the same flow don't overwrite each other's telemetry.
+const RetryTelemetryKey = "retry_telemetry"
+
+// RetryTelemetry describes the retry activity of a single node execution.
+// It is stored in SharedState before the post phase runs (and before a panic on
+// retry exhaustion), so post-processing and alerting can distinguish first-try
+// successes from executions that barely survived.
+//
+// For batch executions the telemetry is aggregated across all items.
+//
+// Example:
+//
+//	telemetry := state.Get(flow.RetryTelemetryKey).(flow.RetryTelemetry)
+//	if telemetry.Attempts > 1 {
+//		log.Printf("succeeded after %d attempts: %v", telemetry.Attempts, telemetry.LastError)
+//	}
+type RetryTelemetry struct {
+	// Attempts is the number of exec calls made
+	Attempts int
+	// Backoff is the total time spent sleeping between attempts
+	Backoff time.Duration
+	// LastError is the most recent error returned by exec, or nil if every attempt succeeded
+	LastError error
+}
+
+// retryTelemetryKey returns the state key holding telemetry for the named node
+func retryTelemetryKey(name string) string {
+	if name == "" {
+		return RetryTelemetryKey
+	}
+	return RetryTelemetryKey + ":" + name
+}
+
+// retryRecorder accumulates RetryTelemetry, safely across parallel batch items.
+// A recorder with a parent also accumulates into it, for per-item accounting.
+type retryRecorder struct {
+	mu        sync.Mutex
+	telemetry RetryTelemetry
+	parent    *retryRecorder
+}
+
+func (r *retryRecorder) attempt(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.telemetry.Attempts++
+	if err != nil {
+		r.telemetry.LastError = err
+	}
+	r.mu.Unlock()
+	r.parent.attempt(err)
+}
+
+func (r *retryRecorder) slept(d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.telemetry.Backoff += d
+	r.mu.Unlock()
+	r.parent.slept(d)
+}
+
+// store writes the accumulated telemetry to shared state under the node's key
+func (r *retryRecorder) store(shared *SharedState, name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	shared.Set(retryTelemetryKey(name), r.telemetry)
+}
+
+// newRetryRecorder returns a recorder when retries are configured, nil otherwise
+func newRetryRecorder(retries int) *retryRecorder {
+	if retries <= 0 {
+		return nil
+	}
+	return &retryRecorder{}
+}
+
+const (
+	// JitterFull sleeps a random duration between zero and the exponential backoff
+	JitterFull = "full"
+	// JitterEqual sleeps half the exponential backoff plus a random duration up to the other half
+	JitterEqual = "equal"
+	// JitterDecorrelated sleeps a random duration between retry_delay and three times the previous sleep
+	JitterDecorrelated = "decorrelated"
+)
+
+const (
+	defaultRetryMultiplier = 2.0
+	defaultRetryJitter     = 0.1
+	decorrelatedGrowth     = 3.0
+)
+
+// retryPolicy holds the retry configuration resolved from a node's parameters
+type retryPolicy struct {
+	retries    int
+	delay      time.Duration
+	multiplier float64
+	maxDelay   time.Duration
+	jitter     float64
+	strategy   string
+	maxElapsed time.Duration
+	budget     *retryBudget
+}
+
+// retryBudget caps the total number of retries shared by every item of one execution
+type retryBudget struct {
+	remaining int64
+}
+
+// take consumes one retry from the budget, reporting false once it is spent.
+// A nil budget is unlimited.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	return atomic.AddInt64(&b.remaining, -1) >= 0
+}
+
+// retryPolicy resolves the node's retry parameters, applying defaults for unset values.
+// It is resolved once per execution so that a "retry_budget" is shared by all of
+// the execution's batch items.
+func (n *Node) retryPolicy(ctx context.Context) retryPolicy {
+	policy := retryPolicy{
+		retries:    n.getIntParam(ctx, "retries"),
+		delay:      n.getDurationParam(ctx, "retry_delay"),
+		multiplier: defaultRetryMultiplier,
+		maxDelay:   n.getDurationParam(ctx, "retry_max_delay"),
+		jitter:     defaultRetryJitter,
+		strategy:   n.getStringParam(ctx, "retry_jitter_strategy"),
+		maxElapsed: n.getDurationParam(ctx, "retry_max_elapsed"),
+	}
+	if m, ok := n.getFloatParam(ctx, "retry_multiplier"); ok && m > 0 {
+		policy.multiplier = m
+	}
+	if j, ok := n.getFloatParam(ctx, "retry_jitter"); ok && j >= 0 {
+		policy.jitter = j
+	}
+	if budget, ok := n.param(ctx, "retry_budget").(int); ok {
+		policy.budget = &retryBudget{remaining: int64(budget)}
+	}
+	return policy
+}
+
+// execWithRetry calls exec on input up to policy.retries times (at least once),
+// sleeping with exponential backoff and jitter between failed attempts. Each
+// retry consumes one unit of the shared retry budget, if any; once the budget is
+// spent, or the error is not retryable (see IsRetryable), the failure is returned
+// immediately. It returns the first successful
+// result or the last error, wrapped as a *NodeError that matches
+// ErrRetriesExhausted when retries were configured. Retries also stop once the
+// next attempt would start more than "retry_max_elapsed" after the first. A
+// retry-after hint on the
+// error replaces the computed backoff. Inputs already marked in
+// the node's "dedup" store are skipped with a nil result, and inputs with a
+// cached result reuse it when "cache" is set. Once ctx is done a pending backoff
+// is abandoned and the error wraps ctx.Err().
+func (n *Node) execWithRetry(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta, policy retryPolicy, rec *retryRecorder) (interface{}, error) {
+	call := n.newExecCall(ctx, shared, input, meta, policy, rec)
+	for !call.done {
+		if wait := call.step(); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				call.abort()
+			}
+		}
+	}
+	return call.result, call.err
+}
+
+// execCall is one exec input making its way through its attempts. Stepping it
+// one attempt at a time lets parallel batches park items between retries on a
+// timer instead of a sleeping goroutine.
+type execCall struct {
+	n      *Node
+	exec   *Node // the node whose exec function is being attempted: n or a fallback
+	ctx    context.Context
+	shared *SharedState
+	input  interface{}
+	meta   ItemMeta
+	policy retryPolicy
+	rec    *retryRecorder
+	replay *replayer
+
+	dedup    Dedup
+	dedupKey string
+	reserved bool // the dedup key is reserved until the call completes
+	cacheKey string
+	cached   bool
+
+	retries int
+	attempt int
+	delay   time.Duration
+	started time.Time // first attempt under the current policy
+
+	fallbacks []*Node // fallbacks not tried yet
+	failures  []error // final errors of the exec functions tried so far
+
+	result interface{}
+	err    error
+	done   bool
+}
+
+// newExecCall prepares the exec call for input, completing it immediately when
+// the input is deduplicated, its result is cached, or it doesn't fit the model's
+// context window
+func (n *Node) newExecCall(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta, policy retryPolicy, rec *retryRecorder) *execCall {
+	c := &execCall{
+		n: n, exec: n, ctx: withStream(ctx), shared: shared, input: input, meta: meta, rec: rec,
+		replay:    replayerFrom(ctx),
+		fallbacks: n.fallbacks,
+	}
+	c.usePolicy(policy)
+
+	// Prompts that don't fit the model's context window never reach exec
+	fitted, err := n.fitContext(ctx, input)
+	if err != nil {
+		c.err, c.done = n.execError(ctx, meta, err), true
+		return c
+	}
+	c.input = fitted
+
+	if c.replay == nil {
+		// Items whose side effects already happened are skipped with a nil result
+		if c.dedup, c.dedupKey = n.dedup(ctx, input); c.dedup != nil && !c.reserve() {
+			c.done = true
+			return c
+		}
+	}
+
+	// Cached results are reused without calling exec
+	if c.cacheKey, c.cached = n.cacheKey(ctx, input); c.cached {
+		if result, ok := n.cachedResult(ctx, shared, input, meta, c.cacheKey); ok {
+			c.result, c.done = result, true
+			c.release()
+		}
+	}
+	return c
+}
+
+// reserve claims the call's dedup key, reporting false if the input was already
+// processed. Stores implementing DedupReserver claim it atomically; others are
+// only checked with Seen.
+func (c *execCall) reserve() bool {
+	if reserver, ok := c.dedup.(DedupReserver); ok {
+		c.reserved = reserver.Reserve(c.dedupKey)
+		return c.reserved
+	}
+	return !c.dedup.Seen(c.dedupKey)
+}
+
+// release frees the call's dedup reservation, if any, so the input stays
+// eligible after a failure
+func (c *execCall) release() {
+	if c.reserved {
+		c.reserved = false
+		c.dedup.(DedupReserver).Release(c.dedupKey)
+	}
+}
+
+// abort gives up on the call's remaining attempts because ctx is done
+func (c *execCall) abort() {
+	c.release()
+	c.result = nil
+	c.err = c.exec.execError(c.ctx, c.meta, c.ctx.Err())
+	c.done = true
+}
+
+// aborted reports whether err is an abort of a call because ctx is done
+func aborted(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// usePolicy restarts the call's attempts under policy
+func (c *execCall) usePolicy(policy retryPolicy) {
+	c.policy = policy
+	c.retries = policy.retries
+	if c.retries <= 0 {
+		c.retries = 1
+	}
+	c.attempt, c.delay = 0, 0
+	c.started = time.Now()
+	c.meta.MaxAttempts = c.retries
+}
+
+// callExec makes one call of the exec function being attempted, releasing the
+// dedup reservation if it panics
+func (c *execCall) callExec() (interface{}, error) {
+	if c.reserved {
+		defer func() {
+			if r := recover(); r != nil {
+				c.release()
+				panic(r)
+			}
+		}()
+	}
+	return c.exec.callExec(c.ctx, c.shared, c.input, c.meta)
+}
+
+// step makes the next attempt. If it failed and may be retried, step returns how
+// long to wait before the next step; otherwise the call is done.
+func (c *execCall) step() time.Duration {
+	n := c.exec
+	c.meta.Attempt = c.attempt + 1
+	if c.replay != nil {
+		c.result, c.err = c.replay.next(n.name, c.meta)
+	} else {
+		c.result, c.err = c.callExec()
+	}
+	c.rec.attempt(c.err)
+	n.emit(c.ctx, Event{Type: EventExecAttempt, Index: c.meta.Index, Attempt: c.meta.Attempt, Result: c.result, Err: c.err})
+	if c.err == nil {
+		if c.dedup != nil {
+			c.dedup.Mark(c.dedupKey)
+			c.reserved = false
+		}
+		// Results of fallbacks are degraded and not cached as the primary's
+		if c.cached && n == c.n {
+			n.storeResult(c.ctx, c.cacheKey, c.result)
+		}
+		c.done = true
+		return 0
+	}
+	// Errors classified as permanent fail without spending retries
+	if !IsRetryable(c.err) {
+		c.fail(n.execError(c.ctx, c.meta, c.err))
+		return 0
+	}
+	if c.attempt == c.retries-1 {
+		c.exhaust()
+		return 0
+	}
+
+	if c.policy.delay > 0 {
+		c.delay = c.policy.backoff(c.attempt, c.delay)
+	}
+	// A retry-after hint from the failed call overrides the computed backoff
+	if hint, ok := retryAfterHint(c.err); ok {
+		c.delay = hint
+	}
+	if c.pastMaxElapsed() || !c.policy.budget.take() {
+		c.exhaust()
+		return 0
+	}
+	n.emit(c.ctx, Event{Type: EventRetryScheduled, Index: c.meta.Index, Attempt: c.meta.Attempt, Delay: c.delay, Err: c.err})
+	c.attempt++
+	if c.delay <= 0 || c.replay != nil {
+		return 0
+	}
+	c.rec.slept(c.delay)
+	return c.delay
+}
+
+// exhaust fails the attempted exec function once it may not be retried again
+func (c *execCall) exhaust() {
+	nodeErr := c.exec.execError(c.ctx, c.meta, c.err)
+	if c.policy.retries > 0 {
+		nodeErr.kinds = append(nodeErr.kinds, ErrRetriesExhausted)
+	}
+	c.fail(nodeErr)
+}
+
+// pastMaxElapsed reports whether the next attempt, after the pending delay,
+// would start past the policy's "retry_max_elapsed". Replays ignore the limit so
+// they repeat the recorded attempts.
+func (c *execCall) pastMaxElapsed() bool {
+	if c.policy.maxElapsed <= 0 || c.replay != nil {
+		return false
+	}
+	return time.Since(c.started)+c.delay > c.policy.maxElapsed
+}
+
+// RetryAfterError wraps an exec error with the delay the failing dependency asked
+// for, such as the Retry-After header of an HTTP 429 or 503 response. Retrying
+// nodes wait that long before the next attempt instead of the computed backoff;
+// any error in the chain with a RetryAfter() time.Duration method is honored the
+// same way.
+//
+// Example:
+//
+//	if resp.StatusCode == http.StatusTooManyRequests {
+//		return nil, &flow.RetryAfterError{Err: errRateLimited, Delay: flow.ParseRetryAfter(resp.Header.Get("Retry-After"))}
+//	}
+type RetryAfterError struct {
+	// Err is the underlying error
+	Err error
+	// Delay is the wait requested before the next attempt
+	Delay time.Duration
+}
+
+// Error returns the underlying error's message
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the requested delay
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.Delay
+}
+
+// ParseRetryAfter parses a Retry-After header value, given either in seconds or
+// as an HTTP date. It returns zero for an empty, invalid or past value.
+func ParseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryAfterHint returns the delay requested by an error in err's chain, if any
+func retryAfterHint(err error) (time.Duration, bool) {
+	var hinter interface{ RetryAfter() time.Duration }
+	if !errors.As(err, &hinter) {
+		return 0, false
+	}
+	if d := hinter.RetryAfter(); d > 0 {
+		return d, true
+	}
+	return 0, false
+}
+
+// backoff computes the wait before the attempt following the given one.
+// prev is the previous wait (zero before the first retry) and is only used by
+// the decorrelated strategy.
+func (p retryPolicy) backoff(attempt int, prev time.Duration) time.Duration {
+	if p.strategy == JitterDecorrelated {
+		// Decorrelated jitter: random between retry_delay and 3 * previous sleep
+		upper := float64(prev) * decorrelatedGrowth
+		if upper < float64(p.delay) {
+			upper = float64(p.delay)
+		}
+		delay := float64(p.delay) + secureRandFloat64()*(upper-float64(p.delay))
+		return p.capDelay(delay)
+	}
+
+	// Exponential backoff: retry_delay * (retry_multiplier^attempt), capped at retry_max_delay
+	delay := p.capDelay(float64(p.delay) * math.Pow(p.multiplier, float64(attempt)))
+
+	switch p.strategy {
+	case JitterFull:
+		return time.Duration(secureRandFloat64() * float64(delay))
+	case JitterEqual:
+		half := float64(delay) / 2
+		return time.Duration(half + secureRandFloat64()*half)
+	default:
+		// Add jitter (up to retry_jitter of the backoff delay)
+		return p.capDelay(float64(delay) + secureRandFloat64()*float64(delay)*p.jitter)
+	}
+}
+
+// capDelay bounds delay by retry_max_delay when one is configured
+func (p retryPolicy) capDelay(delay float64) time.Duration {
+	if p.maxDelay > 0 && delay > float64(p.maxDelay) {
+		delay = float64(p.maxDelay)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,449 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRetryTelemetry tests that attempts, backoff, and last error are recorded in state
+func TestRetryTelemetry(t *testing.T) {
+	state := NewSharedState()
+	counter := &mockCounter{}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"retries":     3,
+		"retry_delay": time.Millisecond * 5,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		if counter.increment() < 3 {
+			return nil, fmt.Errorf("transient failure")
+		}
+		return "ok", nil
+	})
+
+	var seen RetryTelemetry
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		seen = shared.Get(RetryTelemetryKey).(RetryTelemetry)
+		return exec.(string)
+	})
+
+	node.Run(state)
+
+	if seen.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", seen.Attempts)
+	}
+	if seen.Backoff < time.Millisecond*15 {
+		t.Errorf("Expected at least 15ms of backoff, got %v", seen.Backoff)
+	}
+	if seen.LastError == nil || seen.LastError.Error() != "transient failure" {
+		t.Errorf("Expected last error 'transient failure', got %v", seen.LastError)
+	}
+}
+
+// TestRetryTelemetryNamedBatch tests aggregated telemetry for a named parallel batch
+func TestRetryTelemetryNamedBatch(t *testing.T) {
+	state := NewSharedState()
+
+	node := NewNode()
+	node.SetName("fetch")
+	node.SetParams(map[string]interface{}{
+		"data":     []int{1, 2, 3},
+		"batch":    true,
+		"parallel": true,
+		"retries":  2,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return item, nil
+	})
+
+	node.Run(state)
+
+	telemetry, ok := state.Get(RetryTelemetryKey + ":fetch").(RetryTelemetry)
+	if !ok {
+		t.Fatal("Expected telemetry under the node's named key")
+	}
+	if telemetry.Attempts != 3 {
+		t.Errorf("Expected 3 attempts (first-try successes), got %d", telemetry.Attempts)
+	}
+	if telemetry.LastError != nil {
+		t.Errorf("Expected no error, got %v", telemetry.LastError)
+	}
+}
+
+// TestRetryTelemetryOnExhaustion tests that telemetry is stored before the failure panic
+func TestRetryTelemetryOnExhaustion(t *testing.T) {
+	state := NewSharedState()
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":     []int{1, 2},
+		"batch":    true,
+		"parallel": true,
+		"retries":  2,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("item %v failed", item)
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic after retry exhaustion")
+			}
+		}()
+		node.Run(state)
+	}()
+
+	telemetry := state.Get(RetryTelemetryKey).(RetryTelemetry)
+	if telemetry.Attempts != 4 {
+		t.Errorf("Expected 4 attempts, got %d", telemetry.Attempts)
+	}
+}
+
+// TestBackoffConfiguration tests multiplier, max delay, and jitter params
+func TestBackoffConfiguration(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"retries":          10,
+		"retry_delay":      time.Millisecond * 10,
+		"retry_multiplier": 3.0,
+		"retry_max_delay":  time.Millisecond * 50,
+		"retry_jitter":     0.0,
+	})
+	policy := node.retryPolicy(context.Background())
+
+	expected := []time.Duration{
+		time.Millisecond * 10,
+		time.Millisecond * 30,
+		time.Millisecond * 50, // 90ms capped
+		time.Millisecond * 50,
+	}
+	for attempt, want := range expected {
+		if got := policy.backoff(attempt, 0); got != want {
+			t.Errorf("Attempt %d: expected %v, got %v", attempt, want, got)
+		}
+	}
+
+	// Defaults: multiplier 2, jitter up to 10%
+	node.SetParams(map[string]interface{}{
+		"retries":     3,
+		"retry_delay": time.Millisecond * 10,
+	})
+	policy = node.retryPolicy(context.Background())
+	if got := policy.backoff(2, 0); got < time.Millisecond*40 || got > time.Millisecond*44 {
+		t.Errorf("Expected default backoff in [40ms, 44ms], got %v", got)
+	}
+}
+
+// TestMaxDelayBoundsRetries tests that retry_max_delay bounds the total backoff slept
+func TestMaxDelayBoundsRetries(t *testing.T) {
+	state := NewSharedState()
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"retries":         8,
+		"retry_delay":     time.Millisecond * 2,
+		"retry_max_delay": time.Millisecond * 5,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("always fails")
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+		node.Run(state)
+	}()
+
+	telemetry := state.Get(RetryTelemetryKey).(RetryTelemetry)
+	// 7 waits of at most 5ms each
+	if telemetry.Backoff > time.Millisecond*35 {
+		t.Errorf("Expected total backoff <= 35ms, got %v", telemetry.Backoff)
+	}
+}
+
+// TestJitterStrategies tests the bounds of the full, equal, and decorrelated strategies
+func TestJitterStrategies(t *testing.T) {
+	base := time.Millisecond * 10
+	maxDelay := time.Millisecond * 200
+
+	for i := 0; i < 50; i++ {
+		full := retryPolicy{delay: base, multiplier: 2, maxDelay: maxDelay, strategy: JitterFull}
+		if got := full.backoff(2, 0); got < 0 || got > time.Millisecond*40 {
+			t.Fatalf("Full jitter out of range [0, 40ms]: %v", got)
+		}
+
+		equal := retryPolicy{delay: base, multiplier: 2, maxDelay: maxDelay, strategy: JitterEqual}
+		if got := equal.backoff(2, 0); got < time.Millisecond*20 || got > time.Millisecond*40 {
+			t.Fatalf("Equal jitter out of range [20ms, 40ms]: %v", got)
+		}
+
+		decorrelated := retryPolicy{delay: base, multiplier: 2, maxDelay: maxDelay, strategy: JitterDecorrelated}
+		if got := decorrelated.backoff(3, time.Millisecond*30); got < base || got > time.Millisecond*90 {
+			t.Fatalf("Decorrelated jitter out of range [10ms, 90ms]: %v", got)
+		}
+		if got := decorrelated.backoff(9, time.Millisecond*150); got > maxDelay {
+			t.Fatalf("Decorrelated jitter exceeded max delay: %v", got)
+		}
+	}
+}
+
+// TestRetryBudgetSharedAcrossBatch tests that a retry budget caps retries across parallel items
+func TestRetryBudgetSharedAcrossBatch(t *testing.T) {
+	state := NewSharedState()
+	counter := &mockCounter{}
+
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":         items,
+		"batch":        true,
+		"parallel":     true,
+		"retries":      3,
+		"retry_budget": 5,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		counter.increment()
+		return nil, fmt.Errorf("dependency degraded")
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic when items fail")
+			}
+		}()
+		node.Run(state)
+	}()
+
+	// 20 first attempts + 5 budgeted retries instead of 60 total attempts
+	if counter.count != 25 {
+		t.Errorf("Expected 25 attempts, got %d", counter.count)
+	}
+}
+
+// TestRetryMaxElapsed tests that retries stop once the next attempt would start too late
+func TestRetryMaxElapsed(t *testing.T) {
+	counter := &mockCounter{}
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"retries":           100,
+		"retry_delay":       20 * time.Millisecond,
+		"retry_multiplier":  1.0,
+		"retry_jitter":      0.0,
+		"retry_max_elapsed": 50 * time.Millisecond,
+	})
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		counter.increment()
+		return nil, fmt.Errorf("dependency degraded")
+	})
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		node.Run(NewSharedState())
+	}()
+
+	err, _ := recovered.(error)
+	if !errors.Is(err, ErrRetriesExhausted) {
+		t.Errorf("Expected retries to be exhausted, got %v", recovered)
+	}
+	// Attempts start at 0, 20 and 40ms; the next would start past 50ms
+	if counter.count < 2 || counter.count > 3 {
+		t.Errorf("Expected the attempts to fit in retry_max_elapsed, got %d", counter.count)
+	}
+}
+
+// TestAttemptVisibleToExec tests that exec can detect its final attempt
+func TestAttemptVisibleToExec(t *testing.T) {
+	var seen []ItemMeta
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"retries": 3})
+	node.SetBatchExecFunc(func(prep interface{}, meta ItemMeta) (interface{}, error) {
+		seen = append(seen, meta)
+		if !meta.FinalAttempt() {
+			return nil, fmt.Errorf("primary model unavailable")
+		}
+		return "fallback model", nil
+	})
+
+	var result interface{}
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		result = exec
+		return DefaultAction
+	})
+	node.Run(NewSharedState())
+
+	if result != "fallback model" {
+		t.Errorf("Expected the final attempt to succeed, got %v", result)
+	}
+	if len(seen) != 3 || seen[0].Attempt != 1 || seen[2].Attempt != 3 || seen[2].MaxAttempts != 3 {
+		t.Errorf("Unexpected attempt metadata: %+v", seen)
+	}
+}
+
+// TestBatchItemMetadata tests that per-item metadata identifies flaky items in a parallel batch
+func TestBatchItemMetadata(t *testing.T) {
+	state := NewSharedState()
+	var mu sync.Mutex
+	calls := map[int]int{}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":           []int{0, 1, 2, 3},
+		"batch":          true,
+		"parallel":       true,
+		"parallel_limit": 2,
+		"retries":        3,
+		"batch_metadata": true,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		i := item.(int)
+		calls[i]++
+		if i == 2 && calls[i] < 3 {
+			return nil, fmt.Errorf("flaky item")
+		}
+		return i, nil
+	})
+	node.Run(state)
+
+	meta, ok := state.Get(BatchMetadataKey).([]BatchItemMetadata)
+	if !ok || len(meta) != 4 {
+		t.Fatalf("Expected metadata for 4 items, got %v", state.Get(BatchMetadataKey))
+	}
+	for i, item := range meta {
+		if item.Index != i || item.Worker < 0 || item.Worker >= 2 || item.Err != nil {
+			t.Errorf("Unexpected metadata for item %d: %+v", i, item)
+		}
+	}
+	if meta[2].Attempts != 3 || meta[0].Attempts != 1 {
+		t.Errorf("Expected per-item attempts 1 and 3, got %d and %d", meta[0].Attempts, meta[2].Attempts)
+	}
+	if telemetry := state.Get(RetryTelemetryKey).(RetryTelemetry); telemetry.Attempts != 6 {
+		t.Errorf("Expected aggregate telemetry of 6 attempts, got %d", telemetry.Attempts)
+	}
+}
+
+// TestBatchItemMetadataOnFailure tests that metadata is stored before a failed batch panics
+func TestBatchItemMetadataOnFailure(t *testing.T) {
+	state := NewSharedState()
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":           []int{0, 1, 2},
+		"batch":          true,
+		"batch_metadata": true,
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) == 1 {
+			return nil, fmt.Errorf("bad item")
+		}
+		return item, nil
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic when an item fails")
+			}
+		}()
+		node.Run(state)
+	}()
+
+	meta := state.Get(BatchMetadataKey).([]BatchItemMetadata)
+	if meta[0].Err != nil || meta[0].Worker != 0 || meta[0].Attempts != 1 {
+		t.Errorf("Unexpected metadata for item 0: %+v", meta[0])
+	}
+	if meta[1].Err == nil {
+		t.Error("Expected the failed item to record its error")
+	}
+	if meta[2].Worker != -1 || meta[2].Attempts != 0 {
+		t.Errorf("Expected item 2 to be recorded as never started, got %+v", meta[2])
+	}
+}
+
+// TestRetryAfterHint tests that a retry-after hint replaces the computed backoff
+func TestRetryAfterHint(t *testing.T) {
+	state := NewSharedState()
+
+	attempts := 0
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"retries":     2,
+		"retry_delay": time.Hour,
+	})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, fmt.Errorf("rate limited: %w", &RetryAfterError{Err: errors.New("429"), Delay: 20 * time.Millisecond})
+		}
+		return "ok", nil
+	})
+	node.Run(state)
+
+	telemetry := state.Get(RetryTelemetryKey).(RetryTelemetry)
+	if telemetry.Backoff != 20*time.Millisecond {
+		t.Errorf("Expected the hinted 20ms backoff, got %v", telemetry.Backoff)
+	}
+}
+
+// TestParseRetryAfter tests parsing Retry-After header values
+func TestParseRetryAfter(t *testing.T) {
+	if d := ParseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("Expected 3s, got %v", d)
+	}
+	if d := ParseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d <= 55*time.Second || d > time.Minute {
+		t.Errorf("Expected about a minute, got %v", d)
+	}
+	for _, value := range []string{"", "-1", "soon", "Mon, 02 Jan 2006 15:04:05 GMT"} {
+		if d := ParseRetryAfter(value); d != 0 {
+			t.Errorf("Expected 0 for %q, got %v", value, d)
+		}
+	}
+}
+
+// TestRetryBackoffStopsWhenContextDone tests that every retry path abandons its
+// backoff once ctx is done
+func TestRetryBackoffStopsWhenContextDone(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"single":     {},
+		"sequential": {"batch": true, "data": []int{1, 2, 3}},
+		"parallel":   {"batch": true, "data": []int{1, 2, 3}, "parallel": true},
+	}
+	for name, params := range cases {
+		params["retries"] = 3
+		params["retry_delay"] = time.Hour
+
+		node := NewNode()
+		node.SetParams(params)
+		node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+			return nil, errors.New("unavailable")
+		})
+		pipeline := NewFlow().Start(node)
+		pipeline.SetParams(params)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		start := time.Now()
+		_, err := pipeline.RunContext(ctx, NewSharedState())
+		cancel()
+
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("%s: expected the backoff to be abandoned, took %v", name, time.Since(start))
+		}
+		if !errors.Is(err, ErrFlowTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected a timed out run, got %v", name, err)
+		}
+	}
+}
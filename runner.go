@@ -0,0 +1,667 @@
+package Flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRunnerShutdown is returned when a run is started on a Runner that is shutting down
+	ErrRunnerShutdown = errors.New("flow: runner is shut down")
+	// ErrRunInterrupted is returned when a run is stopped before reaching a terminal node
+	ErrRunInterrupted = errors.New("flow: run interrupted")
+	// ErrUnknownFlow is returned when running a flow name that is not registered
+	ErrUnknownFlow = errors.New("flow: unknown flow")
+	// ErrUnknownFlowVersion is returned when resuming a checkpoint whose flow version is not registered
+	ErrUnknownFlowVersion = errors.New("flow: unknown flow version")
+	// ErrUnknownCheckpointNode is returned when a checkpoint's next node is not part of its flow version
+	ErrUnknownCheckpointNode = errors.New("flow: checkpoint node not found in flow")
+	// ErrUnknownRun is returned when cancelling a run that is not active, or retrying
+	// a run the runner holds no checkpoint for
+	ErrUnknownRun = errors.New("flow: unknown run")
+)
+
+// RunInfo identifies a run managed by a Runner
+type RunInfo struct {
+	// ID is the runner-assigned identifier of the run
+	ID string `json:"id"`
+	// Flow is the name of the flow being run
+	Flow string `json:"flow"`
+	// Version is the version of the flow definition the run started on
+	Version string `json:"version,omitempty"`
+	// Tenant is the tenant stored under TenantKey in the run's initial state, if any
+	Tenant string `json:"tenant,omitempty"`
+	// Started is when the run began
+	Started time.Time `json:"started"`
+}
+
+// Checkpoint captures where an interrupted run stopped so it can be resumed later.
+// It is passed to the function registered with SetCheckpointFunc and is included
+// in the ShutdownReport.
+type Checkpoint struct {
+	RunInfo
+	// State is the run's shared state at the point of interruption
+	State *SharedState
+	// LastAction is the action returned by the last completed node
+	LastAction string
+	// NextNode is the node that would have run next (the interrupted node when a
+	// batch was stopped mid-way), or nil if unknown
+	NextNode *Node
+}
+
+// ShutdownReport describes the runs affected by a Runner shutdown
+type ShutdownReport struct {
+	// Interrupted lists runs that were stopped at a node boundary or mid-batch
+	Interrupted []Checkpoint
+	// Unfinished lists runs still executing a node when the grace period expired
+	Unfinished []RunInfo
+}
+
+// activeRun tracks a single in-flight run
+type activeRun struct {
+	info     RunInfo
+	progress *progressTracker
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// Runner executes flows on behalf of a service and supports graceful shutdown.
+// Shutdown stops runs from starting new nodes (and batches from starting new
+// items), waits for in-flight work up to a grace period, checkpoints the state of
+// interrupted runs, and reports which runs were interrupted.
+//
+// Example:
+//
+//	runner := flow.NewRunner()
+//	runner.SetCheckpointFunc(func(cp flow.Checkpoint) {
+//		store.Save(cp.ID, cp.State)
+//	})
+//
+//	go func() {
+//		action, err := runner.Run(pipeline, state)
+//		...
+//	}()
+//
+//	// On deploy:
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	report, err := runner.Shutdown(ctx)
+type Runner struct {
+	mu          sync.Mutex
+	runs        map[string]*activeRun
+	interrupted []Checkpoint
+	failed      []Checkpoint
+	retention   int
+	closing     bool
+	nextID      int64
+	checkpoint  func(Checkpoint)
+	wg          sync.WaitGroup
+
+	// stuckAfter and checks configure Health
+	stuckAfter time.Duration
+	checks     map[string]func() error
+
+	authorizer Authorizer
+
+	// definitions holds registered flow versions by name, then version
+	definitions map[string]map[string]*Flow
+	latest      map[string]*Flow
+
+	stop       context.Context
+	cancelStop context.CancelFunc
+}
+
+// NewRunner creates a Runner ready to execute flows
+func NewRunner() *Runner {
+	stop, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		runs:        make(map[string]*activeRun),
+		definitions: make(map[string]map[string]*Flow),
+		latest:      make(map[string]*Flow),
+		stop:        stop,
+		cancelStop:  cancel,
+	}
+}
+
+// SetCheckpointFunc sets a function invoked with the checkpoint of every run
+// interrupted by Shutdown or Cancel, typically to persist the state for later resumption
+func (r *Runner) SetCheckpointFunc(fn func(Checkpoint)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkpoint = fn
+}
+
+// SetCheckpointRetention caps the failed and the interrupted checkpoints the
+// runner holds for Retry at n each, dropping the oldest first once a list is
+// full. Checkpoints are kept until retried or discarded when n <= 0, the default.
+func (r *Runner) SetCheckpointRetention(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retention = n
+	r.failed = r.retain(r.failed)
+	r.interrupted = r.retain(r.interrupted)
+}
+
+// retain drops the oldest checkpoints of list past the retention cap.
+// The caller must hold r.mu.
+func (r *Runner) retain(list []Checkpoint) []Checkpoint {
+	if r.retention <= 0 || len(list) <= r.retention {
+		return list
+	}
+	return append(list[:0:0], list[len(list)-r.retention:]...)
+}
+
+// Register adds a versioned flow definition to the runner, keyed by the flow's
+// name and version. The most recently registered version of a name is the one
+// returned by Definition, so new runs pick up the new graph, while earlier
+// versions stay registered so checkpointed runs can Resume on the graph they
+// started with.
+//
+// Example:
+//
+//	v2 := buildIngestFlow() // routing changed in this deploy
+//	v2.SetName("ingest")
+//	v2.SetVersion("2")
+//	runner.Register(v1)
+//	runner.Register(v2)
+//
+//	pipeline, _ := runner.Definition("ingest") // v2 for new runs
+//	action, err := runner.Resume(checkpoint)    // continues on the checkpoint's version
+func (r *Runner) Register(f *Flow) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.definitions[f.name]
+	if !ok {
+		versions = make(map[string]*Flow)
+		r.definitions[f.name] = versions
+	}
+	versions[f.version] = f
+	r.latest[f.name] = f
+}
+
+// Definition returns the most recently registered version of the named flow.
+// It reports false if no flow with that name is registered.
+func (r *Runner) Definition(name string) (*Flow, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.latest[name]
+	return f, ok
+}
+
+// Run executes the flow with the given state and returns its final action.
+// It returns ErrRunnerShutdown if the runner is shutting down, and an error
+// wrapping ErrRunInterrupted if the run was stopped by Shutdown or Cancel. Panics
+// from node execution propagate to the caller as with Flow.Run, after the run is
+// checkpointed for Retry. It returns ErrNoStartNode if the flow has no start node.
+func (r *Runner) Run(f *Flow, shared *SharedState) (string, error) {
+	if f.startNode == nil {
+		return "", ErrNoStartNode
+	}
+	run, err := r.register(f, f.startNode, shared)
+	if err != nil {
+		return "", err
+	}
+	defer r.unregister(run)
+
+	return r.execute(run, f, f.startNode, shared)
+}
+
+// RunNamed executes the latest registered version of the named flow like Run.
+// It returns an error wrapping ErrUnknownFlow if no such flow is registered, and
+// one wrapping ErrUnauthorized if the runner's Authorizer denies the run. It is
+// RunNamedContext with context.Background().
+func (r *Runner) RunNamed(name string, shared *SharedState) (string, error) {
+	return r.RunNamedContext(context.Background(), name, shared)
+}
+
+// RunNamedContext is like RunNamed, passing ctx to the runner's Authorizer so it
+// can check the identity or credentials it carries. The run itself is stopped by
+// Cancel or Shutdown, not by ctx.
+func (r *Runner) RunNamedContext(ctx context.Context, name string, shared *SharedState) (string, error) {
+	f, ok := r.Definition(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownFlow, name)
+	}
+	if err := r.authorize(ctx, AuthRequest{Action: AuthRun, Flow: name, Tenant: tenantOf(shared)}); err != nil {
+		return "", err
+	}
+	return r.Run(f, shared)
+}
+
+// RunResult is the JSON document written by RunDocument
+type RunResult struct {
+	// Flow is the name of the flow that ran
+	Flow string `json:"flow"`
+	// Action is the terminal action of the run
+	Action string `json:"action"`
+	// State is the final shared state
+	State map[string]interface{} `json:"state"`
+	// Error describes why the run failed, if it did
+	Error string `json:"error,omitempty"`
+}
+
+// RunDocument runs the named flow with the initial state read from doc as a JSON
+// object and writes a RunResult to out as JSON, for invoking library-defined flows
+// from scripts and ops tooling. Integral JSON numbers are stored as int so nodes
+// can read them with GetInt. A failed or panicking run is reported in the
+// result's Error and also returned; decoding and encoding errors are returned
+// without writing a result.
+//
+// Example:
+//
+//	runner.Register(buildIngestFlow())
+//	if err := runner.RunDocument("ingest", os.Stdin, os.Stdout); err != nil {
+//		os.Exit(1)
+//	}
+func (r *Runner) RunDocument(name string, doc io.Reader, out io.Writer) error {
+	return r.RunDocumentContext(context.Background(), name, doc, out)
+}
+
+// RunDocumentContext is like RunDocument, running the flow with RunNamedContext
+// so ctx reaches the runner's Authorizer
+func (r *Runner) RunDocumentContext(ctx context.Context, name string, doc io.Reader, out io.Writer) (err error) {
+	var initial map[string]interface{}
+	if err := json.NewDecoder(doc).Decode(&initial); err != nil {
+		return fmt.Errorf("flow: invalid state document: %w", err)
+	}
+
+	shared := NewSharedState()
+	for key, value := range initial {
+		shared.Set(key, normalizeJSON(value))
+	}
+
+	result := RunResult{Flow: name}
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				err = panicToError(p)
+			}
+		}()
+		result.Action, err = r.RunNamedContext(ctx, name, shared)
+	}()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.State = shared.Snapshot()
+
+	if encErr := json.NewEncoder(out).Encode(result); encErr != nil {
+		return fmt.Errorf("flow: encoding run result: %w", encErr)
+	}
+	return err
+}
+
+// normalizeJSON converts integral float64 values decoded from JSON to int, recursively
+func normalizeJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeJSON(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = normalizeJSON(v[key])
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// Resume continues a checkpointed run from its next node on the flow version it
+// started with, which must have been registered with Register. It returns an
+// error wrapping ErrUnknownFlowVersion if that version is not registered, and
+// ErrUnknownCheckpointNode if the next node is not part of it. The node is
+// matched by identity, or by name for checkpoints that outlived their graph.
+// Otherwise Resume behaves like Run.
+func (r *Runner) Resume(cp Checkpoint) (string, error) {
+	if cp.NextNode == nil {
+		return cp.LastAction, nil
+	}
+	run, f, start, err := r.registerResume(cp)
+	if err != nil {
+		return "", err
+	}
+	defer r.unregister(run)
+
+	return r.execute(run, f, start, cp.State)
+}
+
+// registerResume registers a run continuing cp from its next node, which must not be nil
+func (r *Runner) registerResume(cp Checkpoint) (*activeRun, *Flow, *Node, error) {
+	r.mu.Lock()
+	f, ok := r.definitions[cp.Flow][cp.Version]
+	r.mu.Unlock()
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %s version %q", ErrUnknownFlowVersion, cp.Flow, cp.Version)
+	}
+
+	start := f.resolveNode(cp.NextNode)
+	if start == nil {
+		return nil, nil, nil, fmt.Errorf("%w: %s", ErrUnknownCheckpointNode, cp.NextNode.Name())
+	}
+
+	run, err := r.register(f, start, cp.State)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return run, f, start, nil
+}
+
+// resolveNode finds node in the flow's graph by identity, falling back to a
+// uniquely named node with the same name
+func (f *Flow) resolveNode(node *Node) *Node {
+	var named *Node
+	matches := 0
+	for _, candidate := range f.nodes() {
+		if candidate == node {
+			return candidate
+		}
+		if node.name != "" && candidate.name == node.name {
+			named = candidate
+			matches++
+		}
+	}
+	if matches != 1 {
+		return nil
+	}
+	return named
+}
+
+// execute orchestrates the flow from start, converting a stop signal into a checkpointed interruption
+func (r *Runner) execute(run *activeRun, f *Flow, start *Node, shared *SharedState) (string, error) {
+	completed := false
+	defer func() { run.progress.finish(completed) }()
+
+	var current *Node
+	var lastAction string
+	defer func() {
+		if p := recover(); p != nil {
+			// Keep the failed node's checkpoint so the run can be retried from it
+			r.mu.Lock()
+			r.failed = r.retain(append(r.failed, Checkpoint{RunInfo: run.info, State: shared, LastAction: lastAction, NextNode: current}))
+			r.mu.Unlock()
+			panic(p)
+		}
+	}()
+
+	action, next, err := f.runInterruptible(withRunID(run.ctx, run.info.ID), shared, start, func(curr *Node, prevAction string) {
+		current, lastAction = curr, prevAction
+		run.progress.enter(curr)
+	})
+	if err != nil {
+		return action, r.interrupt(run, shared, action, next)
+	}
+	completed = true
+	return action, nil
+}
+
+// interrupt records and checkpoints a stopped run
+func (r *Runner) interrupt(run *activeRun, shared *SharedState, lastAction string, next *Node) error {
+	cp := Checkpoint{
+		RunInfo:    run.info,
+		State:      shared,
+		LastAction: lastAction,
+		NextNode:   next,
+	}
+
+	r.mu.Lock()
+	r.interrupted = r.retain(append(r.interrupted, cp))
+	checkpoint := r.checkpoint
+	r.mu.Unlock()
+
+	if checkpoint != nil {
+		checkpoint(cp)
+	}
+	return fmt.Errorf("%w: %s", ErrRunInterrupted, run.info.ID)
+}
+
+// register adds a new run starting at start unless the runner is shutting down
+func (r *Runner) register(f *Flow, start *Node, shared *SharedState) (*activeRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closing {
+		return nil, ErrRunnerShutdown
+	}
+
+	r.nextID++
+	ctx, cancel := context.WithCancel(r.stop)
+	run := &activeRun{
+		ctx:    ctx,
+		cancel: cancel,
+		info: RunInfo{
+			ID:      fmt.Sprintf("run-%d", r.nextID),
+			Flow:    f.name,
+			Version: f.version,
+			Tenant:  tenantOf(shared),
+			Started: time.Now(),
+		},
+		progress: newProgressTracker(start),
+	}
+	r.runs[run.info.ID] = run
+	r.wg.Add(1)
+	return run, nil
+}
+
+// unregister removes a finished run
+func (r *Runner) unregister(run *activeRun) {
+	r.mu.Lock()
+	delete(r.runs, run.info.ID)
+	r.mu.Unlock()
+	run.cancel()
+	r.wg.Done()
+}
+
+// Active lists the runs currently in flight, oldest first
+func (r *Runner) Active() []RunInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]RunInfo, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, run.info)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Started.Before(runs[j].Started)
+	})
+	return runs
+}
+
+// ActiveRun describes an active run and how far it has advanced
+type ActiveRun struct {
+	RunInfo
+	// CurrentNode is the name of the node the run is executing
+	CurrentNode string `json:"current_node"`
+	// Completed is the number of nodes that have finished executing
+	Completed int `json:"completed"`
+	// Elapsed is how long the run has been in flight
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Runs lists the active runs with their current node and elapsed time, oldest first
+func (r *Runner) Runs() []ActiveRun {
+	infos := r.Active()
+	runs := make([]ActiveRun, 0, len(infos))
+	for _, info := range infos {
+		progress, ok := r.Progress(info.ID)
+		if !ok {
+			// Finished since it was listed
+			continue
+		}
+		runs = append(runs, ActiveRun{
+			RunInfo:     info,
+			CurrentNode: progress.CurrentNode,
+			Completed:   progress.Completed,
+			Elapsed:     progress.Elapsed,
+		})
+	}
+	return runs
+}
+
+// Cancel stops the active run with the given ID as Shutdown would: it starts no
+// further nodes or batch items, and is checkpointed and returns an error wrapping
+// ErrRunInterrupted once in-flight work finishes. It returns an error wrapping
+// ErrUnknownRun if no such run is active.
+func (r *Runner) Cancel(id string) error {
+	r.mu.Lock()
+	run, ok := r.runs[id]
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRun, id)
+	}
+	run.cancel()
+	return nil
+}
+
+// Failed lists the checkpoints of runs that panicked, oldest first. Each points at
+// the node that failed, so Retry re-runs it.
+func (r *Runner) Failed() []Checkpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Checkpoint(nil), r.failed...)
+}
+
+// Discard drops the failed or interrupted checkpoint of the run with the given
+// ID without retrying it. It returns an error wrapping ErrUnknownRun if the runner
+// holds no checkpoint for id.
+func (r *Runner) Discard(id string) error {
+	if _, _, ok := r.takeCheckpoint(id); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRun, id)
+	}
+	return nil
+}
+
+// Retry resumes the failed or interrupted run with the given ID from its last
+// checkpoint, as a new run on the flow version it started with. The checkpoint is
+// consumed; if the retried run fails or is interrupted again, it is checkpointed
+// under its new ID. It returns an error wrapping ErrUnknownRun if the runner holds
+// no checkpoint for id; otherwise it behaves like Resume.
+func (r *Runner) Retry(id string) (string, error) {
+	cp, run, f, start, err := r.registerRetry(id)
+	if err != nil || run == nil {
+		return cp.LastAction, err
+	}
+	defer r.unregister(run)
+
+	return r.execute(run, f, start, cp.State)
+}
+
+// retryAsync starts Retry in the background and returns the new run's info.
+// A panic of the retried run is checkpointed as a failure instead of propagating.
+func (r *Runner) retryAsync(id string) (RunInfo, error) {
+	cp, run, f, start, err := r.registerRetry(id)
+	if err != nil {
+		return RunInfo{}, err
+	}
+	if run == nil {
+		// Nothing was left to run
+		return cp.RunInfo, nil
+	}
+
+	go func() {
+		defer r.unregister(run)
+		defer func() { _ = recover() }()
+		_, _ = r.execute(run, f, start, cp.State)
+	}()
+	return run.info, nil
+}
+
+// registerRetry takes the checkpoint of run id and registers the run resuming it;
+// the run is nil if the checkpoint has no next node. The checkpoint is put back
+// if the runner is shutting down.
+func (r *Runner) registerRetry(id string) (Checkpoint, *activeRun, *Flow, *Node, error) {
+	cp, list, ok := r.takeCheckpoint(id)
+	if !ok {
+		return cp, nil, nil, nil, fmt.Errorf("%w: %s", ErrUnknownRun, id)
+	}
+	if cp.NextNode == nil {
+		return cp, nil, nil, nil, nil
+	}
+
+	run, f, start, err := r.registerResume(cp)
+	if errors.Is(err, ErrRunnerShutdown) {
+		// Never started; keep the checkpoint where it was
+		r.mu.Lock()
+		*list = r.retain(append(*list, cp))
+		r.mu.Unlock()
+	}
+	return cp, run, f, start, err
+}
+
+// takeCheckpoint removes the failed or interrupted checkpoint of run id and
+// returns it with the list it was taken from
+func (r *Runner) takeCheckpoint(id string) (Checkpoint, *[]Checkpoint, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, list := range []*[]Checkpoint{&r.failed, &r.interrupted} {
+		for i, cp := range *list {
+			if cp.ID == id {
+				*list = append((*list)[:i:i], (*list)[i+1:]...)
+				return cp, list, true
+			}
+		}
+	}
+	return Checkpoint{}, nil, false
+}
+
+// Progress returns the progress of the active run with the given ID.
+// It reports false if no such run is in flight.
+func (r *Runner) Progress(id string) (Progress, bool) {
+	r.mu.Lock()
+	run, ok := r.runs[id]
+	r.mu.Unlock()
+	if !ok {
+		return Progress{}, false
+	}
+	return run.progress.snapshot(), true
+}
+
+// Shutdown stops all runs from starting new nodes or batch items, then waits for
+// in-flight work to finish until ctx is done. Runs stopped at a boundary are
+// checkpointed and listed as interrupted; runs still executing when ctx expires
+// are listed as unfinished and ctx.Err() is returned. New runs are rejected with
+// ErrRunnerShutdown once Shutdown has been called.
+func (r *Runner) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	r.mu.Lock()
+	r.closing = true
+	r.mu.Unlock()
+	r.cancelStop()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	var waitErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		waitErr = ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := ShutdownReport{
+		Interrupted: append([]Checkpoint(nil), r.interrupted...),
+	}
+	for _, run := range r.runs {
+		report.Unfinished = append(report.Unfinished, run.info)
+	}
+	return report, waitErr
+}
@@ -0,0 +1,405 @@
+package Flow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunnerShutdownStopsAtNodeBoundary tests that in-flight nodes finish but no new nodes start
+func TestRunnerShutdownStopsAtNodeBoundary(t *testing.T) {
+	runner := NewRunner()
+
+	// The in-flight node finishes once shutdown cancels the run's context
+	started := make(chan struct{})
+	slow := NewNode()
+	slow.SetName("slow")
+	slow.SetExecFuncWithContext(func(ctx context.Context, prep interface{}) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return "next", nil
+	})
+
+	secondRan := false
+	second := NewNode()
+	second.SetName("second")
+	second.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		secondRan = true
+		return "done", nil
+	})
+	slow.Next(second, "next")
+
+	var checkpointed []Checkpoint
+	runner.SetCheckpointFunc(func(cp Checkpoint) {
+		checkpointed = append(checkpointed, cp)
+	})
+
+	pipeline := NewFlow().Start(slow)
+	pipeline.SetName("deploy-test")
+
+	type outcome struct {
+		action string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		action, err := runner.Run(pipeline, NewSharedState())
+		done <- outcome{action, err}
+	}()
+
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report, err := runner.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Expected graceful shutdown, got %v", err)
+	}
+
+	result := <-done
+	if !errors.Is(result.err, ErrRunInterrupted) {
+		t.Errorf("Expected ErrRunInterrupted, got %v", result.err)
+	}
+	if result.action != "next" {
+		t.Errorf("Expected last action 'next', got '%s'", result.action)
+	}
+	if secondRan {
+		t.Error("Expected second node not to start after shutdown")
+	}
+
+	if len(report.Interrupted) != 1 || len(report.Unfinished) != 0 {
+		t.Fatalf("Expected 1 interrupted and 0 unfinished, got %d and %d", len(report.Interrupted), len(report.Unfinished))
+	}
+	cp := report.Interrupted[0]
+	if cp.Flow != "deploy-test" || cp.NextNode != second || cp.LastAction != "next" {
+		t.Errorf("Unexpected checkpoint: flow=%s next=%v action=%s", cp.Flow, cp.NextNode, cp.LastAction)
+	}
+	if len(checkpointed) != 1 {
+		t.Errorf("Expected checkpoint func to be called once, got %d", len(checkpointed))
+	}
+
+	if _, err := runner.Run(pipeline, NewSharedState()); !errors.Is(err, ErrRunnerShutdown) {
+		t.Errorf("Expected ErrRunnerShutdown for new runs, got %v", err)
+	}
+}
+
+// TestRunnerShutdownStopsBatchItems tests that a parallel batch stops starting new items
+func TestRunnerShutdownStopsBatchItems(t *testing.T) {
+	runner := NewRunner()
+	counter := &mockCounter{}
+
+	items := make([]int, 10)
+	started := make(chan struct{}, len(items))
+	batch := NewNode()
+	batch.SetExecFuncWithContext(func(ctx context.Context, item interface{}) (interface{}, error) {
+		counter.increment()
+		started <- struct{}{}
+		<-ctx.Done()
+		return item, nil
+	})
+
+	pipeline := NewFlow().Start(batch)
+	// Flow params are applied to every node it runs
+	pipeline.SetParams(map[string]interface{}{
+		"data":           items,
+		"batch":          true,
+		"parallel":       true,
+		"parallel_limit": 2,
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := runner.Run(pipeline, NewSharedState())
+		done <- err
+	}()
+
+	// Shut down once both workers are busy
+	<-started
+	<-started
+	report, err := runner.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("Expected graceful shutdown, got %v", err)
+	}
+
+	if err := <-done; !errors.Is(err, ErrRunInterrupted) {
+		t.Errorf("Expected ErrRunInterrupted, got %v", err)
+	}
+	if counter.count != 2 {
+		t.Errorf("Expected only the 2 in-flight items to run, got %d", counter.count)
+	}
+	if len(report.Interrupted) != 1 || report.Interrupted[0].NextNode != batch {
+		t.Errorf("Expected the batch node to be checkpointed for resumption")
+	}
+}
+
+// TestRunnerShutdownGraceExpired tests that runs outliving the grace period are reported
+func TestRunnerShutdownGraceExpired(t *testing.T) {
+	runner := NewRunner()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	stuck := NewNode()
+	stuck.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return "done", nil
+	})
+	defer close(release)
+
+	go func() {
+		_, _ = runner.Run(NewFlow().Start(stuck), NewSharedState())
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	report, err := runner.Shutdown(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if len(report.Unfinished) != 1 {
+		t.Errorf("Expected 1 unfinished run, got %d", len(report.Unfinished))
+	}
+}
+
+// TestRunnerResumeOnStartedVersion tests that checkpoints resume on their own flow version
+func TestRunnerResumeOnStartedVersion(t *testing.T) {
+	build := func(version, route string) *Flow {
+		first := NewNode()
+		first.SetName("first")
+		first.SetExecFunc(func(prep interface{}) (interface{}, error) {
+			return "next", nil
+		})
+
+		second := NewNode()
+		second.SetName("second")
+		second.SetExecFunc(func(prep interface{}) (interface{}, error) {
+			return route, nil
+		})
+		first.Next(second, "next")
+
+		f := NewFlow().Start(first)
+		f.SetName("ingest")
+		f.SetVersion(version)
+		return f
+	}
+
+	runner := NewRunner()
+	v1 := build("1", "routed-v1")
+	runner.Register(v1)
+	runner.Register(build("2", "routed-v2"))
+
+	latest, ok := runner.Definition("ingest")
+	if !ok || latest.Version() != "2" {
+		t.Fatalf("Expected latest definition to be version 2, got %v", latest)
+	}
+
+	// A checkpoint taken on v1, pointing at v1's second node
+	cp := Checkpoint{
+		RunInfo:    RunInfo{ID: "run-1", Flow: "ingest", Version: "1"},
+		State:      NewSharedState(),
+		LastAction: "next",
+		NextNode:   v1.StartNode().GetSuccessors()["next"],
+	}
+	action, err := runner.Resume(cp)
+	if err != nil || action != "routed-v1" {
+		t.Errorf("Expected resume on version 1, got action '%s' err %v", action, err)
+	}
+
+	// A checkpoint restored after a restart only knows the node by name
+	restored := NewNode()
+	restored.SetName("second")
+	cp.NextNode = restored
+	if action, err := runner.Resume(cp); err != nil || action != "routed-v1" {
+		t.Errorf("Expected name-matched resume on version 1, got action '%s' err %v", action, err)
+	}
+
+	cp.Version = "0"
+	if _, err := runner.Resume(cp); !errors.Is(err, ErrUnknownFlowVersion) {
+		t.Errorf("Expected ErrUnknownFlowVersion, got %v", err)
+	}
+}
+
+// TestRunnerRunDocument tests running a registered flow by name from a JSON state document
+func TestRunnerRunDocument(t *testing.T) {
+	double := NewNode()
+	double.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("result", shared.GetInt("input")*2)
+		return "doubled"
+	})
+	pipeline := NewFlow().Start(double)
+	pipeline.SetName("double")
+
+	runner := NewRunner()
+	runner.Register(pipeline)
+
+	var out bytes.Buffer
+	if err := runner.RunDocument("double", strings.NewReader(`{"input": 21}`), &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := out.String(); got != `{"flow":"double","action":"doubled","state":{"input":21,"result":42}}`+"\n" {
+		t.Errorf("Unexpected result document %s", got)
+	}
+
+	out.Reset()
+	err := runner.RunDocument("missing", strings.NewReader(`{}`), &out)
+	if !errors.Is(err, ErrUnknownFlow) {
+		t.Errorf("Expected ErrUnknownFlow, got %v", err)
+	}
+	if !strings.Contains(out.String(), `"error":"flow: unknown flow: missing"`) {
+		t.Errorf("Expected the error in the result document, got %s", out.String())
+	}
+}
+
+// TestRunnerCancel tests that a cancelled run stops at the next node boundary and can be retried
+func TestRunnerCancel(t *testing.T) {
+	runner := NewRunner()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	first := NewNode()
+	first.SetName("first")
+	first.SetExecFunc(func(interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return "next", nil
+	})
+	secondRuns := 0
+	second := NewNode()
+	second.SetName("second")
+	second.SetExecFunc(func(interface{}) (interface{}, error) {
+		secondRuns++
+		return "done", nil
+	})
+	first.Next(second, "next")
+
+	pipeline := NewFlow().Start(first)
+	pipeline.SetName("cancel-test")
+	runner.Register(pipeline)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := runner.Run(pipeline, NewSharedState())
+		errs <- err
+	}()
+	<-started
+
+	runs := runner.Runs()
+	if len(runs) != 1 || runs[0].CurrentNode != "first" {
+		t.Fatalf("Expected one run at node first, got %+v", runs)
+	}
+	if err := runner.Cancel(runs[0].ID); err != nil {
+		t.Fatalf("Unexpected cancel error: %v", err)
+	}
+	close(release)
+	if err := <-errs; !errors.Is(err, ErrRunInterrupted) {
+		t.Fatalf("Expected ErrRunInterrupted, got %v", err)
+	}
+	if secondRuns != 0 {
+		t.Fatal("Expected the cancelled run not to start the next node")
+	}
+	if err := runner.Cancel(runs[0].ID); !errors.Is(err, ErrUnknownRun) {
+		t.Errorf("Expected ErrUnknownRun cancelling a finished run, got %v", err)
+	}
+
+	action, err := runner.Retry(runs[0].ID)
+	if err != nil || action != "done" || secondRuns != 1 {
+		t.Errorf("Expected the retry to run the second node, got %q, %v", action, err)
+	}
+	if _, err := runner.Retry(runs[0].ID); !errors.Is(err, ErrUnknownRun) {
+		t.Errorf("Expected the checkpoint to be consumed, got %v", err)
+	}
+}
+
+// TestRunnerRetryFailed tests retrying a panicked run from the node that failed
+func TestRunnerRetryFailed(t *testing.T) {
+	runner := NewRunner()
+
+	prepared := NewNode()
+	prepared.SetName("prepared")
+	prepared.SetExecFunc(func(interface{}) (interface{}, error) { return "next", nil })
+	prepared.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("prepared", true)
+		return exec.(string)
+	})
+	calls := 0
+	flaky := NewNode()
+	flaky.SetName("flaky")
+	flaky.SetExecFunc(func(interface{}) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("upstream down")
+		}
+		return "done", nil
+	})
+	prepared.Next(flaky, "next")
+
+	pipeline := NewFlow().Start(prepared)
+	pipeline.SetName("retry-test")
+	runner.Register(pipeline)
+
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = runner.Run(pipeline, NewSharedState())
+		t.Fatal("Expected the run to panic")
+	}()
+
+	failed := runner.Failed()
+	if len(failed) != 1 || failed[0].NextNode != flaky || failed[0].LastAction != "next" {
+		t.Fatalf("Expected a failure checkpoint at node flaky, got %+v", failed)
+	}
+	if failed[0].State.Get("prepared") != true {
+		t.Error("Expected the checkpoint to carry the run's state")
+	}
+
+	action, err := runner.Retry(failed[0].ID)
+	if err != nil || action != "done" || calls != 2 {
+		t.Errorf("Expected the retry to succeed, got %q, %v after %d calls", action, err, calls)
+	}
+	if len(runner.Failed()) != 0 {
+		t.Errorf("Expected no failed runs after the retry, got %+v", runner.Failed())
+	}
+}
+
+// TestRunnerCheckpointRetention tests that the oldest failed checkpoints are evicted
+// past the retention cap, and that Discard drops a checkpoint
+func TestRunnerCheckpointRetention(t *testing.T) {
+	runner := NewRunner()
+	runner.SetCheckpointRetention(2)
+
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		return nil, errors.New("upstream down")
+	})
+	pipeline := NewFlow().Start(node)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		func() {
+			defer func() { _ = recover() }()
+			_, _ = runner.Run(pipeline, NewSharedState())
+		}()
+		failed := runner.Failed()
+		ids = append(ids, failed[len(failed)-1].ID)
+	}
+
+	failed := runner.Failed()
+	if len(failed) != 2 || failed[0].ID != ids[1] || failed[1].ID != ids[2] {
+		t.Fatalf("Expected the two newest checkpoints %v, got %+v", ids[1:], failed)
+	}
+	if _, err := runner.Retry(ids[0]); !errors.Is(err, ErrUnknownRun) {
+		t.Errorf("Expected the evicted checkpoint to be gone, got %v", err)
+	}
+
+	if err := runner.Discard(ids[1]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if failed := runner.Failed(); len(failed) != 1 || failed[0].ID != ids[2] {
+		t.Errorf("Expected only the newest checkpoint after Discard, got %+v", failed)
+	}
+	if err := runner.Discard(ids[1]); !errors.Is(err, ErrUnknownRun) {
+		t.Errorf("Expected ErrUnknownRun discarding twice, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package Flow
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PriorityScheduler bounds concurrent work like a ConcurrencyLimiter, but serves
+// waiters in priority order (highest first, then in arrival order), so interactive
+// requests sharing a process with large background batches are not starved.
+//
+// Schedulers are created and registered with Scheduler(). Nodes referencing one
+// through the "scheduler" param hold a slot for every exec call, at the priority
+// given by the "priority" param or the node's SetPriorityFunc func, which lets
+// batch items carry their own priorities. Whole runs are queued with Run. A
+// scheduler should not be used for both a run and the nodes inside it, which
+// would wait on the slot the run holds.
+//
+// Example:
+//
+//	flow.Scheduler("llm", 8)
+//
+//	interactive.SetParams(map[string]interface{}{"scheduler": "llm", "priority": 10})
+//	backfill.SetParams(map[string]interface{}{
+//		"data":      documents,
+//		"batch":     true,
+//		"parallel":  true,
+//		"scheduler": "llm", // Priority 0, runs when no interactive call is waiting
+//	})
+type PriorityScheduler struct {
+	name  string
+	limit int
+
+	mu      sync.Mutex
+	running int
+	waiting waitQueue
+	seq     uint64
+}
+
+var schedulerRegistry = struct {
+	mu         sync.RWMutex
+	schedulers map[string]*PriorityScheduler
+}{
+	schedulers: make(map[string]*PriorityScheduler),
+}
+
+// Scheduler registers a named priority scheduler running at most workers units of
+// work at a time and returns it. If a scheduler with the same name is already
+// registered, the existing scheduler is returned unchanged. A workers value <= 0
+// is treated as 1.
+//
+// Parameters:
+//   - name: The name nodes use to reference the scheduler via the "scheduler" param
+//   - workers: The maximum number of concurrent units of work
+//
+// Returns:
+//   - *PriorityScheduler: The registered scheduler
+func Scheduler(name string, workers int) *PriorityScheduler {
+	schedulerRegistry.mu.Lock()
+	defer schedulerRegistry.mu.Unlock()
+
+	if existing, ok := schedulerRegistry.schedulers[name]; ok {
+		return existing
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+	s := &PriorityScheduler{name: name, limit: workers}
+	schedulerRegistry.schedulers[name] = s
+	return s
+}
+
+// lookupScheduler returns the registered scheduler for name, or nil if none exists
+func lookupScheduler(name string) *PriorityScheduler {
+	schedulerRegistry.mu.RLock()
+	defer schedulerRegistry.mu.RUnlock()
+	return schedulerRegistry.schedulers[name]
+}
+
+// Name returns the name the scheduler was registered under
+func (s *PriorityScheduler) Name() string {
+	return s.name
+}
+
+// Limit returns the maximum number of concurrent units of work
+func (s *PriorityScheduler) Limit() int {
+	return s.limit
+}
+
+// Waiting returns the number of callers queued for a slot
+func (s *PriorityScheduler) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiting)
+}
+
+// Acquire blocks until a slot is granted at the given priority or ctx is done,
+// returning ctx.Err() in the latter case
+func (s *PriorityScheduler) Acquire(ctx context.Context, priority int) error {
+	s.mu.Lock()
+	if s.running < s.limit && len(s.waiting) == 0 {
+		s.running++
+		s.mu.Unlock()
+		return nil
+	}
+	s.seq++
+	w := &waiter{priority: priority, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-w.ready:
+			// The slot was granted as ctx finished; hand it on
+			s.releaseLocked()
+		default:
+			heap.Remove(&s.waiting, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot previously obtained with Acquire, granting it to the
+// highest priority waiter
+func (s *PriorityScheduler) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *PriorityScheduler) releaseLocked() {
+	if len(s.waiting) > 0 {
+		// The slot passes directly to the next waiter, so running is unchanged
+		close(heap.Pop(&s.waiting).(*waiter).ready)
+		return
+	}
+	s.running--
+}
+
+// Run executes f with RunContext once a slot is granted at the given priority.
+// It returns an error wrapping ErrRunInterrupted if ctx is done while queued.
+func (s *PriorityScheduler) Run(ctx context.Context, f *Flow, shared *SharedState, priority int) (string, error) {
+	addGauge(GaugeQueuedRuns, s.name, 1)
+	err := s.Acquire(ctx, priority)
+	addGauge(GaugeQueuedRuns, s.name, -1)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrRunInterrupted, err)
+	}
+	defer s.Release()
+	return f.RunContext(ctx, shared)
+}
+
+// SetPriorityFunc sets the function computing the scheduling priority of each
+// exec input, such as a batch item, overriding the "priority" param
+func (n *Node) SetPriorityFunc(fn func(input interface{}) int) {
+	n.priorityFunc = fn
+}
+
+// schedule waits for a slot of the node's "scheduler" at the input's priority and
+// returns the function releasing it, or nil if the node has no scheduler. It
+// returns ctx.Err() if ctx is done first.
+func (n *Node) schedule(ctx context.Context, input interface{}) (func(), error) {
+	name := n.getStringParam(ctx, "scheduler")
+	if name == "" {
+		return nil, nil
+	}
+	s := lookupScheduler(name)
+	if s == nil {
+		panic(fmt.Errorf("flow: scheduler %q is not registered", name))
+	}
+
+	priority := n.getIntParam(ctx, "priority")
+	if n.priorityFunc != nil {
+		priority = n.priorityFunc(input)
+	}
+	var err error
+	timeWait(name, func() { err = s.Acquire(ctx, priority) })
+	if err != nil {
+		return nil, err
+	}
+	return s.Release, nil
+}
+
+// waiter is a caller queued for a scheduler slot
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// waitQueue is a heap of waiters, highest priority and then earliest first
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return w
+}
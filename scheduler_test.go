@@ -0,0 +1,141 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSchedulerServesHighestPriorityFirst tests that queued waiters are granted by priority
+func TestSchedulerServesHighestPriorityFirst(t *testing.T) {
+	s := Scheduler("test-sched-order", 1)
+	if err := s.Acquire(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i, priority := range []int{1, 5, 1, 9} {
+		wg.Add(1)
+		go func(priority int) {
+			defer wg.Done()
+			_ = s.Acquire(context.Background(), priority)
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+			s.Release()
+		}(priority)
+		// Queue the waiters in a known arrival order
+		for s.Waiting() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	s.Release()
+	wg.Wait()
+
+	expected := []int{9, 5, 1, 1}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+// TestSchedulerBatchPriorities tests per-item priorities of a scheduled parallel batch
+func TestSchedulerBatchPriorities(t *testing.T) {
+	s := Scheduler("test-sched-batch", 1)
+	if err := s.Acquire(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []int
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"data":      []int{1, 2, 3},
+		"batch":     true,
+		"parallel":  true,
+		"scheduler": "test-sched-batch",
+	})
+	node.SetPriorityFunc(func(input interface{}) int {
+		return input.(int)
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		mu.Lock()
+		order = append(order, item.(int))
+		mu.Unlock()
+		return item, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		node.Run(NewSharedState())
+	}()
+	for s.Waiting() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	s.Release()
+	<-done
+
+	if len(order) != 3 || order[0] != 3 || order[1] != 2 || order[2] != 1 {
+		t.Errorf("Expected items served as [3 2 1], got %v", order)
+	}
+}
+
+// TestSchedulerRunCancelled tests that a queued run gives up once ctx is done
+func TestSchedulerRunCancelled(t *testing.T) {
+	s := Scheduler("test-sched-cancel", 1)
+	if err := s.Acquire(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	node := NewNode()
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		t.Error("Expected the queued run not to start")
+		return nil, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Run(ctx, NewFlow().Start(node), NewSharedState(), 1); err == nil {
+		t.Error("Expected the queued run to be interrupted")
+	}
+	if s.Waiting() != 0 {
+		t.Errorf("Expected the cancelled waiter to leave the queue, %d waiting", s.Waiting())
+	}
+
+	// The slot still works after a cancelled wait
+	s.Release()
+	if action, err := s.Run(context.Background(), NewFlow().Start(NewNode()), NewSharedState(), 0); err != nil || action != DefaultAction {
+		t.Errorf("Expected the run to complete, got %q, %v", action, err)
+	}
+}
+
+// TestSchedulerNodeCancelled tests that a node waiting for a scheduler slot gives
+// up once the run's ctx is done
+func TestSchedulerNodeCancelled(t *testing.T) {
+	s := Scheduler("test-sched-node-cancel", 1)
+	if err := s.Acquire(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Release()
+
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		t.Error("Expected exec not to run without a slot")
+		return nil, nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"scheduler": "test-sched-node-cancel"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pipeline.RunContext(ctx, NewSharedState()); err == nil {
+		t.Error("Expected the run to fail once ctx is done")
+	}
+	if s.Waiting() != 0 {
+		t.Errorf("Expected the cancelled node to leave the queue, %d waiting", s.Waiting())
+	}
+}
@@ -0,0 +1,153 @@
+package Flow
+
+import (
+	"path"
+	"sync"
+)
+
+// RedactedValue replaces secret values wherever state is printed or exported
+const RedactedValue = "[REDACTED]"
+
+// Redactor decides how a value may appear in observability output. It returns the
+// replacement and true to redact the value, or false to leave it to the next
+// redactor. key is the state key holding the value, or "" for values that are not
+// stored under a key, such as exec results and error messages in a journal.
+type Redactor func(key string, value interface{}) (interface{}, bool)
+
+var redactors = struct {
+	mu   sync.RWMutex
+	list []Redactor
+}{}
+
+// AddRedactor registers a redactor applied, in registration order, to every state
+// dump (Snapshot, debug traces, audit entries) and to the results and errors of
+// exported journals, so observability can be enabled in regulated environments.
+// Persisted state (WriteSnapshot) keeps the real values so runs can resume.
+//
+// Example:
+//
+//	flow.AddRedactor(flow.RedactKeys("*_token", "user.email"))
+//	flow.AddRedactor(flow.RedactValues(func(v interface{}) bool {
+//		s, ok := v.(string)
+//		return ok && cardNumber.MatchString(s)
+//	}))
+func AddRedactor(r Redactor) {
+	redactors.mu.Lock()
+	defer redactors.mu.Unlock()
+	redactors.list = append(redactors.list, r)
+}
+
+// RedactKeys returns a Redactor replacing the values of state keys matching any
+// of the glob patterns with RedactedValue
+func RedactKeys(patterns ...string) Redactor {
+	return func(key string, value interface{}) (interface{}, bool) {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, key); matched && key != "" {
+				return RedactedValue, true
+			}
+		}
+		return nil, false
+	}
+}
+
+// RedactValues returns a Redactor replacing every value for which match returns
+// true with RedactedValue, wherever it appears
+func RedactValues(match func(value interface{}) bool) Redactor {
+	return func(_ string, value interface{}) (interface{}, bool) {
+		if match(value) {
+			return RedactedValue, true
+		}
+		return nil, false
+	}
+}
+
+// redact applies the registered redactors to a value about to be printed or exported
+func redact(key string, value interface{}) interface{} {
+	redactors.mu.RLock()
+	defer redactors.mu.RUnlock()
+	for _, r := range redactors.list {
+		if replacement, ok := r(key, value); ok {
+			return replacement
+		}
+	}
+	return value
+}
+
+// redactError returns the message of err as it may be printed or exported
+func redactError(err error) string {
+	message := err.Error()
+	if replacement, ok := redact("", message).(string); ok {
+		return replacement
+	}
+	return RedactedValue
+}
+
+// SecretRef names a secret stored with SetSecret. Used as a param value, it is
+// replaced by the secret from the run's SharedState when the Params passed to a
+// SetExecFuncWithParams function are built, so credentials never sit in node
+// params, where they could leak through GetParam or logged configuration. That
+// is the only place it is resolved: GetParam and the built-in params see the
+// SecretRef itself.
+//
+// Example:
+//
+//	state.SetSecret("openrouter_key", os.Getenv("OPENROUTER_API_KEY"))
+//
+//	llm.SetParams(map[string]interface{}{"api_key": flow.SecretRef("openrouter_key")})
+//	llm.SetExecFuncWithParams(func(params flow.Params, prompt interface{}) (interface{}, error) {
+//		return complete(params.String("api_key"), prompt)
+//	})
+type SecretRef string
+
+// SetSecret stores a secret value under key. It reads back normally with Get,
+// but is replaced by RedactedValue in debug traces, audit entries, Snapshot, and
+// everything built on them (Runner.RunDocument), and in plaintext WriteSnapshot
+// output. The key stays secret if it is overwritten with Set.
+func (s *SharedState) SetSecret(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	if s.secrets == nil {
+		s.secrets = make(map[string]bool)
+	}
+	s.secrets[key] = true
+	s.touch(key)
+}
+
+// IsSecret reports whether key holds a secret stored with SetSecret
+func (s *SharedState) IsSecret(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.secrets[key]
+}
+
+// redacted returns the value of key as it may be printed or exported;
+// callers must hold the lock
+func (s *SharedState) redacted(key string) interface{} {
+	if s.secrets[key] {
+		return RedactedValue
+	}
+	return redact(key, s.data[key])
+}
+
+// withSecrets returns params with every SecretRef replaced by its secret from shared
+func (p Params) withSecrets(shared *SharedState) Params {
+	var resolved Params
+	for key, value := range p {
+		ref, ok := value.(SecretRef)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = make(Params, len(p))
+			for k, v := range p {
+				resolved[k] = v
+			}
+		}
+		resolved[key] = shared.Get(string(ref))
+	}
+	if resolved == nil {
+		return p
+	}
+	return resolved
+}
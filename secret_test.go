@@ -0,0 +1,120 @@
+package Flow
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestSecretsRedacted tests that secrets read back normally but are redacted in traces, audits and exports
+func TestSecretsRedacted(t *testing.T) {
+	state := NewSharedState()
+	state.SetSecret("api_key", "sk-live-123")
+
+	if state.Get("api_key") != "sk-live-123" || !state.IsSecret("api_key") {
+		t.Fatal("Expected the secret to read back with Get")
+	}
+
+	rotate := NewNode()
+	rotate.SetName("rotate")
+	rotate.SetExecFunc(func(prep interface{}) (interface{}, error) { return nil, nil })
+	rotate.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("api_key", "sk-live-456")
+		shared.Set("rotated", true)
+		return DefaultAction
+	})
+	pipeline := NewFlow().Start(rotate)
+	audit := NewMemoryAuditSink()
+	pipeline.SetAuditSink(audit)
+
+	var trace bytes.Buffer
+	DebugTo(&trace, false, pipeline, state)
+
+	if strings.Contains(trace.String(), "sk-live") || !strings.Contains(trace.String(), RedactedValue) {
+		t.Errorf("Expected the trace to redact the secret:\n%s", trace.String())
+	}
+	if mutations := audit.Entries()[0].Mutations; mutations["api_key"] != RedactedValue || mutations["rotated"] != true {
+		t.Errorf("Expected the audit entry to redact the secret, got %v", mutations)
+	}
+	if snapshot := state.Snapshot(); snapshot["api_key"] != RedactedValue {
+		t.Errorf("Expected the snapshot to redact the secret, got %v", snapshot["api_key"])
+	}
+	if state.Get("api_key") != "sk-live-456" {
+		t.Error("Expected an overwritten secret to read back normally")
+	}
+}
+
+// TestSecretRefInjectedIntoParams tests that SecretRef params resolve at exec time only
+func TestSecretRefInjectedIntoParams(t *testing.T) {
+	state := NewSharedState()
+	state.SetSecret("token", "s3cr3t")
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"token": SecretRef("token"), "url": "https://api"})
+	var seen Params
+	node.SetExecFuncWithParams(func(params Params, prep interface{}) (interface{}, error) {
+		seen = params
+		return nil, nil
+	})
+	node.Run(state)
+
+	if seen.String("token") != "s3cr3t" || seen.String("url") != "https://api" {
+		t.Errorf("Expected the secret to be injected, got %v", seen)
+	}
+	if node.GetParam("token") != SecretRef("token") {
+		t.Errorf("Expected node params to keep the reference, got %v", node.GetParam("token"))
+	}
+}
+
+// TestRedactors tests that registered redactors apply to state dumps, traces and exported journals
+func TestRedactors(t *testing.T) {
+	AddRedactor(RedactKeys("test_redact_*"))
+	AddRedactor(RedactValues(func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && strings.Contains(s, "4111-1111")
+	}))
+
+	node := NewNode()
+	node.SetName("charge")
+	calls := 0
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("card 4111-1111 declined")
+		}
+		return "receipt for 4111-1111", nil
+	})
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("test_redact_email", "ada@example.com")
+		shared.Set("card", "4111-1111-1111-1111")
+		shared.Set("amount", 42)
+		return DefaultAction
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"retries": 2})
+	journal := NewJournal()
+	pipeline.Subscribe(journal.Record)
+
+	state := NewSharedState()
+	var trace bytes.Buffer
+	DebugTo(&trace, false, pipeline, state)
+
+	var exported bytes.Buffer
+	if _, err := journal.WriteTo(&exported); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, output := range map[string]string{"trace": trace.String(), "journal": exported.String()} {
+		if strings.Contains(output, "ada@example.com") || strings.Contains(output, "4111-1111") {
+			t.Errorf("Expected the %s to be redacted:\n%s", name, output)
+		}
+	}
+
+	snapshot := state.Snapshot()
+	if snapshot["test_redact_email"] != RedactedValue || snapshot["card"] != RedactedValue || snapshot["amount"] != 42 {
+		t.Errorf("Unexpected snapshot: %v", snapshot)
+	}
+	if state.Get("card") != "4111-1111-1111-1111" {
+		t.Error("Expected Get to return the original value")
+	}
+}
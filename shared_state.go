@@ -18,8 +18,40 @@ import "sync"
 //	userID := state.GetInt("user_id")
 //	results := state.GetSlice("results")
 type SharedState struct {
+	*stateData
+
+	// writes, when set, collects the keys written through this view of the state
+	// for the audit trail; writes are also collected by the parent view, if any
+	writes map[string]struct{}
+	parent *SharedState
+}
+
+// stateData is the storage of a SharedState, shared with the views created for
+// audited nodes
+type stateData struct {
 	data map[string]interface{}
 	mu   sync.RWMutex
+
+	// seq increments on every write; written records the seq of each key's last write
+	seq     uint64
+	written map[string]uint64
+
+	// scope identifies the run using this state, for RunLogger
+	scope runScope
+
+	// secrets marks the keys stored with SetSecret
+	secrets map[string]bool
+
+	// watchers holds the functions registered with Watch, by key
+	watchers map[string][]*watcher
+
+	// resultOwners records the batch node that last stored results under each key
+	resultOwners map[string]*Node
+}
+
+// watcher is one function registered with Watch
+type watcher struct {
+	fn func(value interface{})
 }
 
 // NewSharedState creates a new SharedState instance with an empty data map.
@@ -30,9 +62,10 @@ type SharedState struct {
 //	state := NewSharedState()
 //	state.Set("key", "value")
 func NewSharedState() *SharedState {
-	return &SharedState{
-		data: make(map[string]interface{}),
-	}
+	return &SharedState{stateData: &stateData{
+		data:    make(map[string]interface{}),
+		written: make(map[string]uint64),
+	}}
 }
 
 // Set stores a value in the shared state under the specified key.
@@ -48,8 +81,11 @@ func NewSharedState() *SharedState {
 //	state.Set("results", []string{"a", "b", "c"})
 func (s *SharedState) Set(key string, value interface{}) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.data[key] = value
+	s.touch(key)
+	watchers := s.watchers[key]
+	s.mu.Unlock()
+	notify(watchers, value)
 }
 
 // Get retrieves a value from the shared state by key.
@@ -92,14 +128,158 @@ func (s *SharedState) GetSlice(key string) []interface{} {
 	return []interface{}{}
 }
 
+// Snapshot returns a shallow copy of every key and value in the shared state,
+// with secrets replaced by RedactedValue and the registered redactors applied.
+// It is the view of the state for output such as Runner.RunDocument; persist
+// state with WriteSnapshot, which keeps the real values.
+func (s *SharedState) Snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot := make(map[string]interface{}, len(s.data))
+	for key := range s.data {
+		snapshot[key] = s.redacted(key)
+	}
+	return snapshot
+}
+
+// values returns a shallow copy of every key and value in the shared state as
+// stored, and the keys holding secrets
+func (s *SharedState) values() (map[string]interface{}, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]interface{}, len(s.data))
+	for key, value := range s.data {
+		values[key] = value
+	}
+	return values, sortedKeys(s.secrets)
+}
+
 // Append adds an item to a slice in shared state
 func (s *SharedState) Append(key string, value interface{}) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if existing, ok := s.data[key].([]interface{}); ok {
 		s.data[key] = append(existing, value)
 	} else {
 		s.data[key] = []interface{}{value}
 	}
+	s.touch(key)
+	watchers := s.watchers[key]
+	s.mu.Unlock()
+	notify(watchers, value)
+}
+
+// Watch registers fn to be called after every Set or Append of key, so UIs and
+// concurrently running nodes can react to data as it arrives, e.g. the chunks a
+// streaming node appends (see SetStreamExecFunc). Set passes the new value and
+// Append the appended item. fn runs synchronously on the writing goroutine,
+// after the state is unlocked, so it may read the state but must be fast and
+// safe for concurrent use. The returned function unregisters fn.
+//
+// Example:
+//
+//	stop := state.Watch("answer_stream", func(chunk interface{}) {
+//		fmt.Print(chunk)
+//	})
+//	defer stop()
+func (s *SharedState) Watch(key string, fn func(value interface{})) (stop func()) {
+	w := &watcher{fn: fn}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchers == nil {
+		s.watchers = make(map[string][]*watcher)
+	}
+	s.watchers[key] = append(s.watchers[key], w)
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		watchers := s.watchers[key]
+		for i, existing := range watchers {
+			if existing == w {
+				// Copy rather than splice, since notify may still be ranging over the old slice
+				s.watchers[key] = append(append([]*watcher(nil), watchers[:i]...), watchers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// claimResults records n as the node storing batch results under key and returns
+// the node that stored them before, if any
+func (s *SharedState) claimResults(key string, n *Node) *Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resultOwners == nil {
+		s.resultOwners = make(map[string]*Node)
+	}
+	previous := s.resultOwners[key]
+	s.resultOwners[key] = n
+	return previous
+}
+
+// notify calls watchers with value
+func notify(watchers []*watcher, value interface{}) {
+	for _, w := range watchers {
+		w.fn(value)
+	}
+}
+
+// runScope returns the run, flow and node currently using the state
+func (s *SharedState) runScope() *runScope {
+	return &s.scope
+}
+
+// touch records a write to key; callers must hold the write lock
+func (s *SharedState) touch(key string) {
+	s.seq++
+	s.written[key] = s.seq
+	for view := s; view != nil && view.writes != nil; view = view.parent {
+		view.writes[key] = struct{}{}
+	}
+}
+
+// recording returns a view of the state that collects the keys written through
+// it, so the writes of one node can be told apart from those of nodes running
+// concurrently on the same state, such as the branches of Flow.StartAll
+func (s *SharedState) recording() *SharedState {
+	view := &SharedState{stateData: s.stateData, writes: make(map[string]struct{})}
+	if s.writes != nil {
+		view.parent = s
+	}
+	return view
+}
+
+// recorded returns the current value of every key written through a view
+// created by recording, with secrets redacted since they are audited
+func (s *SharedState) recorded() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := make(map[string]interface{}, len(s.writes))
+	for key := range s.writes {
+		changes[key] = s.redacted(key)
+	}
+	return changes
+}
+
+// sequence returns the current write sequence number
+func (s *SharedState) sequence() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq
+}
+
+// changesSince returns the current value of every key written after seq, with
+// secrets redacted since changes are printed and audited
+func (s *SharedState) changesSince(seq uint64) map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := make(map[string]interface{})
+	for key, written := range s.written {
+		if written > seq {
+			changes[key] = s.redacted(key)
+		}
+	}
+	return changes
 }
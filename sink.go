@@ -0,0 +1,226 @@
+package Flow
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// sink buffers the lines written by a sink node
+type sink struct {
+	key    string
+	format func(w io.Writer, value interface{}) error
+
+	mu  sync.Mutex
+	buf *bufio.Writer
+}
+
+// NewWriterSinkNode creates a node that appends the value stored under key in
+// shared state to w, one line per value formatted with fmt. Slice values write
+// one line per element, so a sink after a batch node can use BatchResultsKey.
+// Writes are buffered and flushed when the flow run ends (also when it panics),
+// or immediately when the node is run on its own. A write or flush error panics.
+//
+// Example:
+//
+//	report := flow.NewWriterSinkNode(os.Stdout, "summary")
+//	transform.Next(report, "default")
+func NewWriterSinkNode(w io.Writer, key string) *Node {
+	return newSinkNode(w, key, func(w io.Writer, value interface{}) error {
+		_, err := fmt.Fprintln(w, value)
+		return err
+	})
+}
+
+// NewJSONLSinkNode creates a node that appends the value stored under key to w as
+// JSON lines. It buffers and expands slices like NewWriterSinkNode.
+//
+// Example:
+//
+//	out, _ := flow.NewRotatingFile("results.jsonl", 64<<20, 5)
+//	defer out.Close()
+//	load := flow.NewJSONLSinkNode(out, flow.BatchResultsKey)
+func NewJSONLSinkNode(w io.Writer, key string) *Node {
+	return newSinkNode(w, key, func(w io.Writer, value interface{}) error {
+		return json.NewEncoder(w).Encode(value)
+	})
+}
+
+func newSinkNode(w io.Writer, key string, format func(io.Writer, interface{}) error) *Node {
+	n := NewNode()
+	n.sink = &sink{key: key, format: format, buf: bufio.NewWriter(w)}
+	return n
+}
+
+// runSink writes the node's state value to its buffer and schedules the flush
+func (n *Node) runSink(ctx context.Context, shared *SharedState) string {
+	s := n.sink
+	value := shared.Get(s.key)
+
+	s.mu.Lock()
+	err := s.write(value)
+	s.mu.Unlock()
+	if err != nil {
+		panic(fmt.Errorf("flow: sink %q write failed: %w", n.name, err))
+	}
+
+	if flushers := flushersFrom(ctx); flushers != nil {
+		flushers.add(s)
+	} else if err := s.flush(); err != nil {
+		panic(fmt.Errorf("flow: sink %q flush failed: %w", n.name, err))
+	}
+	return DefaultAction
+}
+
+// write formats value, expanding slices into one line per element
+func (s *sink) write(value interface{}) error {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if err := s.format(s.buf, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return s.format(s.buf, value)
+}
+
+func (s *sink) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Flush()
+}
+
+// flushSet collects the sinks written during a flow run so they can be flushed at its end
+type flushSet struct {
+	mu    sync.Mutex
+	sinks []*sink
+}
+
+type flushersKey struct{}
+
+func (fs *flushSet) add(s *sink) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, existing := range fs.sinks {
+		if existing == s {
+			return
+		}
+	}
+	fs.sinks = append(fs.sinks, s)
+}
+
+// flush flushes every collected sink, returning the first error
+func (fs *flushSet) flush() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var first error
+	for _, s := range fs.sinks {
+		if err := s.flush(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// flushersFrom returns the flush set of the flow run carried by ctx, or nil
+func flushersFrom(ctx context.Context) *flushSet {
+	fs, _ := ctx.Value(flushersKey{}).(*flushSet)
+	return fs
+}
+
+// RotatingFile is an io.WriteCloser that appends to a file and rotates it once it
+// would exceed a size limit, keeping a bounded number of backups named
+// path.1 (newest) through path.N. It is safe for concurrent use.
+type RotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens path for appending, rotating it once a write would push
+// it past maxBytes (<= 0 disables rotation) and keeping up to backups old files.
+//
+// Parameters:
+//   - path: The file to append to
+//   - maxBytes: The size at which the file is rotated
+//   - backups: The number of rotated files to keep
+//
+// Returns:
+//   - *RotatingFile: The open file
+//   - error: An error if the file cannot be opened
+func NewRotatingFile(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would push the file past its size limit
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups, moves the current file to path.1, and reopens path
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if r.backups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+
+	for i := r.backups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", r.path, i)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", r.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
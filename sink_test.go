@@ -0,0 +1,85 @@
+package Flow
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSinkNodesFlushOnFlowEnd tests buffered writes that are flushed when the flow ends
+func TestSinkNodesFlushOnFlowEnd(t *testing.T) {
+	var text, jsonl bytes.Buffer
+
+	transform := NewNode()
+	transform.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("summary", "3 records")
+		shared.Set(BatchResultsKey, []interface{}{map[string]int{"id": 1}, map[string]int{"id": 2}})
+		return DefaultAction
+	})
+
+	report := NewWriterSinkNode(&text, "summary")
+	load := NewJSONLSinkNode(&jsonl, BatchResultsKey)
+
+	buffered := true
+	check := NewNode()
+	check.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		buffered = text.Len() == 0 && jsonl.Len() == 0
+		return "done"
+	})
+
+	transform.Next(report, DefaultAction)
+	report.Next(load, DefaultAction)
+	load.Next(check, DefaultAction)
+	NewFlow().Start(transform).Run(NewSharedState())
+
+	if !buffered {
+		t.Error("Expected sink output to stay buffered until the flow ends")
+	}
+	if text.String() != "3 records\n" {
+		t.Errorf("Unexpected text output %q", text.String())
+	}
+	if jsonl.String() != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("Unexpected JSONL output %q", jsonl.String())
+	}
+
+	// Run on its own, a sink flushes immediately
+	state := NewSharedState()
+	state.Set("summary", "standalone")
+	text.Reset()
+	report.Run(state)
+	if text.String() != "standalone\n" {
+		t.Errorf("Expected immediate flush, got %q", text.String())
+	}
+}
+
+// TestRotatingFile tests size-based rotation with bounded backups
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+	file, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(name)
+		if err != nil || string(got) != want {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", filepath.Base(name), want, got, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected only 2 backups to be kept")
+	}
+}
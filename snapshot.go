@@ -0,0 +1,77 @@
+package Flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// snapshotSecretsKey lists the secret keys of an encrypted snapshot, so
+// ReadSnapshot restores them as secrets
+const snapshotSecretsKey = "flow.secrets"
+
+// WriteSnapshot writes every key and value of shared to w as JSON, encrypted with
+// enc unless it is nil. Use it to persist the state of checkpoints and
+// interrupted runs; values must be JSON-encodable. Values are written as stored,
+// since a resumed run needs them: redactors registered with AddRedactor only
+// apply to observability output. Secrets stored with SetSecret are kept, and
+// read back as secrets, when enc is set; a plaintext snapshot has them as
+// RedactedValue, and they must be provided again after reading.
+//
+// Example:
+//
+//	runner.SetCheckpointFunc(func(cp flow.Checkpoint) {
+//		f, _ := os.Create(cp.ID + ".state")
+//		defer f.Close()
+//		flow.WriteSnapshot(f, cp.State, enc)
+//	})
+func WriteSnapshot(w io.Writer, shared *SharedState, enc Encrypter) error {
+	values, secrets := shared.values()
+	if enc == nil {
+		for _, key := range secrets {
+			values[key] = RedactedValue
+		}
+	} else if len(secrets) > 0 {
+		values[snapshotSecretsKey] = secrets
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("flow: encoding snapshot: %w", err)
+	}
+	if enc != nil {
+		if data, err = enc.Encrypt(data); err != nil {
+			return fmt.Errorf("flow: encrypting snapshot: %w", err)
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadSnapshot reads state written by WriteSnapshot, decrypting it with enc unless
+// it is nil. Values read back as JSON types, with integral numbers as int.
+func ReadSnapshot(r io.Reader, enc Encrypter) (*SharedState, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		if data, err = enc.Decrypt(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("flow: decoding snapshot: %w", err)
+	}
+	secrets := toStrings(values[snapshotSecretsKey])
+	delete(values, snapshotSecretsKey)
+	shared := NewSharedState()
+	for key, value := range values {
+		shared.Set(key, normalizeJSON(value))
+	}
+	for _, key := range secrets {
+		shared.SetSecret(key, shared.Get(key))
+	}
+	return shared, nil
+}
@@ -0,0 +1,118 @@
+package Flow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestEncryptedSnapshot tests that snapshots round-trip and are not stored in plaintext
+func TestEncryptedSnapshot(t *testing.T) {
+	enc, err := NewAESGCMEncrypter(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	state := NewSharedState()
+	state.Set("email", "ada@example.com")
+	state.Set("retries", 3)
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, state, enc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("ada@example.com")) {
+		t.Error("Expected the snapshot not to contain plaintext")
+	}
+
+	restored, err := ReadSnapshot(bytes.NewReader(buf.Bytes()), enc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.Get("email") != "ada@example.com" || restored.GetInt("retries") != 3 {
+		t.Errorf("Unexpected restored state: %v", restored.Snapshot())
+	}
+
+	tampered := append([]byte(nil), buf.Bytes()...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := ReadSnapshot(bytes.NewReader(tampered), enc); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed for a tampered snapshot, got %v", err)
+	}
+
+	other, _ := NewAESGCMEncrypter(bytes.Repeat([]byte{8}, 32))
+	if _, err := ReadSnapshot(bytes.NewReader(buf.Bytes()), other); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed with the wrong key, got %v", err)
+	}
+}
+
+// TestPlainSnapshot tests that a nil Encrypter writes readable JSON
+func TestPlainSnapshot(t *testing.T) {
+	state := NewSharedState()
+	state.Set("step", "fetch")
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, state, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != `{"step":"fetch"}` {
+		t.Errorf("Unexpected snapshot: %s", buf.String())
+	}
+	if _, err := NewAESGCMEncrypter([]byte("short")); err == nil {
+		t.Error("Expected an invalid key length to be rejected")
+	}
+}
+
+// TestSnapshotKeepsRedactedValues tests that a run resumed from a snapshot sees
+// the real values of redacted keys and secrets
+func TestSnapshotKeepsRedactedValues(t *testing.T) {
+	AddRedactor(RedactKeys("test_snapshot_account"))
+	enc, err := NewAESGCMEncrypter(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	state := NewSharedState()
+	state.Set("test_snapshot_account", "acct-42")
+	state.SetSecret("api_key", "sk-live")
+	if state.Snapshot()["test_snapshot_account"] != RedactedValue {
+		t.Fatal("Expected Snapshot to redact the account")
+	}
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, state, enc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := ReadSnapshot(bytes.NewReader(buf.Bytes()), enc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	charge := NewNode()
+	charge.SetName("charge")
+	charge.SetExecFuncWithState(func(shared *SharedState, _ interface{}) (interface{}, error) {
+		return fmt.Sprintf("%v:%v", shared.Get("test_snapshot_account"), shared.Get("api_key")), nil
+	})
+	start := NewNode()
+	start.Next(charge, DefaultAction)
+	f := NewFlow().Start(start)
+	f.SetName("billing")
+	runner := NewRunner()
+	runner.Register(f)
+
+	action, err := runner.Resume(Checkpoint{RunInfo: RunInfo{ID: "run-1", Flow: "billing"}, State: restored, NextNode: charge})
+	if err != nil || action != "acct-42:sk-live" {
+		t.Errorf("Expected the resumed run to see the real values, got action %q err %v", action, err)
+	}
+	if !restored.IsSecret("api_key") || restored.Snapshot()["api_key"] != RedactedValue {
+		t.Error("Expected the secret to be restored as a secret")
+	}
+
+	// Plaintext snapshots never hold secrets
+	buf.Reset()
+	if err := WriteSnapshot(&buf, state, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("acct-42")) || bytes.Contains(buf.Bytes(), []byte("sk-live")) {
+		t.Errorf("Expected the plaintext snapshot to keep the account but not the secret: %s", buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	// ItemAction is the action under which a channel source's per-item subgraph is registered
+	ItemAction = "item"
+	// ChannelItemKey is the SharedState key holding the received value during a per-item traversal
+	ChannelItemKey = "channel_item"
+	// ParentStateKey is the SharedState key holding the run's *SharedState during a per-item traversal
+	ParentStateKey = "parent_state"
+)
+
+// defaultSourceParallelLimit bounds concurrent traversals of a parallel channel source
+const defaultSourceParallelLimit = 10
+
+type flowKey struct{}
+
+// NewChannelSourceNode creates a node that feeds every value received from ch
+// through the subgraph registered under ItemAction, bridging existing producer
+// code into a flow. Each traversal runs on its own SharedState holding the value
+// under ChannelItemKey and the run's state under ParentStateKey, so items can be
+// processed in parallel; write aggregate results to the parent state. Once ch is
+// closed and every traversal has finished, the node returns "default" so the flow
+// continues with its default successor.
+//
+// With "parallel": true, up to "parallel_limit" items (default 10) are processed
+// concurrently. A panicking traversal stops the source from receiving further
+// values and is re-raised once in-flight traversals finish.
+//
+// Example:
+//
+//	source := flow.NewChannelSourceNode(events)
+//	source.Next(handleEvent, flow.ItemAction)
+//	source.Next(summarize, flow.DefaultAction)
+func NewChannelSourceNode(ch <-chan interface{}) *Node {
+	n := NewNode()
+	n.source = ch
+	return n
+}
+
+// withFlow records the running flow in ctx so nodes can orchestrate subgraphs
+func withFlow(ctx context.Context, f *Flow) context.Context {
+	return context.WithValue(ctx, flowKey{}, f)
+}
+
+// flowFrom returns the flow recorded in ctx, or a bare flow outside of one
+func flowFrom(ctx context.Context) *Flow {
+	if f, ok := ctx.Value(flowKey{}).(*Flow); ok {
+		return f
+	}
+	return NewFlow()
+}
+
+// runSource drains the node's channel, orchestrating the item subgraph for each value
+func (n *Node) runSource(ctx context.Context, shared *SharedState) string {
+	subgraph, ok := n.successor(ItemAction)
+	if !ok || subgraph == nil {
+		panic(fmt.Errorf("flow: channel source %q has no successor for action %q", n.name, ItemAction))
+	}
+	f := flowFrom(ctx)
+
+	limit := 1
+	if n.getBoolParam(ctx, "parallel") {
+		limit = n.getIntParam(ctx, "parallel_limit")
+		if limit <= 0 {
+			limit = defaultSourceParallelLimit
+		}
+	}
+
+	sem := make(chan struct{}, limit)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first interface{}
+	received := 0
+	interrupted := false
+
+	traverse := func(value interface{}) {
+		defer wg.Done()
+		defer func() { <-sem }()
+		defer func() {
+			if r := recover(); r != nil {
+				once.Do(func() {
+					first = r
+					close(stop)
+				})
+			}
+		}()
+
+		item := NewSharedState()
+		item.Set(ChannelItemKey, value)
+		item.Set(ParentStateKey, shared)
+		if _, _, err := f.orchestrate(ctx, item, subgraph, nil); err != nil {
+			panic(fmt.Errorf("flow: channel source item interrupted: %w", err))
+		}
+	}
+
+receive:
+	for {
+		// Wait for a free slot before receiving, so values stay in ch until they can run
+		select {
+		case sem <- struct{}{}:
+		case <-stop:
+			break receive
+		case <-ctx.Done():
+			interrupted = true
+			break receive
+		}
+
+		select {
+		case value, ok := <-n.source:
+			if !ok {
+				<-sem
+				break receive
+			}
+			received++
+			wg.Add(1)
+			go traverse(value)
+		case <-stop:
+			<-sem
+			break receive
+		case <-ctx.Done():
+			<-sem
+			interrupted = true
+			break receive
+		}
+	}
+	wg.Wait()
+
+	if first != nil {
+		panic(first)
+	}
+	if interrupted {
+		panic(fmt.Errorf("flow: channel source interrupted after %d items: %w", received, ctx.Err()))
+	}
+	return DefaultAction
+}
@@ -0,0 +1,94 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestChannelSourceNode tests feeding channel values through the item subgraph
+func TestChannelSourceNode(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			ch := make(chan interface{})
+			go func() {
+				defer close(ch)
+				for i := 1; i <= 20; i++ {
+					ch <- i
+				}
+			}()
+
+			square := NewNode()
+			square.SetPrepFunc(func(shared *SharedState) interface{} {
+				return shared.Get(ChannelItemKey)
+			})
+			square.SetExecFunc(func(prep interface{}) (interface{}, error) {
+				return prep.(int) * prep.(int), nil
+			})
+			square.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+				shared.Get(ParentStateKey).(*SharedState).Append("squares", exec)
+				return DefaultAction
+			})
+
+			summarized := false
+			summary := NewNode()
+			summary.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+				summarized = len(shared.GetSlice("squares")) == 20
+				return "summarized"
+			})
+
+			source := NewChannelSourceNode(ch)
+			source.Next(square, ItemAction)
+			source.Next(summary, DefaultAction)
+
+			pipeline := NewFlow().Start(source)
+			pipeline.SetParams(map[string]interface{}{"parallel": parallel, "parallel_limit": 4})
+
+			state := NewSharedState()
+			if action := pipeline.Run(state); action != "summarized" {
+				t.Errorf("Expected action 'summarized', got '%s'", action)
+			}
+			if !summarized {
+				t.Errorf("Expected all 20 items before the summary, got %d", len(state.GetSlice("squares")))
+			}
+		})
+	}
+}
+
+// TestChannelSourceStops tests failure propagation and cancellation
+func TestChannelSourceStops(t *testing.T) {
+	ch := make(chan interface{}, 10)
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+
+	failing := NewNode()
+	failing.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("bad event")
+	})
+	source := NewChannelSourceNode(ch)
+	source.Next(failing, ItemAction)
+
+	func() {
+		defer func() {
+			if err, _ := recover().(error); nodeErrorCause(err) != "bad event" {
+				t.Errorf("Expected item failure to propagate, got %v", err)
+			}
+		}()
+		source.Run(NewSharedState())
+	}()
+	if len(ch) != 9 {
+		t.Errorf("Expected the source to stop receiving after the failure, %d values left", len(ch))
+	}
+
+	// An open channel is abandoned once the run's context is done
+	idle := NewChannelSourceNode(make(chan interface{}))
+	idle.Next(NewNode(), ItemAction)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if _, err := NewFlow().Start(idle).RunContext(ctx, NewSharedState()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
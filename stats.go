@@ -0,0 +1,169 @@
+package Flow
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencySmoothing is the weight given to the newest sample in the rolling average latency
+const latencySmoothing = 0.2
+
+// NodeStats is a snapshot of a node's execution statistics.
+// Statistics are recorded automatically on every Run and are safe to read while
+// the node is executing.
+type NodeStats struct {
+	// Name is the node's name (see SetName)
+	Name string
+	// Executions counts completed and failed runs
+	Executions int64
+	// Failures counts runs that ended in a panic (e.g. retries exhausted)
+	Failures int64
+	// LastDuration is the wall-clock duration of the most recent run
+	LastDuration time.Duration
+	// AverageLatency is an exponentially weighted rolling average of run durations
+	AverageLatency time.Duration
+}
+
+// FailureRate returns the fraction of executions that failed, or 0 if the node never ran
+func (s NodeStats) FailureRate() float64 {
+	if s.Executions == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Executions)
+}
+
+// FlowStats aggregates the statistics of every node reachable from a flow's start node
+type FlowStats struct {
+	// Executions is the total number of node executions across the flow
+	Executions int64
+	// Failures is the total number of failed node executions across the flow
+	Failures int64
+	// Nodes holds per-node statistics in traversal order from the start node
+	Nodes []NodeStats
+	// Overhead is the time the flow itself spent between its nodes, recorded once
+	// enabled with SetOverheadStats
+	Overhead OverheadStats
+}
+
+// OverheadStats is the orchestration overhead of a flow's node transitions,
+// including those of its StartAll branches, accumulated over every run: the time
+// spent outside the nodes themselves, split by what it was spent on.
+type OverheadStats struct {
+	// Transitions counts the nodes the flow has run
+	Transitions int64
+	// Params is the time spent applying the flow's params to its nodes
+	Params time.Duration
+	// State is the time spent recording the current node in the shared state
+	State time.Duration
+	// Routing is the time spent finding the next node after each node ran
+	Routing time.Duration
+}
+
+// PerTransition returns the average overhead of a node transition, or 0 if the
+// flow never ran a node
+func (s OverheadStats) PerTransition() time.Duration {
+	if s.Transitions == 0 {
+		return 0
+	}
+	return (s.Params + s.State + s.Routing) / time.Duration(s.Transitions)
+}
+
+// overheadStats accumulates a flow's OverheadStats without locking
+type overheadStats struct {
+	enabled                             atomic.Bool
+	transitions, params, state, routing atomic.Int64
+}
+
+// SetOverheadStats enables or disables recording the flow's orchestration
+// overhead in FlowStats.Overhead. It is off by default, sparing every transition
+// the clock reads, e.g. to find out whether a flow of thousands of tiny nodes
+// spends noticeable time between them.
+func (f *Flow) SetOverheadStats(enabled bool) {
+	f.overhead.enabled.Store(enabled)
+}
+
+// record adds one transition to the statistics
+func (s *overheadStats) record(params, state, routing time.Duration) {
+	s.transitions.Add(1)
+	s.params.Add(int64(params))
+	s.state.Add(int64(state))
+	s.routing.Add(int64(routing))
+}
+
+// snapshot returns the accumulated statistics
+func (s *overheadStats) snapshot() OverheadStats {
+	return OverheadStats{
+		Transitions: s.transitions.Load(),
+		Params:      time.Duration(s.params.Load()),
+		State:       time.Duration(s.state.Load()),
+		Routing:     time.Duration(s.routing.Load()),
+	}
+}
+
+// FailureRate returns the fraction of node executions that failed across the flow
+func (s FlowStats) FailureRate() float64 {
+	if s.Executions == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Executions)
+}
+
+// nodeStats accumulates execution statistics for a single node
+type nodeStats struct {
+	mu           sync.Mutex
+	executions   int64
+	failures     int64
+	lastDuration time.Duration
+	avgLatency   float64
+}
+
+// record adds one execution to the statistics
+func (s *nodeStats) record(duration time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.executions++
+	if failed {
+		s.failures++
+	}
+	s.lastDuration = duration
+	if s.executions == 1 {
+		s.avgLatency = float64(duration)
+	} else {
+		s.avgLatency += latencySmoothing * (float64(duration) - s.avgLatency)
+	}
+}
+
+// Stats returns a snapshot of the node's execution statistics.
+//
+// Example:
+//
+//	stats := node.Stats()
+//	fmt.Printf("%s: %d runs, %.1f%% failed, avg %v\n",
+//		stats.Name, stats.Executions, stats.FailureRate()*100, stats.AverageLatency)
+func (n *Node) Stats() NodeStats {
+	n.stats.mu.Lock()
+	defer n.stats.mu.Unlock()
+
+	return NodeStats{
+		Name:           n.name,
+		Executions:     n.stats.executions,
+		Failures:       n.stats.failures,
+		LastDuration:   n.stats.lastDuration,
+		AverageLatency: time.Duration(n.stats.avgLatency),
+	}
+}
+
+// Stats aggregates the statistics of every node reachable from the start node,
+// along with the flow's own orchestration overhead
+func (f *Flow) Stats() FlowStats {
+	stats := FlowStats{Overhead: f.overhead.snapshot()}
+	for _, node := range f.nodes() {
+		nodeStats := node.Stats()
+		stats.Executions += nodeStats.Executions
+		stats.Failures += nodeStats.Failures
+		stats.Nodes = append(stats.Nodes, nodeStats)
+	}
+	return stats
+}
@@ -0,0 +1,145 @@
+package Flow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestNodeStats tests execution counting, failures, and latency tracking
+func TestNodeStats(t *testing.T) {
+	state := NewSharedState()
+	fail := false
+
+	node := NewNode()
+	node.SetName("lookup")
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond * 5)
+		if fail {
+			return nil, fmt.Errorf("lookup failed")
+		}
+		return "found", nil
+	})
+
+	for i := 0; i < 3; i++ {
+		node.Run(state)
+	}
+
+	fail = true
+	func() {
+		defer func() { _ = recover() }()
+		node.Run(state)
+	}()
+
+	stats := node.Stats()
+	if stats.Name != "lookup" {
+		t.Errorf("Expected name 'lookup', got '%s'", stats.Name)
+	}
+	if stats.Executions != 4 {
+		t.Errorf("Expected 4 executions, got %d", stats.Executions)
+	}
+	if stats.Failures != 1 {
+		t.Errorf("Expected 1 failure, got %d", stats.Failures)
+	}
+	if stats.FailureRate() != 0.25 {
+		t.Errorf("Expected failure rate 0.25, got %f", stats.FailureRate())
+	}
+	if stats.LastDuration < time.Millisecond*5 {
+		t.Errorf("Expected last duration >= 5ms, got %v", stats.LastDuration)
+	}
+	if stats.AverageLatency < time.Millisecond*5 {
+		t.Errorf("Expected average latency >= 5ms, got %v", stats.AverageLatency)
+	}
+}
+
+// TestFlowStats tests aggregation of node statistics across a flow
+func TestFlowStats(t *testing.T) {
+	first := NewNode()
+	first.SetName("first")
+	first.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "next", nil
+	})
+
+	second := NewNode()
+	second.SetName("second")
+	second.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return "done", nil
+	})
+	first.Next(second, "next")
+
+	pipeline := NewFlow().Start(first)
+	pipeline.Run(NewSharedState())
+	pipeline.Run(NewSharedState())
+
+	stats := pipeline.Stats()
+	if stats.Executions != 4 {
+		t.Errorf("Expected 4 executions, got %d", stats.Executions)
+	}
+	if stats.FailureRate() != 0 {
+		t.Errorf("Expected failure rate 0, got %f", stats.FailureRate())
+	}
+	if len(stats.Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(stats.Nodes))
+	}
+	if stats.Nodes[0].Name != "first" || stats.Nodes[1].Name != "second" {
+		t.Errorf("Unexpected node order: %s, %s", stats.Nodes[0].Name, stats.Nodes[1].Name)
+	}
+}
+
+// tinyChain builds a flow of size nodes that do nothing but route to the next
+func tinyChain(size int) *Flow {
+	start := NewNode()
+	curr := start
+	for i := 1; i < size; i++ {
+		curr = curr.Next(NewNode(), DefaultAction)
+	}
+	return NewFlow().Start(start)
+}
+
+// TestFlowOverheadStats tests the orchestration overhead recorded per transition
+func TestFlowOverheadStats(t *testing.T) {
+	pipeline := tinyChain(1000)
+	pipeline.Run(NewSharedState())
+	if overhead := pipeline.Stats().Overhead; overhead.Transitions != 0 {
+		t.Fatalf("Expected no overhead recorded by default, got %+v", overhead)
+	}
+
+	pipeline.SetOverheadStats(true)
+	pipeline.Run(NewSharedState())
+	pipeline.Run(NewSharedState())
+	overhead := pipeline.Stats().Overhead
+	if overhead.Transitions != 2000 {
+		t.Fatalf("Expected 2000 transitions, got %d", overhead.Transitions)
+	}
+	if overhead.Params <= 0 || overhead.State <= 0 || overhead.Routing <= 0 || overhead.PerTransition() <= 0 {
+		t.Errorf("Expected every kind of overhead to be measured, got %+v", overhead)
+	}
+
+	// Nodes already holding the flow's params are not set again
+	owner := NewNode()
+	owner.SetParams(map[string]interface{}{"retries": 0})
+	node := NewNode()
+	node.adoptParams(owner)
+	version := node.paramsVersion
+	node.adoptParams(owner)
+	if node.paramsVersion != version {
+		t.Error("Expected unchanged params not to be set again")
+	}
+	owner.SetParams(map[string]interface{}{"retries": 1})
+	node.adoptParams(owner)
+	if node.GetParam("retries") != 1 {
+		t.Errorf("Expected new flow params to replace the node's, got %v", node.GetParam("retries"))
+	}
+}
+
+// BenchmarkFlowTransitions measures the orchestration of a chain of tiny nodes
+func BenchmarkFlowTransitions(b *testing.B) {
+	pipeline := tinyChain(1000)
+	pipeline.SetOverheadStats(true)
+	state := NewSharedState()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pipeline.Run(state)
+	}
+	b.ReportMetric(float64(pipeline.Stats().Overhead.PerTransition().Nanoseconds()), "overhead-ns/transition")
+}
@@ -0,0 +1,91 @@
+package Flow
+
+import "context"
+
+// StepRunner executes a flow one node per Step call, exposing the pending node,
+// the last action and the shared state in between, for interactively exploring
+// complex flows during development. The state may be inspected or edited between
+// steps, and the graph may be changed as long as the pending node stays valid.
+//
+// Example:
+//
+//	stepper := flow.NewStepRunner(pipeline, state)
+//	for !stepper.Done() {
+//		fmt.Printf("next: %s (last action %q)\n", stepper.Pending().Name(), stepper.LastAction())
+//		bufio.NewReader(os.Stdin).ReadString('\n')
+//		stepper.Step()
+//	}
+type StepRunner struct {
+	flow       *Flow
+	shared     *SharedState
+	ctx        context.Context
+	finish     func() error
+	pending    *Node
+	lastAction string
+	steps      int
+}
+
+// NewStepRunner prepares a step-by-step run of the flow on shared, starting at
+// the flow's start node. No node runs until Step is called.
+func NewStepRunner(f *Flow, shared *SharedState) *StepRunner {
+	ctx, finish := f.prepareRun(context.Background(), shared)
+	s := &StepRunner{
+		flow:    f,
+		shared:  shared,
+		ctx:     ctx,
+		finish:  finish,
+		pending: f.startNode,
+	}
+	if s.pending == nil {
+		s.complete()
+	}
+	return s
+}
+
+// Step runs the pending node and returns the action it chose. It reports false,
+// without running anything, once the flow is finished. Panics from the node
+// propagate as with Flow.Run and leave the node pending, so it can be retried.
+func (s *StepRunner) Step() (string, bool) {
+	if s.pending == nil {
+		return s.lastAction, false
+	}
+
+	s.steps++
+	s.lastAction, s.pending = s.flow.step(s.ctx, s.shared, s.pending)
+	if s.pending == nil {
+		s.complete()
+	}
+	return s.lastAction, true
+}
+
+// complete ends the run, flushing sinks written during it
+func (s *StepRunner) complete() {
+	if err := s.finish(); err != nil {
+		panic(err)
+	}
+}
+
+// Pending returns the node the next Step will run, or nil once the flow is finished
+func (s *StepRunner) Pending() *Node {
+	return s.pending
+}
+
+// LastAction returns the action chosen by the most recent step, or "" before the first
+func (s *StepRunner) LastAction() string {
+	return s.lastAction
+}
+
+// Steps returns the number of steps taken so far, including failed ones
+func (s *StepRunner) Steps() int {
+	return s.steps
+}
+
+// State returns the shared state of the run
+func (s *StepRunner) State() *SharedState {
+	return s.shared
+}
+
+// Done reports whether the flow is finished
+func (s *StepRunner) Done() bool {
+	return s.pending == nil
+}
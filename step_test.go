@@ -0,0 +1,63 @@
+package Flow
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestStepRunner tests executing a flow one node per step
+func TestStepRunner(t *testing.T) {
+	first := NewNode()
+	first.SetName("first")
+	first.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("count", shared.GetInt("count")+1)
+		return "next"
+	})
+
+	attempts := 0
+	second := NewNode()
+	second.SetName("second")
+	second.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, fmt.Errorf("flaky")
+		}
+		return "done", nil
+	})
+	first.Next(second, "next")
+
+	stepper := NewStepRunner(NewFlow().Start(first), NewSharedState())
+	if stepper.Pending() != first || stepper.LastAction() != "" || stepper.Done() {
+		t.Fatal("Expected the start node to be pending before the first step")
+	}
+
+	if action, ok := stepper.Step(); !ok || action != "next" {
+		t.Errorf("Expected first step to return 'next', got '%s' %v", action, ok)
+	}
+	if stepper.Pending() != second || stepper.State().GetInt("count") != 1 {
+		t.Errorf("Expected second node pending with count 1, got %v and %d", stepper.Pending(), stepper.State().GetInt("count"))
+	}
+
+	// The state can be edited between steps, and a failed step stays pending
+	stepper.State().Set("count", 10)
+	func() {
+		defer func() { _ = recover() }()
+		stepper.Step()
+	}()
+	if stepper.Pending() != second {
+		t.Error("Expected the failed node to remain pending")
+	}
+
+	if action, ok := stepper.Step(); !ok || action != "done" {
+		t.Errorf("Expected retried step to return 'done', got '%s' %v", action, ok)
+	}
+	if !stepper.Done() || stepper.Steps() != 3 {
+		t.Errorf("Expected the flow to be finished after 3 steps, got done=%v steps=%d", stepper.Done(), stepper.Steps())
+	}
+	if _, ok := stepper.Step(); ok {
+		t.Error("Expected Step to report false once finished")
+	}
+	if stepper.State().GetInt("count") != 10 {
+		t.Errorf("Expected the edited state to be kept, got %d", stepper.State().GetInt("count"))
+	}
+}
@@ -0,0 +1,145 @@
+package Flow
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// SetStreamExecFunc sets a business logic function that produces its result
+// incrementally, such as a model call streaming tokens or a chunked HTTP
+// response. Each chunk passed to emit is appended to the SharedState slice under
+// the "stream_key" param as it arrives, notifying the key's watchers (see
+// SharedState.Watch), so downstream consumers can start on partial output before
+// exec returns. The returned result goes to post as usual. Without "stream_key",
+// emit does nothing. It replaces any previously set exec function.
+//
+// A key left over from an earlier exec call is reset to an empty slice before
+// the first attempt of the next one, and every attempt collects its own chunks. Only one attempt at a time
+// streams into the key: a failed attempt resets it for the next one, and when a
+// hedged attempt wins over the one streaming, the key is set to the winner's
+// chunks. Watchers see each reset as a Set of the new slice. In batch mode every
+// item streams into its own key, "stream_key" suffixed with ":" and the item's
+// index, e.g. "answer_stream:3".
+//
+// Example:
+//
+//	node.SetStreamExecFunc(func(prompt interface{}, emit func(interface{})) (interface{}, error) {
+//		var answer strings.Builder
+//		for token := range complete(prompt.(string)) {
+//			answer.WriteString(token)
+//			emit(token)
+//		}
+//		return answer.String(), nil
+//	})
+//	node.SetParams(map[string]interface{}{"stream_key": "answer_stream"})
+func (n *Node) SetStreamExecFunc(fn func(input interface{}, emit func(chunk interface{})) (interface{}, error)) {
+	if fn == nil {
+		n.execFunc = nil
+		return
+	}
+	n.execFunc = func(ctx context.Context, shared *SharedState, input interface{}, meta ItemMeta) (interface{}, error) {
+		key := n.getStringParam(ctx, "stream_key")
+		if key == "" {
+			return fn(input, func(interface{}) {})
+		}
+		if n.getBoolParam(ctx, "batch") {
+			key += ":" + strconv.Itoa(meta.Index)
+		}
+
+		stream := streamFrom(ctx)
+		if stream == nil {
+			// Called outside of an exec call, so this is its only attempt
+			stream = &streamScope{}
+		}
+		stream.begin(shared, key)
+		attempt := &streamAttempt{}
+		result, err := fn(input, func(chunk interface{}) {
+			stream.emit(shared, key, attempt, chunk)
+		})
+		stream.finish(shared, key, attempt, err == nil)
+		return result, err
+	}
+}
+
+type streamKey struct{}
+
+// streamScope coordinates the attempts of one exec call streaming into a key, so
+// that hedged and retried attempts never mix their chunks
+type streamScope struct {
+	mu        sync.Mutex
+	started   bool
+	live      *streamAttempt // the attempt appending its chunks to the key
+	committed bool           // an attempt succeeded, so later chunks are dropped
+}
+
+// streamAttempt holds the chunks emitted by one attempt
+type streamAttempt struct {
+	chunks []interface{}
+}
+
+// withStream gives the exec call running under ctx its own stream scope
+func withStream(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamKey{}, &streamScope{})
+}
+
+// streamFrom returns the stream scope carried by ctx, or nil outside an exec call
+func streamFrom(ctx context.Context) *streamScope {
+	s, _ := ctx.Value(streamKey{}).(*streamScope)
+	return s
+}
+
+// begin clears key before the first attempt of the exec call, unless it is unset
+func (s *streamScope) begin(shared *SharedState, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		s.started = true
+		if shared.Get(key) != nil {
+			shared.Set(key, []interface{}{})
+		}
+	}
+}
+
+// emit records a chunk of attempt, appending it to key if the attempt is the one
+// streaming or no attempt is
+func (s *streamScope) emit(shared *SharedState, key string, attempt *streamAttempt, chunk interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.committed {
+		return
+	}
+	attempt.chunks = append(attempt.chunks, chunk)
+	switch {
+	case s.live == attempt:
+		shared.Append(key, chunk)
+	case s.live == nil && len(attempt.chunks) == 1:
+		s.live = attempt
+		shared.Append(key, chunk)
+	case s.live == nil:
+		// Takes over from a failed attempt with the chunks it buffered meanwhile
+		s.live = attempt
+		shared.Set(key, append([]interface{}{}, attempt.chunks...))
+	}
+}
+
+// finish ends attempt, committing its chunks to key if it succeeded and resetting
+// key if it failed while streaming
+func (s *streamScope) finish(shared *SharedState, key string, attempt *streamAttempt, succeeded bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.committed {
+		return
+	}
+	if succeeded {
+		s.committed = true
+		if s.live != attempt {
+			shared.Set(key, append([]interface{}{}, attempt.chunks...))
+		}
+		return
+	}
+	if s.live == attempt {
+		s.live = nil
+		shared.Set(key, []interface{}{})
+	}
+}
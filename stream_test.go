@@ -0,0 +1,140 @@
+package Flow
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStreamExec tests that chunks reach the stream key and its watchers before exec returns
+func TestStreamExec(t *testing.T) {
+	var seen []interface{}
+	var beforeReturn int
+	node := NewNode()
+	node.SetStreamExecFunc(func(input interface{}, emit func(interface{})) (interface{}, error) {
+		for _, chunk := range []string{"Hel", "lo"} {
+			emit(chunk)
+		}
+		beforeReturn = len(seen)
+		return "Hello", nil
+	})
+	node.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		shared.Set("answer", exec)
+		return "default"
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"stream_key": "answer_stream"})
+
+	state := NewSharedState()
+	stop := state.Watch("answer_stream", func(chunk interface{}) { seen = append(seen, chunk) })
+	pipeline.Run(state)
+
+	if beforeReturn != 2 || !reflect.DeepEqual(seen, []interface{}{"Hel", "lo"}) {
+		t.Errorf("Expected watchers to see both chunks before exec returned, got %v (%d before return)", seen, beforeReturn)
+	}
+	if got := state.GetSlice("answer_stream"); !reflect.DeepEqual(got, []interface{}{"Hel", "lo"}) {
+		t.Errorf("Expected the chunks in state, got %v", got)
+	}
+	if state.Get("answer") != "Hello" {
+		t.Errorf("Expected post to receive the full result, got %v", state.Get("answer"))
+	}
+
+	stop()
+	state.Append("answer_stream", "!")
+	if len(seen) != 2 {
+		t.Errorf("Expected no notifications after stop, got %v", seen)
+	}
+}
+
+// TestStreamExecRetry tests that a retried call restarts the stream
+func TestStreamExecRetry(t *testing.T) {
+	attempts := 0
+	node := NewNode()
+	node.SetStreamExecFunc(func(input interface{}, emit func(interface{})) (interface{}, error) {
+		attempts++
+		emit(attempts)
+		if attempts == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return "ok", nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"stream_key": "out", "retries": 2})
+
+	state := NewSharedState()
+	pipeline.Run(state)
+	if got := state.GetSlice("out"); !reflect.DeepEqual(got, []interface{}{2}) {
+		t.Errorf("Expected only the chunks of the successful attempt, got %v", got)
+	}
+}
+
+// TestStreamExecHedged tests that a winning hedged attempt replaces the chunks of
+// the slow attempt, whose later chunks are dropped
+func TestStreamExecHedged(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	done := make(chan struct{})
+	node := NewNode()
+	node.SetStreamExecFunc(func(input interface{}, emit func(interface{})) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			emit("slow")
+			<-release
+			emit("late")
+			close(done)
+			return "slow", nil
+		}
+		emit("fast-1")
+		emit("fast-2")
+		return "fast", nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"stream_key": "out", "hedge_after": 10 * time.Millisecond})
+
+	state := NewSharedState()
+	if action := pipeline.Run(state); action != "fast" {
+		t.Fatalf("Expected the hedged call to win, got %q", action)
+	}
+	if got := state.GetSlice("out"); !reflect.DeepEqual(got, []interface{}{"fast-1", "fast-2"}) {
+		t.Errorf("Expected only the winning attempt's chunks, got %v", got)
+	}
+	close(release)
+	<-done
+	if got := state.GetSlice("out"); !reflect.DeepEqual(got, []interface{}{"fast-1", "fast-2"}) {
+		t.Errorf("Expected the losing attempt's later chunks to be dropped, got %v", got)
+	}
+}
+
+// TestStreamExecBatch tests that batch items stream into their own keys, so a
+// retried item leaves the others' chunks alone
+func TestStreamExecBatch(t *testing.T) {
+	failed := false
+	node := NewNode()
+	node.SetStreamExecFunc(func(input interface{}, emit func(interface{})) (interface{}, error) {
+		emit(input)
+		if input == "b" && !failed {
+			failed = true
+			return nil, errors.New("connection reset")
+		}
+		emit(input.(string) + "!")
+		return input, nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{
+		"data":       []string{"a", "b"},
+		"batch":      true,
+		"retries":    2,
+		"stream_key": "out",
+	})
+
+	state := NewSharedState()
+	state.Set("out:0", []interface{}{"stale"})
+	pipeline.Run(state)
+	if got := state.GetSlice("out:0"); !reflect.DeepEqual(got, []interface{}{"a", "a!"}) {
+		t.Errorf("Expected item 0's chunks in place of the stale ones, got %v", got)
+	}
+	if got := state.GetSlice("out:1"); !reflect.DeepEqual(got, []interface{}{"b", "b!"}) {
+		t.Errorf("Expected only the successful attempt's chunks for item 1, got %v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package Flow
+
+import (
+	"fmt"
+	"time"
+)
+
+// TenantKey is the SharedState key under which FlowPool stores the tenant of each
+// run when a SetTenantFunc func is registered
+const TenantKey = "tenant"
+
+// TenantQuota bounds the runs a FlowPool starts for one tenant. Zero fields are unlimited.
+type TenantQuota struct {
+	// MaxConcurrent is the most runs of the tenant in progress at once
+	MaxConcurrent int
+	// Rate is the most runs of the tenant started per Period
+	Rate int
+	// Period is the window Rate applies to; one second if zero
+	Period time.Duration
+}
+
+// SetTenantFunc sets the function tagging each run with the tenant its input
+// belongs to, and returns the FlowPool for method chaining. The tenant is stored
+// under TenantKey in the run's state and reported in PoolResult.Tenant. Runs are
+// started round-robin across tenants within their quotas, so one tenant's backlog
+// cannot hold the pool's workers while another tenant waits.
+//
+// Example:
+//
+//	pool := flow.NewFlowPool(pipeline, 16).
+//		SetTenantFunc(func(input interface{}) string { return input.(Job).Tenant }).
+//		SetDefaultTenantQuota(flow.TenantQuota{MaxConcurrent: 4, Rate: 100, Period: time.Minute})
+func (p *FlowPool) SetTenantFunc(fn func(input interface{}) string) *FlowPool {
+	p.tenantOf = fn
+	return p
+}
+
+// SetTenantQuota sets the quota of one tenant, overriding the default quota, and
+// returns the FlowPool for method chaining
+func (p *FlowPool) SetTenantQuota(tenant string, quota TenantQuota) *FlowPool {
+	if p.quotas == nil {
+		p.quotas = make(map[string]TenantQuota)
+	}
+	p.quotas[tenant] = quota
+	return p
+}
+
+// SetDefaultTenantQuota sets the quota of every tenant without its own and returns
+// the FlowPool for method chaining. By default tenants are bounded only by the
+// pool's worker limit.
+func (p *FlowPool) SetDefaultTenantQuota(quota TenantQuota) *FlowPool {
+	p.defaultQuota = quota
+	return p
+}
+
+// tenantQueue holds the runs of one tenant waiting to start, in input order
+type tenantQueue struct {
+	name    string
+	quota   TenantQuota
+	pending []int
+	running int
+	rate    *TokenBucket // nil without a rate quota
+}
+
+// newTenantQueue creates the queue of a tenant with its quota
+func (p *FlowPool) newTenantQueue(tenant string) *tenantQueue {
+	quota, ok := p.quotas[tenant]
+	if !ok {
+		quota = p.defaultQuota
+	}
+	q := &tenantQueue{name: tenant, quota: quota}
+	if quota.Rate > 0 {
+		q.rate = newTokenBucket(fmt.Sprintf("tenant:%s", tenant), quota.Rate, quota.Period)
+	}
+	return q
+}
+
+// ready reports whether the tenant may start its next run at now, taking a rate
+// token if so. Otherwise it returns how long until a rate token is available, or
+// 0 if the tenant is waiting for one of its runs to finish.
+func (q *tenantQueue) ready(now time.Time) (bool, time.Duration) {
+	if len(q.pending) == 0 || (q.quota.MaxConcurrent > 0 && q.running >= q.quota.MaxConcurrent) {
+		return false, 0
+	}
+	if q.rate != nil {
+		if wait := q.rate.take(now); wait > 0 {
+			return false, wait
+		}
+	}
+	return true, 0
+}
+
+// tenantScheduler picks the next run to start, round-robin across tenants
+type tenantScheduler struct {
+	queues []*tenantQueue
+	byName map[string]*tenantQueue
+	next   int // queue to consider first
+	queued int
+}
+
+// newTenantScheduler queues every input under its tenant
+func (p *FlowPool) newTenantScheduler(tenants []string) *tenantScheduler {
+	s := &tenantScheduler{byName: make(map[string]*tenantQueue)}
+	for i, tenant := range tenants {
+		q, ok := s.byName[tenant]
+		if !ok {
+			q = p.newTenantQueue(tenant)
+			s.byName[tenant] = q
+			s.queues = append(s.queues, q)
+		}
+		q.pending = append(q.pending, i)
+		s.queued++
+	}
+	return s
+}
+
+// pick returns the index of the next run to start and its queue. If none may
+// start, it returns -1 and how long until a rate quota allows one (0 if only a
+// finishing run can unblock the pool).
+func (s *tenantScheduler) pick(now time.Time) (int, *tenantQueue, time.Duration) {
+	var soonest time.Duration
+	for i := range s.queues {
+		q := s.queues[(s.next+i)%len(s.queues)]
+		ok, wait := q.ready(now)
+		if !ok {
+			if wait > 0 && (soonest == 0 || wait < soonest) {
+				soonest = wait
+			}
+			continue
+		}
+		s.next = (s.next + i + 1) % len(s.queues)
+		index := q.pending[0]
+		q.pending = q.pending[1:]
+		q.running++
+		s.queued--
+		return index, q, 0
+	}
+	return -1, nil, soonest
+}
+
+// drain removes and returns every run still waiting to start
+func (s *tenantScheduler) drain() []int {
+	var indices []int
+	for _, q := range s.queues {
+		indices = append(indices, q.pending...)
+		q.pending = nil
+	}
+	s.queued = 0
+	return indices
+}
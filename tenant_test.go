@@ -0,0 +1,96 @@
+package Flow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFlowPoolTenantConcurrency tests that a tenant's concurrency quota doesn't hold the pool
+func TestFlowPoolTenantConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	inFlight := map[string]int{}
+	peak := map[string]int{}
+	node := NewNode()
+	node.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		tenant := shared.Get(TenantKey).(string)
+		mu.Lock()
+		order = append(order, tenant)
+		inFlight[tenant]++
+		if inFlight[tenant] > peak[tenant] {
+			peak[tenant] = inFlight[tenant]
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight[tenant]--
+		mu.Unlock()
+		return "done", nil
+	})
+
+	inputs := []interface{}{"bulk", "bulk", "bulk", "bulk", "bulk", "bulk", "interactive", "interactive"}
+	pool := NewFlowPool(NewFlow().Start(node), 4).
+		SetTenantFunc(func(input interface{}) string { return input.(string) }).
+		SetTenantQuota("bulk", TenantQuota{MaxConcurrent: 1})
+	results, err := pool.Run(context.Background(), inputs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if peak["bulk"] != 1 {
+		t.Errorf("Expected at most 1 concurrent bulk run, got %d", peak["bulk"])
+	}
+	if peak["interactive"] != 2 {
+		t.Errorf("Expected both interactive runs to run together, got %d", peak["interactive"])
+	}
+	// Interactive runs queued behind the bulk backlog start right away
+	for _, tenant := range order[3:] {
+		if tenant != "bulk" {
+			t.Errorf("Expected interactive runs to start first, got order %v", order)
+			break
+		}
+	}
+	if results[6].Tenant != "interactive" || results[0].Tenant != "bulk" {
+		t.Errorf("Expected results to carry their tenant, got %+v", results)
+	}
+}
+
+// TestFlowPoolTenantRate tests that a tenant's rate quota spaces out its runs
+func TestFlowPoolTenantRate(t *testing.T) {
+	var mu sync.Mutex
+	starts := map[string][]time.Time{}
+	node := NewNode()
+	node.SetExecFuncWithState(func(shared *SharedState, prep interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		tenant := shared.Get(TenantKey).(string)
+		starts[tenant] = append(starts[tenant], time.Now())
+		return "done", nil
+	})
+
+	inputs := []interface{}{"metered", "metered", "metered", "metered", "free", "free"}
+	pool := NewFlowPool(NewFlow().Start(node), 4).
+		SetTenantFunc(func(input interface{}) string { return input.(string) }).
+		SetTenantQuota("metered", TenantQuota{Rate: 2, Period: 100 * time.Millisecond})
+
+	began := time.Now()
+	if _, err := pool.Run(context.Background(), inputs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	metered := starts["metered"]
+	if len(metered) != 4 {
+		t.Fatalf("Expected 4 metered runs, got %d", len(metered))
+	}
+	// Two runs use the initial burst; the other two wait for 50ms refills each
+	if elapsed := metered[3].Sub(began); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the rate quota to delay the last metered run, started after %v", elapsed)
+	}
+	for _, start := range starts["free"] {
+		if start.Sub(began) > 40*time.Millisecond {
+			t.Errorf("Expected unmetered runs not to wait for the metered tenant, started after %v", start.Sub(began))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SuppressedAction is returned by a node whose execution was suppressed by its
+// "debounce" parameter. Flows end at a suppressed node unless it has a successor
+// registered for SuppressedAction; it does not fall back to "default".
+const SuppressedAction = "suppressed"
+
+// gate tracks the execution times used by the "debounce" and "throttle" parameters
+type gate struct {
+	mu        sync.Mutex
+	lastCall  time.Time
+	nextStart time.Time
+}
+
+// admit applies the node's debounce and throttle parameters before it runs.
+// With "debounce", a call within the interval of the previous call is suppressed
+// and admit reports false, so a burst of triggers collapses into its first one.
+// With "throttle", admit waits until the interval has passed since the previous
+// admitted start, capping the node's execution frequency. A throttle wait stopped
+// because ctx is done panics with an error wrapping ctx.Err().
+func (n *Node) admit(ctx context.Context) bool {
+	debounce := n.getDurationParam(ctx, "debounce")
+	throttle := n.getDurationParam(ctx, "throttle")
+	if debounce <= 0 && throttle <= 0 {
+		return true
+	}
+
+	n.gate.mu.Lock()
+	now := time.Now()
+	if debounce > 0 {
+		previous := n.gate.lastCall
+		n.gate.lastCall = now
+		if !previous.IsZero() && now.Sub(previous) < debounce {
+			n.gate.mu.Unlock()
+			return false
+		}
+	}
+
+	var wait time.Duration
+	if throttle > 0 {
+		start := now
+		if n.gate.nextStart.After(now) {
+			start = n.gate.nextStart
+		}
+		n.gate.nextStart = start.Add(throttle)
+		wait = start.Sub(now)
+	}
+	n.gate.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			panic(fmt.Errorf("flow: throttled node %q interrupted: %w", n.name, ctx.Err()))
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestDebounce tests that bursts of calls collapse into the first one
+func TestDebounce(t *testing.T) {
+	counter := &mockCounter{}
+
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"debounce": time.Millisecond * 30})
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		counter.increment()
+		return "handled", nil
+	})
+
+	state := NewSharedState()
+	actions := []string{node.Run(state), node.Run(state), node.Run(state)}
+	if actions[0] != "handled" || actions[1] != SuppressedAction || actions[2] != SuppressedAction {
+		t.Errorf("Expected first call handled and the rest suppressed, got %v", actions)
+	}
+
+	time.Sleep(time.Millisecond * 40)
+	if action := node.Run(state); action != "handled" {
+		t.Errorf("Expected call after the quiet interval to run, got '%s'", action)
+	}
+	if counter.count != 2 {
+		t.Errorf("Expected 2 executions, got %d", counter.count)
+	}
+
+	// A suppressed node ends the flow rather than following "default"
+	next := NewNode()
+	next.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		t.Error("Expected flow to stop at the suppressed node")
+		return nil, nil
+	})
+	node.Next(next, DefaultAction)
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"debounce": time.Hour})
+	if action := pipeline.Run(state); action != SuppressedAction {
+		t.Errorf("Expected flow to end suppressed, got '%s'", action)
+	}
+}
+
+// TestThrottle tests that throttled starts are spaced by the interval
+func TestThrottle(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{"throttle": time.Millisecond * 20})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		node.Run(NewSharedState())
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*60 {
+		t.Errorf("Expected 4 throttled runs to take at least 60ms, took %v", elapsed)
+	}
+
+	// A throttle wait is interrupted by cancellation
+	node.SetParams(map[string]interface{}{"throttle": time.Hour})
+	node.Run(NewSharedState())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"throttle": time.Hour})
+	if _, err := pipeline.RunContext(ctx, NewSharedState()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrContextWindow is returned in place of calling exec when a node's prompt does
+// not fit its model's context window. It is permanent, so the call isn't retried.
+var ErrContextWindow = errors.New("flow: prompt exceeds the model's context window")
+
+// Policies for the "context_policy" param, applied when a prompt is over budget
+const (
+	// ContextReject fails the call with ErrContextWindow (the default)
+	ContextReject = "reject"
+	// ContextTruncate cuts the prompt to fit: a string keeps its beginning, and a
+	// []Message drops its oldest turns after any leading system prompt
+	ContextTruncate = "truncate"
+)
+
+// Tokenizer counts the tokens a model family's tokenizer produces for text
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// TokenizerFunc adapts a function to the Tokenizer interface, e.g. the encoder of
+// a BPE library:
+//
+//	enc, _ := tiktoken.GetEncoding("o200k_base")
+//	flow.RegisterModel("gpt-4o", 128000, flow.TokenizerFunc(func(text string) int {
+//		return len(enc.Encode(text, nil, nil))
+//	}))
+type TokenizerFunc func(text string) int
+
+// Count calls fn(text)
+func (fn TokenizerFunc) Count(text string) int {
+	return fn(text)
+}
+
+// EstimateTokenizer counts tokens with EstimateTokens. It is the tokenizer of the
+// built-in model families, since the package ships no vocabularies; register an
+// exact tokenizer with RegisterModel where budgets are tight.
+var EstimateTokenizer Tokenizer = TokenizerFunc(EstimateTokens)
+
+// modelInfo is the context window and tokenizer of a model family
+type modelInfo struct {
+	window    int
+	tokenizer Tokenizer
+}
+
+// modelRegistry maps model name prefixes to their family, preloaded with common families
+var modelRegistry = struct {
+	mu     sync.RWMutex
+	models map[string]modelInfo
+}{
+	models: map[string]modelInfo{
+		"gpt-4o":        {window: 128000, tokenizer: EstimateTokenizer},
+		"gpt-4-turbo":   {window: 128000, tokenizer: EstimateTokenizer},
+		"gpt-4":         {window: 8192, tokenizer: EstimateTokenizer},
+		"gpt-3.5-turbo": {window: 16385, tokenizer: EstimateTokenizer},
+		"o1":            {window: 200000, tokenizer: EstimateTokenizer},
+		"claude-3":      {window: 200000, tokenizer: EstimateTokenizer},
+		"gemini-1.5":    {window: 1048576, tokenizer: EstimateTokenizer},
+		"llama-3":       {window: 8192, tokenizer: EstimateTokenizer},
+		"mistral":       {window: 32768, tokenizer: EstimateTokenizer},
+	},
+}
+
+// RegisterModel registers the context window and tokenizer of the models whose
+// names start with prefix, replacing any earlier registration of the prefix. The
+// longest matching prefix wins, and provider prefixes such as "openai/" in
+// OpenRouter model names are ignored. A nil tokenizer means EstimateTokenizer.
+//
+// Parameters:
+//   - prefix: The model name prefix, e.g. "gpt-4o"
+//   - window: The context window in tokens
+//   - tokenizer: Counts tokens for the family
+func RegisterModel(prefix string, window int, tokenizer Tokenizer) {
+	if tokenizer == nil {
+		tokenizer = EstimateTokenizer
+	}
+	modelRegistry.mu.Lock()
+	defer modelRegistry.mu.Unlock()
+	modelRegistry.models[prefix] = modelInfo{window: window, tokenizer: tokenizer}
+}
+
+// lookupModel returns the family of model by longest prefix
+func lookupModel(model string) (modelInfo, bool) {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	modelRegistry.mu.RLock()
+	defer modelRegistry.mu.RUnlock()
+	var prefixes []string
+	for prefix := range modelRegistry.models {
+		if strings.HasPrefix(model, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return modelInfo{}, false
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return modelRegistry.models[prefixes[0]], true
+}
+
+// ContextWindow returns the registered context window of model, or 0 if unknown
+func ContextWindow(model string) int {
+	info, _ := lookupModel(model)
+	return info.window
+}
+
+// CountTokens counts the tokens of text with the tokenizer registered for model,
+// or EstimateTokenizer if the model is unknown
+func CountTokens(model, text string) int {
+	if info, ok := lookupModel(model); ok {
+		return info.tokenizer.Count(text)
+	}
+	return EstimateTokenizer.Count(text)
+}
+
+// fitContext checks a string or []Message exec input against the context window
+// configured by the "model", "context_window" and "reserve_tokens" params, and
+// truncates or rejects it per "context_policy". Other inputs, and nodes without
+// a known window, pass through unchanged.
+func (n *Node) fitContext(ctx context.Context, input interface{}) (interface{}, error) {
+	model := n.getStringParam(ctx, "model")
+	info, _ := lookupModel(model)
+	if info.tokenizer == nil {
+		info.tokenizer = EstimateTokenizer
+	}
+	if window := n.getIntParam(ctx, "context_window"); window > 0 {
+		info.window = window
+	}
+	if info.window <= 0 {
+		return input, nil
+	}
+	budget := info.window - n.getIntParam(ctx, "reserve_tokens")
+	truncate := n.getStringParam(ctx, "context_policy") == ContextTruncate
+
+	var count int
+	switch prompt := input.(type) {
+	case string:
+		if count = info.tokenizer.Count(prompt); count <= budget {
+			return input, nil
+		}
+		if truncate && budget > 0 {
+			return truncateText(info.tokenizer, prompt, budget), nil
+		}
+	case []Message:
+		if count = countMessages(info.tokenizer, prompt); count <= budget {
+			return input, nil
+		}
+		if truncate {
+			if fitted, ok := truncateMessages(info.tokenizer, prompt, budget); ok {
+				return fitted, nil
+			}
+		}
+	default:
+		return input, nil
+	}
+	return nil, Permanent(fmt.Errorf("%w: %d tokens over a budget of %d", ErrContextWindow, count, budget))
+}
+
+// countMessages counts the tokens of a conversation, including per-message framing
+func countMessages(t Tokenizer, history []Message) int {
+	total := 0
+	for _, m := range history {
+		total += messageOverhead + t.Count(m.Content)
+	}
+	return total
+}
+
+// truncateText returns the longest prefix of text within budget tokens
+func truncateText(t Tokenizer, text string, budget int) string {
+	runes := []rune(text)
+	// The longest prefix that fits, by binary search over its length in runes
+	keep := sort.Search(len(runes)+1, func(i int) bool {
+		return t.Count(string(runes[:i])) > budget
+	}) - 1
+	if keep < 0 {
+		keep = 0
+	}
+	return string(runes[:keep])
+}
+
+// truncateMessages drops the oldest turns after a leading system prompt until the
+// history fits budget, always keeping the final turn. It reports false if even
+// that doesn't fit.
+func truncateMessages(t Tokenizer, history []Message, budget int) ([]Message, bool) {
+	start := 0
+	if len(history) > 0 && history[0].Role == "system" {
+		start = 1
+	}
+	for drop := 0; start+drop < len(history); drop++ {
+		fitted := append(append([]Message(nil), history[:start]...), history[start+drop:]...)
+		if countMessages(t, fitted) <= budget {
+			return fitted, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,97 @@
+package Flow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestModelRegistry tests prefix lookup of context windows and registered tokenizers
+func TestModelRegistry(t *testing.T) {
+	if got := ContextWindow("gpt-4o-mini"); got != 128000 {
+		t.Errorf("Expected gpt-4o-mini to match the gpt-4o family, got %d", got)
+	}
+	if got := ContextWindow("gpt-4-0613"); got != 8192 {
+		t.Errorf("Expected gpt-4-0613 to match gpt-4, got %d", got)
+	}
+	if got := ContextWindow("anthropic/claude-3-haiku"); got != 200000 {
+		t.Errorf("Expected the provider prefix to be ignored, got %d", got)
+	}
+	if got := ContextWindow("unknown-model"); got != 0 {
+		t.Errorf("Expected 0 for an unknown model, got %d", got)
+	}
+
+	RegisterModel("test-words", 100, TokenizerFunc(func(text string) int {
+		return len(strings.Fields(text))
+	}))
+	if got := CountTokens("test-words-v2", "one two three"); got != 3 {
+		t.Errorf("Expected the registered tokenizer to count 3 words, got %d", got)
+	}
+	if got := CountTokens("unknown-model", "12345678"); got != 2 {
+		t.Errorf("Expected the estimate for an unknown model, got %d", got)
+	}
+}
+
+// TestContextWindowReject tests that an oversized prompt fails without calling exec or retrying
+func TestContextWindowReject(t *testing.T) {
+	calls := 0
+	node := NewNode()
+	node.SetPrepFunc(func(*SharedState) interface{} { return strings.Repeat("word ", 100) })
+	node.SetExecFunc(func(interface{}) (interface{}, error) {
+		calls++
+		return "ok", nil
+	})
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"context_window": 100, "reserve_tokens": 20, "retries": 3})
+
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrContextWindow) {
+				t.Errorf("Expected ErrContextWindow, got %v", err)
+			}
+		}()
+		pipeline.Run(NewSharedState())
+	}()
+	if calls != 0 {
+		t.Errorf("Expected exec not to be called, got %d calls", calls)
+	}
+}
+
+// TestContextWindowTruncate tests truncation of string and message prompts
+func TestContextWindowTruncate(t *testing.T) {
+	RegisterModel("test-words", 100, TokenizerFunc(func(text string) int {
+		return len(strings.Fields(text))
+	}))
+
+	var got interface{}
+	node := NewNode()
+	node.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		got = prep
+		return "ok", nil
+	})
+	node.SetPrepFunc(func(shared *SharedState) interface{} { return shared.Get("prompt") })
+	pipeline := NewFlow().Start(node)
+	pipeline.SetParams(map[string]interface{}{"model": "test-words", "context_window": 5, "context_policy": ContextTruncate})
+
+	state := NewSharedState()
+	state.Set("prompt", "a b c d e f g h")
+	pipeline.Run(state)
+	if s, _ := got.(string); strings.TrimSpace(s) != "a b c d e" {
+		t.Errorf("Expected the prompt to keep its first 5 words, got %q", got)
+	}
+
+	// Each message costs 4 tokens of framing plus its words
+	pipeline.SetParams(map[string]interface{}{"model": "test-words", "context_window": 14, "context_policy": ContextTruncate})
+	state.Set("prompt", []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "first question"},
+		{Role: "assistant", Content: "first answer"},
+		{Role: "user", Content: "next"},
+	})
+	pipeline.Run(state)
+	history, _ := got.([]Message)
+	if len(history) != 2 || history[0].Role != "system" || history[1].Content != "next" {
+		t.Errorf("Expected the system prompt and the last turn, got %+v", got)
+	}
+}
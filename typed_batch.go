@@ -0,0 +1,205 @@
+package Flow
+
+import (
+	"context"
+	"fmt"
+)
+
+// batchItemsKey carries the *batchRequest of a RunBatch call to the node's dispatch
+type batchItemsKey struct{}
+
+// batchRequest holds the items of a RunBatch call; items is nil once dispatch has
+// taken them
+type batchRequest struct {
+	items           []interface{}
+	continueOnError bool
+}
+
+// RunBatch runs node in batch mode over items and returns its results typed as R,
+// in item order, instead of the []interface{} stored under "batch_results". The
+// node's other parameters apply as usual ("parallel", "retries", ...), and its
+// "batch" and "data" parameters are not needed. Failures are returned as errors
+// rather than panics, and an exec result that is not an R is reported as an error.
+// Items skipped without a result (for example by "dedup") yield R's zero value.
+//
+// Example:
+//
+//	fetch := flow.NewNode()
+//	fetch.SetParams(map[string]interface{}{"parallel": true, "retries": 3})
+//	fetch.SetExecFunc(func(url interface{}) (interface{}, error) {
+//		return http.Get(url.(string))
+//	})
+//	responses, err := flow.RunBatch[string, *http.Response](fetch, urls)
+func RunBatch[T, R any](node *Node, items []T) ([]R, error) {
+	return RunBatchContext[T, R](context.Background(), node, items)
+}
+
+// RunBatchContext is RunBatch under ctx; once ctx is done no further items are
+// started and the returned error wraps ctx.Err()
+func RunBatchContext[T, R any](ctx context.Context, node *Node, items []T) ([]R, error) {
+	shared, err := runBatchRequest(ctx, node, items, false)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := shared.GetSlice(node.resultsKey(ctx))
+	results := make([]R, len(raw))
+	for i, value := range raw {
+		if value == nil {
+			continue
+		}
+		typed, ok := value.(R)
+		if !ok {
+			return nil, fmt.Errorf("flow: batch item %d returned %T, not %T", i, value, results[i])
+		}
+		results[i] = typed
+	}
+	return results, nil
+}
+
+// runBatchRequest runs node in batch mode over items on a fresh state, returning
+// its failure as an error
+func runBatchRequest[T any](ctx context.Context, node *Node, items []T, continueOnError bool) (shared *SharedState, err error) {
+	data := make([]interface{}, len(items))
+	for i, item := range items {
+		data[i] = item
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicToError(r)
+		}
+	}()
+	shared = NewSharedState()
+	req := &batchRequest{items: data, continueOnError: continueOnError}
+	node.run(context.WithValue(ctx, batchItemsKey{}, req), shared)
+	return shared, nil
+}
+
+// batchItemsFrom returns the items passed to RunBatch, and a ctx whose request no
+// longer carries them so they are taken only once
+func batchItemsFrom(ctx context.Context) ([]interface{}, context.Context, bool) {
+	req, ok := ctx.Value(batchItemsKey{}).(*batchRequest)
+	if !ok || req.items == nil {
+		return nil, ctx, false
+	}
+	return req.items, context.WithValue(ctx, batchItemsKey{}, &batchRequest{continueOnError: req.continueOnError}), true
+}
+
+// BatchItemResultsKey is the SharedState key under which batch nodes with
+// "continue_on_error": true store a []Result[interface{}] with every item's outcome
+const BatchItemResultsKey = "batch_item_results"
+
+// Result is the outcome of one batch item. Batches with "continue_on_error" keep
+// going when an item fails and report each item's value or error, so partial
+// failures are handled explicitly instead of aborting the whole batch.
+//
+// Example:
+//
+//	results, err := flow.RunBatchResults[string, Page](crawl, urls)
+//	for _, r := range results {
+//		if r.Err != nil {
+//			log.Printf("%s failed after %d attempts: %v", urls[r.Index], r.Attempts, r.Err)
+//		}
+//	}
+type Result[T any] struct {
+	// Value is the item's exec result (the zero value if it failed)
+	Value T
+	// Err is the item's final error, or nil if it succeeded
+	Err error
+	// Index is the position of the item in the batch
+	Index int
+	// Attempts is the number of exec calls made for the item
+	Attempts int
+}
+
+// RunBatchResults runs node in batch mode over items like RunBatch, but always
+// continues past failed items and returns every item's outcome in item order.
+// The error reports failures of the batch as a whole, such as cancellation or a
+// result that is not an R.
+func RunBatchResults[T, R any](node *Node, items []T) ([]Result[R], error) {
+	return RunBatchResultsContext[T, R](context.Background(), node, items)
+}
+
+// RunBatchResultsContext is RunBatchResults under ctx
+func RunBatchResultsContext[T, R any](ctx context.Context, node *Node, items []T) ([]Result[R], error) {
+	shared, err := runBatchRequest(ctx, node, items, true)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _ := shared.Get(BatchItemResultsKey).([]Result[interface{}])
+	results := make([]Result[R], len(raw))
+	for i, r := range raw {
+		results[i] = Result[R]{Err: r.Err, Index: r.Index, Attempts: r.Attempts}
+		if r.Value == nil {
+			continue
+		}
+		typed, ok := r.Value.(R)
+		if !ok {
+			return nil, fmt.Errorf("flow: batch item %d returned %T, not %T", i, r.Value, results[i].Value)
+		}
+		results[i].Value = typed
+	}
+	return results, nil
+}
+
+// batchOutcomes collects per-item Results when "continue_on_error" is enabled.
+// All methods are no-ops on a nil collector.
+type batchOutcomes struct {
+	results []Result[interface{}]
+}
+
+// continueOnError reports whether a failed batch item should not stop the batch
+func (n *Node) continueOnError(ctx context.Context) bool {
+	if req, ok := ctx.Value(batchItemsKey{}).(*batchRequest); ok && req.continueOnError {
+		return true
+	}
+	return n.getBoolParam(ctx, "continue_on_error")
+}
+
+// newBatchOutcomes returns a collector for total items if failures don't stop the batch
+func (n *Node) newBatchOutcomes(ctx context.Context, total int) *batchOutcomes {
+	if !n.continueOnError(ctx) {
+		return nil
+	}
+	results := make([]Result[interface{}], total)
+	for i := range results {
+		results[i].Index = i
+	}
+	return &batchOutcomes{results: results}
+}
+
+// itemRecorder returns a child of rec counting one item's attempts
+func (o *batchOutcomes) itemRecorder(rec *retryRecorder) *retryRecorder {
+	if o == nil {
+		return rec
+	}
+	return &retryRecorder{parent: rec}
+}
+
+// record stores the outcome of one item; items write distinct elements, so
+// parallel workers need no lock
+func (o *batchOutcomes) record(index int, rec *retryRecorder, result interface{}, err error) {
+	if o == nil {
+		return
+	}
+	var attempts int
+	if rec != nil {
+		rec.mu.Lock()
+		attempts = rec.telemetry.Attempts
+		rec.mu.Unlock()
+	}
+	o.results[index] = Result[interface{}]{Value: result, Err: err, Index: index, Attempts: attempts}
+	if err != nil {
+		o.results[index].Value = nil
+	}
+}
+
+// store writes the collected outcomes to shared state
+func (o *batchOutcomes) store(shared *SharedState) {
+	if o == nil {
+		return
+	}
+	shared.Set(BatchItemResultsKey, o.results)
+}
@@ -0,0 +1,129 @@
+package Flow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRunBatch tests typed results in item order for sequential and parallel batches
+func TestRunBatch(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		node := NewNode()
+		node.SetParams(map[string]interface{}{"parallel": parallel})
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			return len(item.(string)), nil
+		})
+
+		lengths, err := RunBatch[string, int](node, []string{"map", "reduce", "flow"})
+		if err != nil {
+			t.Fatalf("Unexpected error (parallel %v): %v", parallel, err)
+		}
+		if len(lengths) != 3 || lengths[0] != 3 || lengths[1] != 6 || lengths[2] != 4 {
+			t.Errorf("Expected [3 6 4] (parallel %v), got %v", parallel, lengths)
+		}
+	}
+}
+
+// TestRunBatchErrors tests exec failures, mistyped results and cancellation
+func TestRunBatchErrors(t *testing.T) {
+	failing := NewNode()
+	failing.SetName("validate")
+	failing.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) < 0 {
+			return nil, errors.New("negative item")
+		}
+		return item, nil
+	})
+	_, err := RunBatch[int, int](failing, []int{1, -1, 2})
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Index != 1 || !errors.Is(err, ErrBatchPartialFailure) {
+		t.Errorf("Expected batch failure on item 1, got %v", err)
+	}
+
+	mistyped := NewNode()
+	mistyped.SetExecFunc(func(item interface{}) (interface{}, error) {
+		return "text", nil
+	})
+	if _, err := RunBatch[int, int](mistyped, []int{1}); err == nil || !strings.Contains(err.Error(), "returned string, not int") {
+		t.Errorf("Expected result type error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RunBatchContext[int, int](ctx, failing, []int{1, 2}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+}
+
+// TestRunBatchResults tests per-item outcomes when failed items don't stop the batch
+func TestRunBatchResults(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		node := NewNode()
+		node.SetParams(map[string]interface{}{"parallel": parallel, "retries": 2})
+		node.SetExecFunc(func(item interface{}) (interface{}, error) {
+			if item.(int) < 0 {
+				return nil, errors.New("negative item")
+			}
+			return item.(int) * 2, nil
+		})
+
+		results, err := RunBatchResults[int, int](node, []int{1, -1, 3})
+		if err != nil {
+			t.Fatalf("Unexpected error (parallel %v): %v", parallel, err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results (parallel %v), got %v", parallel, results)
+		}
+		for i, r := range results {
+			if r.Index != i {
+				t.Errorf("Expected index %d, got %d", i, r.Index)
+			}
+		}
+		if results[0].Value != 2 || results[0].Err != nil || results[0].Attempts != 1 {
+			t.Errorf("Expected item 0 to succeed on the first attempt, got %+v", results[0])
+		}
+		if results[1].Err == nil || nodeErrorCause(results[1].Err) != "negative item" || results[1].Attempts != 2 {
+			t.Errorf("Expected item 1 to fail after 2 attempts, got %+v", results[1])
+		}
+		if results[2].Value != 6 {
+			t.Errorf("Expected item 2 to continue after the failure, got %+v", results[2])
+		}
+	}
+}
+
+// TestContinueOnErrorParam tests the continue_on_error param on a batch node
+func TestContinueOnErrorParam(t *testing.T) {
+	node := NewNode()
+	node.SetParams(map[string]interface{}{
+		"batch":             true,
+		"data":              []int{1, -1, 3},
+		"continue_on_error": true,
+		"resume_key":        "progress",
+	})
+	node.SetExecFunc(func(item interface{}) (interface{}, error) {
+		if item.(int) < 0 {
+			return nil, errors.New("negative item")
+		}
+		return item, nil
+	})
+
+	state := NewSharedState()
+	if action := node.Run(state); action != BatchCompleteAction {
+		t.Errorf("Expected %q, got %q", BatchCompleteAction, action)
+	}
+	results := state.GetSlice(BatchResultsKey)
+	if len(results) != 3 || results[0] != 1 || results[1] != nil || results[2] != 3 {
+		t.Errorf("Expected [1 <nil> 3], got %v", results)
+	}
+	outcomes := state.Get(BatchItemResultsKey).([]Result[interface{}])
+	if outcomes[1].Err == nil || outcomes[1].Value != nil {
+		t.Errorf("Expected item 1 to record its failure, got %+v", outcomes[1])
+	}
+
+	// Progress is kept so a re-run only retries the failed item
+	if progress, ok := state.Get("progress").(*BatchProgress); !ok || progress.Completed() != 2 {
+		t.Errorf("Expected progress with 2 completed items, got %v", state.Get("progress"))
+	}
+}
@@ -0,0 +1,233 @@
+package Flow
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ValidAction is returned by validation nodes when every rule passes
+	ValidAction = "valid"
+	// InvalidAction is returned by validation nodes when at least one rule fails
+	InvalidAction = "invalid"
+	// ViolationsKey is the SharedState key under which validation nodes store their []Violation
+	ViolationsKey = "violations"
+)
+
+// Violation describes a validation rule that failed
+type Violation struct {
+	// Field is the path of the invalid field ("" for the validated value itself)
+	Field string `json:"field"`
+	// Message says what is wrong with it
+	Message string `json:"message"`
+}
+
+// Error formats the violation as "field: message"
+func (v Violation) Error() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// Rule validates one field of the value checked by a validation node. Fields are
+// dotted paths through maps (by key) and structs (by field name or json tag); the
+// empty path is the value itself.
+type Rule struct {
+	field string
+	check func(value interface{}, present bool) string
+}
+
+// Required fails when the field is missing, nil, or an empty string, slice or map
+func Required(field string) Rule {
+	return Rule{field: field, check: func(value interface{}, present bool) string {
+		if !present || isEmptyValue(value) {
+			return "is required"
+		}
+		return ""
+	}}
+}
+
+// Range fails when a present numeric field is outside [min, max]
+func Range(field string, min, max float64) Rule {
+	return Rule{field: field, check: func(value interface{}, present bool) string {
+		if !present {
+			return ""
+		}
+		n, ok := toFloat(value)
+		if !ok {
+			return "must be a number"
+		}
+		if n < min || n > max {
+			return fmt.Sprintf("must be between %v and %v", min, max)
+		}
+		return ""
+	}}
+}
+
+// Format fails when a present field is not a string matching the regular
+// expression pattern. It panics if pattern does not compile.
+func Format(field, pattern string) Rule {
+	re := regexp.MustCompile(pattern)
+	return Rule{field: field, check: func(value interface{}, present bool) string {
+		if !present {
+			return ""
+		}
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if !re.MatchString(s) {
+			return fmt.Sprintf("must match %s", pattern)
+		}
+		return ""
+	}}
+}
+
+// Check fails when fn returns an error for a present field, using the error as the message
+func Check(field string, fn func(value interface{}) error) Rule {
+	return Rule{field: field, check: func(value interface{}, present bool) string {
+		if !present {
+			return ""
+		}
+		if err := fn(value); err != nil {
+			return err.Error()
+		}
+		return ""
+	}}
+}
+
+// NewValidationNode creates a node that validates the struct, map or value stored
+// under key in shared state. It stores every failed rule as a []Violation under
+// ViolationsKey (empty when valid) and routes to ValidAction or InvalidAction.
+//
+// Parameters:
+//   - key: The SharedState key holding the value to validate
+//   - rules: The rules to check, in order
+//
+// Returns:
+//   - *Node: The validation node
+//
+// Example:
+//
+//	validate := flow.NewValidationNode("signup",
+//		flow.Required("email"),
+//		flow.Format("email", `^[^@\s]+@[^@\s]+$`),
+//		flow.Range("age", 13, 130),
+//	)
+//	validate.Next(createAccount, flow.ValidAction)
+//	validate.Next(reportErrors, flow.InvalidAction)
+func NewValidationNode(key string, rules ...Rule) *Node {
+	n := NewNode()
+	n.SetPrepFunc(func(shared *SharedState) interface{} {
+		return shared.Get(key)
+	})
+	n.SetExecFunc(func(prep interface{}) (interface{}, error) {
+		return validate(prep, rules), nil
+	})
+	n.SetPostFunc(func(shared *SharedState, prep, exec interface{}) string {
+		violations := exec.([]Violation)
+		shared.Set(ViolationsKey, violations)
+		if len(violations) > 0 {
+			return InvalidAction
+		}
+		return ValidAction
+	})
+	return n
+}
+
+// validate checks every rule against value
+func validate(value interface{}, rules []Rule) []Violation {
+	violations := []Violation{}
+	for _, rule := range rules {
+		field, present := lookupField(value, rule.field)
+		if message := rule.check(field, present); message != "" {
+			violations = append(violations, Violation{Field: rule.field, Message: message})
+		}
+	}
+	return violations
+}
+
+// lookupField follows a dotted path through maps and structs
+func lookupField(value interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return value, value != nil
+	}
+	current := reflect.ValueOf(value)
+	for _, name := range strings.Split(path, ".") {
+		for current.Kind() == reflect.Pointer || current.Kind() == reflect.Interface {
+			if current.IsNil() {
+				return nil, false
+			}
+			current = current.Elem()
+		}
+
+		switch current.Kind() {
+		case reflect.Map:
+			if current.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			current = current.MapIndex(reflect.ValueOf(name).Convert(current.Type().Key()))
+		case reflect.Struct:
+			current = structField(current, name)
+		default:
+			return nil, false
+		}
+		if !current.IsValid() {
+			return nil, false
+		}
+	}
+	if !current.CanInterface() {
+		return nil, false
+	}
+	return current.Interface(), true
+}
+
+// structField returns the exported field with the given name or json tag
+func structField(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Name == name || tag == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// isEmptyValue reports whether a present value counts as missing for Required
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// toFloat converts any integer or float value to float64
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
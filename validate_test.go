@@ -0,0 +1,87 @@
+package Flow
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidationNode tests routing and collected violations for a map value
+func TestValidationNode(t *testing.T) {
+	validate := NewValidationNode("signup",
+		Required("email"),
+		Format("email", `^[^@\s]+@[^@\s]+$`),
+		Range("age", 13, 130),
+		Required("address.city"),
+	)
+
+	state := NewSharedState()
+	state.Set("signup", map[string]interface{}{
+		"email":   "ada@example.com",
+		"age":     36,
+		"address": map[string]interface{}{"city": "London"},
+	})
+	if action := validate.Run(state); action != ValidAction {
+		t.Errorf("Expected %q, got %q", ValidAction, action)
+	}
+	if violations := state.Get(ViolationsKey).([]Violation); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	state.Set("signup", map[string]interface{}{"email": "not-an-email", "age": 7.5})
+	if action := validate.Run(state); action != InvalidAction {
+		t.Errorf("Expected %q, got %q", InvalidAction, action)
+	}
+	violations := state.Get(ViolationsKey).([]Violation)
+	expected := []string{
+		`email: must match ^[^@\s]+@[^@\s]+$`,
+		"age: must be between 13 and 130",
+		"address.city: is required",
+	}
+	if len(violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %v", len(expected), violations)
+	}
+	for i, v := range violations {
+		if v.Error() != expected[i] {
+			t.Errorf("Expected violation %q, got %q", expected[i], v.Error())
+		}
+	}
+}
+
+// TestValidationNodeStruct tests field lookup on structs by name and json tag
+func TestValidationNodeStruct(t *testing.T) {
+	type order struct {
+		ID       string `json:"id"`
+		Quantity int
+		Notes    *string
+	}
+
+	positive := Check("Quantity", func(value interface{}) error {
+		if value.(int) <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	})
+	validate := NewValidationNode("order", Required("id"), positive, Required("Notes"), Range("Missing", 0, 1))
+
+	state := NewSharedState()
+	state.Set("order", &order{Quantity: 0})
+	if action := validate.Run(state); action != InvalidAction {
+		t.Errorf("Expected %q, got %q", InvalidAction, action)
+	}
+	var fields []string
+	for _, v := range state.Get(ViolationsKey).([]Violation) {
+		fields = append(fields, v.Field)
+	}
+	if len(fields) != 3 || fields[0] != "id" || fields[1] != "Quantity" || fields[2] != "Notes" {
+		t.Errorf("Expected id, Quantity and Notes violations, got %v", fields)
+	}
+
+	// Rules on the value itself use the empty field path
+	state.Set("count", 3)
+	if action := NewValidationNode("count", Required(""), Range("", 5, 10)).Run(state); action != InvalidAction {
+		t.Errorf("Expected %q for out-of-range value, got %q", InvalidAction, action)
+	}
+	if action := NewValidationNode("absent", Required("")).Run(state); action != InvalidAction {
+		t.Errorf("Expected %q for missing value, got %q", InvalidAction, action)
+	}
+}
@@ -0,0 +1,202 @@
+package Flow
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Warning kinds reported to a WarningCollector
+const (
+	// WarnSuccessorOverwritten is raised when Next replaces a node's edge for an
+	// action with an edge to a different node
+	WarnSuccessorOverwritten = "successor_overwritten"
+	// WarnMissingTransition is raised when a node with successors returns an
+	// action that none of them (nor "default") handles, ending the flow there
+	WarnMissingTransition = "missing_transition"
+	// WarnIgnoredParam is raised when a node runs with a param that has no effect
+	// without another one, such as "retry_delay" without "retries"
+	WarnIgnoredParam = "ignored_param"
+	// WarnResultsOverwritten is raised when a batch node replaces the results
+	// another node stored under the same "results_key"
+	WarnResultsOverwritten = "results_overwritten"
+)
+
+// Warning describes a likely mistake that doesn't stop the flow
+type Warning struct {
+	// Kind is one of the Warn constants
+	Kind string
+	// Node is the name of the node the warning is about
+	Node string
+	// Flow and RunID identify the run that raised it; both are "" for warnings
+	// raised while building a graph
+	Flow, RunID string
+	// Message describes the problem
+	Message string
+	// Fields holds the structured details, such as the action or param involved
+	Fields map[string]interface{}
+}
+
+// WarningCollector gathers the warnings raised by nodes and flows once registered
+// with SetWarningCollector, and passes each to the handlers registered with
+// OnWarning as it is raised, e.g. to fail a test or count them in metrics.
+// It is safe for concurrent use.
+//
+// Example:
+//
+//	warnings := flow.NewWarningCollector()
+//	warnings.OnWarning(func(w flow.Warning) {
+//		log.Printf("%s: %s %v", w.Node, w.Message, w.Fields)
+//	})
+//	flow.SetWarningCollector(warnings)
+type WarningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning
+	handlers []func(Warning)
+}
+
+// NewWarningCollector creates an empty collector
+func NewWarningCollector() *WarningCollector {
+	return &WarningCollector{}
+}
+
+// OnWarning registers fn to be called with every warning added from now on.
+// Handlers are called synchronously on the goroutine raising the warning.
+func (c *WarningCollector) OnWarning(fn func(Warning)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, fn)
+}
+
+// Add records w and passes it to the handlers
+func (c *WarningCollector) Add(w Warning) {
+	c.mu.Lock()
+	c.warnings = append(c.warnings, w)
+	handlers := c.handlers
+	c.mu.Unlock()
+	for _, fn := range handlers {
+		fn(w)
+	}
+}
+
+// Warnings returns the warnings recorded so far, oldest first
+func (c *WarningCollector) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Warning(nil), c.warnings...)
+}
+
+// Reset discards the recorded warnings, keeping the handlers
+func (c *WarningCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = nil
+}
+
+var warningCollector atomic.Pointer[WarningCollector]
+
+// SetWarningCollector sets the collector that warnings are added to; nil stops
+// collecting. Warnings raised during a run are also logged through RunLogger
+// either way.
+func SetWarningCollector(c *WarningCollector) {
+	warningCollector.Store(c)
+}
+
+// warn raises a warning about node n, logging it through the run logger when
+// raised during a run on shared (shared is nil while building a graph)
+func (n *Node) warn(shared *SharedState, kind, message string, fields map[string]interface{}) {
+	w := Warning{Kind: kind, Node: debugNodeName(n.name), Message: message, Fields: fields}
+	if shared != nil {
+		scope := shared.runScope()
+		scope.mu.RLock()
+		w.Flow, w.RunID = scope.flow, scope.runID
+		scope.mu.RUnlock()
+
+		args := make([]interface{}, 0, 2*len(fields))
+		for _, key := range sortedKeys(fields) {
+			args = append(args, key, fields[key])
+		}
+		RunLogger(shared).Warn(message, args...)
+	}
+	if c := warningCollector.Load(); c != nil {
+		c.Add(w)
+	}
+}
+
+// paramPrerequisites maps params to the params of which at least one must be set
+// for them to have any effect
+var paramPrerequisites = map[string][]string{
+	"retry_delay":           {"retries"},
+	"retry_multiplier":      {"retries"},
+	"retry_max_delay":       {"retries"},
+	"retry_jitter":          {"retries"},
+	"retry_jitter_strategy": {"retries"},
+	"retry_budget":          {"retries"},
+	"retry_max_elapsed":     {"retries"},
+	"seed":                  {"deterministic"},
+	"adaptive_latency":      {"adaptive_concurrency"},
+	"cache_backend":         {"cache"},
+	"cache_ttl":             {"cache"},
+	"cache_stale":           {"cache"},
+	"bulkhead_queue":        {"bulkhead"},
+	"bulkhead_shed":         {"bulkhead"},
+	"priority":              {"scheduler"},
+	"reserve_tokens":        {"model", "context_window"},
+	"context_policy":        {"model", "context_window"},
+}
+
+// ignoredParams returns the params that have no effect because none of their
+// prerequisites is set, in lexical order
+func ignoredParams(params Params) []string {
+	var ignored []string
+	for key := range params {
+		prerequisites, ok := paramPrerequisites[key]
+		if !ok {
+			continue
+		}
+		enabled := false
+		for _, p := range prerequisites {
+			enabled = enabled || paramSet(params[p])
+		}
+		if !enabled {
+			ignored = append(ignored, key)
+		}
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+// paramSet reports whether a param value enables the behavior it controls: a
+// true bool, a positive number or a non-empty string
+func paramSet(value interface{}) bool {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() > 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() > 0
+	case reflect.String:
+		return v.Len() > 0
+	case reflect.Invalid:
+		return false
+	}
+	return true
+}
+
+// warnIgnoredParams raises a WarnIgnoredParam warning for each ignored param
+func (n *Node) warnIgnoredParams(shared *SharedState, params Params) {
+	for _, key := range ignoredParams(params) {
+		requires := paramPrerequisites[key]
+		quoted := make([]string, len(requires))
+		for i, p := range requires {
+			quoted[i] = strconv.Quote(p)
+		}
+		n.warn(shared, WarnIgnoredParam, fmt.Sprintf("flow: param %q has no effect without %s", key, strings.Join(quoted, " or ")),
+			map[string]interface{}{"param": key, "requires": requires})
+	}
+}
@@ -0,0 +1,167 @@
+package Flow
+
+import (
+	"sync"
+	"testing"
+)
+
+// collectWarnings registers a fresh collector for the duration of the test
+func collectWarnings(t *testing.T) *WarningCollector {
+	warnings := NewWarningCollector()
+	SetWarningCollector(warnings)
+	t.Cleanup(func() { SetWarningCollector(nil) })
+	return warnings
+}
+
+// TestWarningCollector tests that added warnings are recorded and passed to handlers
+func TestWarningCollector(t *testing.T) {
+	warnings := NewWarningCollector()
+	var handled []string
+	warnings.OnWarning(func(w Warning) { handled = append(handled, w.Kind) })
+
+	warnings.Add(Warning{Kind: WarnIgnoredParam})
+	warnings.Add(Warning{Kind: WarnMissingTransition})
+	if got := warnings.Warnings(); len(got) != 2 || got[0].Kind != WarnIgnoredParam || got[1].Kind != WarnMissingTransition {
+		t.Errorf("Expected both warnings in order, got %v", got)
+	}
+	if len(handled) != 2 {
+		t.Errorf("Expected the handler to see both warnings, got %v", handled)
+	}
+
+	warnings.Reset()
+	warnings.Add(Warning{Kind: WarnResultsOverwritten})
+	if got := warnings.Warnings(); len(got) != 1 || len(handled) != 3 {
+		t.Errorf("Expected Reset to clear the warnings but keep the handler, got %v and %v", got, handled)
+	}
+}
+
+// TestWarnSuccessorOverwritten tests the warning for an edge replaced by Next
+func TestWarnSuccessorOverwritten(t *testing.T) {
+	warnings := collectWarnings(t)
+	named := func(name string) *Node {
+		n := NewNode()
+		n.SetName(name)
+		return n
+	}
+	route, approve, reject := named("route"), named("approve"), named("reject")
+
+	route.Next(approve, "ok")
+	route.Next(approve, "ok")
+	if got := warnings.Warnings(); len(got) != 0 {
+		t.Fatalf("Expected no warning for repeating an edge, got %v", got)
+	}
+
+	route.Next(reject, "ok")
+	got := warnings.Warnings()
+	if len(got) != 1 || got[0].Kind != WarnSuccessorOverwritten || got[0].Node != "route" {
+		t.Fatalf("Expected one successor_overwritten warning for route, got %v", got)
+	}
+	if f := got[0].Fields; f["action"] != "ok" || f["previous"] != "approve" || f["next"] != "reject" {
+		t.Errorf("Expected the action and both targets in the fields, got %v", f)
+	}
+}
+
+// TestWarnMissingTransition tests the warning for an action no successor handles
+func TestWarnMissingTransition(t *testing.T) {
+	warnings := collectWarnings(t)
+	action := "retry"
+	check := NewNode()
+	check.SetName("check")
+	check.SetPostFunc(func(*SharedState, interface{}, interface{}) string { return action })
+	check.Next(NewNode(), "done")
+
+	NewFlow().Start(check).Run(NewSharedState())
+	got := warnings.Warnings()
+	if len(got) != 1 || got[0].Kind != WarnMissingTransition || got[0].Fields["action"] != "retry" {
+		t.Fatalf("Expected a missing_transition warning for retry, got %v", got)
+	}
+	if got[0].RunID == "" {
+		t.Errorf("Expected the warning to identify the run")
+	}
+
+	warnings.Reset()
+	action = "done"
+	NewFlow().Start(check).Run(NewSharedState())
+	if got := warnings.Warnings(); len(got) != 0 {
+		t.Errorf("Expected no warning for a terminal node, got %v", got)
+	}
+}
+
+// TestWarnIgnoredParam tests the warning for params missing their prerequisite
+func TestWarnIgnoredParam(t *testing.T) {
+	warnings := collectWarnings(t)
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return nil, nil })
+	node.SetParams(map[string]interface{}{"retry_delay": "1ms", "reserve_tokens": 100})
+
+	node.Run(NewSharedState())
+	node.Run(NewSharedState())
+	got := warnings.Warnings()
+	if len(got) != 2 {
+		t.Fatalf("Expected one warning per ignored param for the params set, got %v", got)
+	}
+	if got[0].Fields["param"] != "reserve_tokens" || got[1].Fields["param"] != "retry_delay" {
+		t.Errorf("Expected warnings in param order, got %v", got)
+	}
+	if want := `flow: param "reserve_tokens" has no effect without "model" or "context_window"`; got[0].Message != want {
+		t.Errorf("Expected message %q, got %q", want, got[0].Message)
+	}
+
+	warnings.Reset()
+	node.SetParams(map[string]interface{}{"retry_delay": "1ms", "retries": 2})
+	node.Run(NewSharedState())
+	if got := warnings.Warnings(); len(got) != 0 {
+		t.Errorf("Expected no warning once the prerequisite is set, got %v", got)
+	}
+}
+
+// TestWarnIgnoredParamConcurrentRuns tests that concurrent runs warn once per params set
+func TestWarnIgnoredParamConcurrentRuns(t *testing.T) {
+	warnings := collectWarnings(t)
+	node := NewNode()
+	node.SetExecFunc(func(interface{}) (interface{}, error) { return nil, nil })
+	node.SetParams(map[string]interface{}{"retry_delay": "1ms"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.Run(NewSharedState())
+		}()
+	}
+	wg.Wait()
+	if got := warnings.Warnings(); len(got) != 1 {
+		t.Errorf("Expected one warning across the concurrent runs, got %d", len(got))
+	}
+
+	// Setting the same map again starts a new version to check
+	warnings.Reset()
+	node.SetParams(node.paramMap())
+	node.Run(NewSharedState())
+	if got := warnings.Warnings(); len(got) != 1 {
+		t.Errorf("Expected the params set again to be checked again, got %d warnings", len(got))
+	}
+}
+
+// TestWarnResultsOverwritten tests the warning for batch results replaced by another node
+func TestWarnResultsOverwritten(t *testing.T) {
+	warnings := collectWarnings(t)
+	double := func(item interface{}) (interface{}, error) { return item.(int) * 2, nil }
+	first := NewMapNode("items", double)
+	first.SetName("first")
+	second := NewMapNode("items", double)
+	second.SetName("second")
+
+	state := NewSharedState()
+	state.Set("items", []interface{}{1, 2})
+	first.Run(state)
+	second.Run(state)
+	got := warnings.Warnings()
+	if len(got) != 1 || got[0].Kind != WarnResultsOverwritten || got[0].Node != "second" {
+		t.Fatalf("Expected a results_overwritten warning for second, got %v", got)
+	}
+	if got[0].Fields["key"] != BatchResultsKey || got[0].Fields["previous_node"] != "first" {
+		t.Errorf("Expected the key and previous node in the fields, got %v", got[0].Fields)
+	}
+}